@@ -19,6 +19,38 @@ struct TaikoGethStatus {
     height: u64,
     hash: B256,
     expected_reorg: Option<u64>,
+    // reorgs not caused by this node since the last take_unexpected_reorgs
+    unexpected_reorgs: u64,
+}
+
+impl TaikoGethStatus {
+    fn on_l2_block(&mut self, block: &L2BlockInfo) {
+        if self.height != 0
+            && (block.block_number != self.height + 1 || block.parent_hash != self.hash)
+        {
+            let reorg_expected = match self.expected_reorg {
+                Some(expected) => block.block_number == expected,
+                None => false,
+            };
+            if !reorg_expected {
+                tracing::warn!(
+                    "⛔ Geth reorg detected: Received L2 block with unexpected number. Expected: block_id {} hash {}",
+                    self.height,
+                    self.hash
+                );
+                self.unexpected_reorgs += 1;
+            } else {
+                tracing::debug!(
+                    "Geth reorg detected: Received L2 block with expected number. Expected: block_id {} hash {}",
+                    self.height,
+                    self.hash
+                );
+            }
+        }
+
+        self.height = block.block_number;
+        self.hash = block.block_hash;
+    }
 }
 
 pub struct ChainMonitor {
@@ -48,6 +80,7 @@ impl ChainMonitor {
             height: 0,
             hash: B256::ZERO,
             expected_reorg: None,
+            unexpected_reorgs: 0,
         }));
         Ok(Self {
             ws_l1_rpc_url,
@@ -63,6 +96,12 @@ impl ChainMonitor {
         status.expected_reorg = Some(expected_block_number);
     }
 
+    /// Returns the number of L2 reorgs not expected by this node since the last call.
+    pub async fn take_unexpected_reorgs(&self) -> u64 {
+        let mut status = self.taiko_geth_status.lock().await;
+        std::mem::take(&mut status.unexpected_reorgs)
+    }
+
     /// Spawns the event listeners and the message handler.
     pub async fn start(&self) -> Result<(), Error> {
         debug!("Starting ChainMonitor");
@@ -126,27 +165,44 @@ impl ChainMonitor {
                         "L2 block → number: {}, hash: {}, parent hash: {}",
                         block.block_number, block.block_hash, block.parent_hash,
                     );
-                    {
-                        let mut status = taiko_geth_status.lock().await;
-
-                        if status.height != 0 && (block.block_number != status.height + 1 || block.parent_hash != status.hash) {
-                            let reorg_expected = match status.expected_reorg {
-                                Some(expected) => block.block_number == expected,
-                                None => false,
-                            };
-                            if !reorg_expected {
-                                tracing::warn!("⛔ Geth reorg detected: Received L2 block with unexpected number. Expected: block_id {} hash {}", status.height, status.hash);
-                            } else {
-                                tracing::debug!("Geth reorg detected: Received L2 block with expected number. Expected: block_id {} hash {}", status.height, status.hash);
-                            }
-                        }
-
-                        status.height = block.block_number;
-                        status.hash = block.block_hash;
-                    }
-
+                    taiko_geth_status.lock().await.on_l2_block(&block);
                 }
             }
         }
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn block(block_number: u64, parent: u8, hash: u8) -> L2BlockInfo {
+        L2BlockInfo {
+            block_number,
+            block_hash: B256::repeat_byte(hash),
+            parent_hash: B256::repeat_byte(parent),
+        }
+    }
+
+    #[test]
+    fn test_only_unexpected_reorgs_are_counted() {
+        let mut status = TaikoGethStatus {
+            height: 0,
+            hash: B256::ZERO,
+            expected_reorg: None,
+            unexpected_reorgs: 0,
+        };
+        status.on_l2_block(&block(10, 9, 10));
+        status.on_l2_block(&block(11, 10, 11));
+        assert_eq!(status.unexpected_reorgs, 0);
+
+        // block 11 replaced by another node
+        status.on_l2_block(&block(11, 10, 12));
+        assert_eq!(status.unexpected_reorgs, 1);
+
+        // blocks reanchored by this node
+        status.expected_reorg = Some(10);
+        status.on_l2_block(&block(10, 9, 13));
+        assert_eq!(status.unexpected_reorgs, 1);
+    }
+}
@@ -1,6 +1,6 @@
 use crate::{shared::signer::Signer, utils::config::L1ContractAddresses};
-use alloy::primitives::Address;
-use std::sync::Arc;
+use alloy::{eips::BlockNumberOrTag, primitives::Address};
+use std::{fmt, str::FromStr, sync::Arc};
 use tokio::sync::OnceCell;
 
 #[derive(Clone)]
@@ -47,7 +47,158 @@ pub struct EthereumL1Config {
     pub max_attempts_to_send_tx: u64,
     pub max_attempts_to_wait_tx: u64,
     pub delay_between_tx_attempts_sec: u64,
+    pub max_attempts_to_resubmit_evicted_tx: u64,
+    pub evicted_tx_timeout_sec: u64,
+    pub tip_escalation_percentage_per_sec: u64,
+    pub tip_escalation_cap_percentage: u64,
+    pub receipt_batch_polling_interval_ms: u64,
     pub signer: Arc<Signer>,
     pub preconfer_address: Option<Address>,
     pub extra_gas_percentage: u64,
+    pub validate_sender_authorization: bool,
+    pub blob_fee_fallback: BlobFeeFallback,
+    pub startup_nonce_source: NonceSource,
+}
+
+/// L1 block tag used to pick the L1 head for anchoring L2 blocks
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum L1BlockTag {
+    Latest,
+    Safe,
+    Finalized,
+}
+
+impl FromStr for L1BlockTag {
+    type Err = anyhow::Error;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "latest" => Ok(L1BlockTag::Latest),
+            "safe" => Ok(L1BlockTag::Safe),
+            "finalized" => Ok(L1BlockTag::Finalized),
+            _ => Err(anyhow::anyhow!("Unknown L1 block tag: {}", s)),
+        }
+    }
+}
+
+impl fmt::Display for L1BlockTag {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let s = match self {
+            L1BlockTag::Latest => "latest",
+            L1BlockTag::Safe => "safe",
+            L1BlockTag::Finalized => "finalized",
+        };
+        write!(f, "{s}")
+    }
+}
+
+impl From<L1BlockTag> for BlockNumberOrTag {
+    fn from(tag: L1BlockTag) -> Self {
+        match tag {
+            L1BlockTag::Latest => BlockNumberOrTag::Latest,
+            L1BlockTag::Safe => BlockNumberOrTag::Safe,
+            L1BlockTag::Finalized => BlockNumberOrTag::Finalized,
+        }
+    }
+}
+
+/// What to do when the blob base fee can't be fetched while choosing between blob and calldata proposal
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum BlobFeeFallback {
+    LastKnown,
+    Calldata,
+}
+
+impl FromStr for BlobFeeFallback {
+    type Err = anyhow::Error;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "last_known" => Ok(BlobFeeFallback::LastKnown),
+            "calldata" => Ok(BlobFeeFallback::Calldata),
+            _ => Err(anyhow::anyhow!("Unknown blob fee fallback: {}", s)),
+        }
+    }
+}
+
+impl fmt::Display for BlobFeeFallback {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let s = match self {
+            BlobFeeFallback::LastKnown => "last_known",
+            BlobFeeFallback::Calldata => "calldata",
+        };
+        write!(f, "{s}")
+    }
+}
+
+/// Nonce used by the first batch submission after the startup. The pending nonce skips
+/// the txs sent before the restart and not mined yet, the latest nonce replaces them.
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum NonceSource {
+    Pending,
+    Latest,
+}
+
+impl FromStr for NonceSource {
+    type Err = anyhow::Error;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "pending" => Ok(NonceSource::Pending),
+            "latest" => Ok(NonceSource::Latest),
+            _ => Err(anyhow::anyhow!("Unknown nonce source: {}", s)),
+        }
+    }
+}
+
+impl fmt::Display for NonceSource {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let s = match self {
+            NonceSource::Pending => "pending",
+            NonceSource::Latest => "latest",
+        };
+        write!(f, "{s}")
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_l1_block_tag_from_str() {
+        assert_eq!(L1BlockTag::from_str("latest").unwrap(), L1BlockTag::Latest);
+        assert_eq!(L1BlockTag::from_str("Safe").unwrap(), L1BlockTag::Safe);
+        assert_eq!(
+            L1BlockTag::from_str("finalized").unwrap(),
+            L1BlockTag::Finalized
+        );
+        assert!(L1BlockTag::from_str("pending").is_err());
+    }
+
+    #[test]
+    fn test_blob_fee_fallback_from_str() {
+        assert_eq!(
+            BlobFeeFallback::from_str("last_known").unwrap(),
+            BlobFeeFallback::LastKnown
+        );
+        assert_eq!(
+            BlobFeeFallback::from_str("Calldata").unwrap(),
+            BlobFeeFallback::Calldata
+        );
+        assert!(BlobFeeFallback::from_str("blob").is_err());
+    }
+
+    #[test]
+    fn test_nonce_source_from_str() {
+        assert_eq!(
+            NonceSource::from_str("pending").unwrap(),
+            NonceSource::Pending
+        );
+        assert_eq!(
+            NonceSource::from_str("Latest").unwrap(),
+            NonceSource::Latest
+        );
+        assert!(NonceSource::from_str("safe").is_err());
+    }
 }
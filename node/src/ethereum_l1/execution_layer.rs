@@ -1,5 +1,5 @@
 use super::{
-    config::{ContractAddresses, EthereumL1Config},
+    config::{ContractAddresses, EthereumL1Config, L1BlockTag, NonceSource},
     transaction_error::TransactionError,
 };
 use crate::{
@@ -10,6 +10,7 @@ use crate::{
         },
         monitor_transaction::TransactionMonitor,
         propose_batch_builder::ProposeBatchBuilder,
+        slot_clock::SlotClock,
     },
     forced_inclusion::ForcedInclusionInfo,
     metrics,
@@ -20,14 +21,18 @@ use alloy::{
     eips::BlockNumberOrTag,
     primitives::{Address, B256, U256},
     providers::{DynProvider, Provider},
+    rpc::types::Transaction,
 };
 use anyhow::{Error, anyhow};
 use std::{
-    sync::Arc,
-    time::{SystemTime, UNIX_EPOCH},
+    sync::{
+        Arc, Mutex,
+        atomic::{AtomicBool, Ordering},
+    },
+    time::{Duration, SystemTime, UNIX_EPOCH},
 };
 use tokio::sync::mpsc::Sender;
-use tracing::{debug, info, warn};
+use tracing::{debug, error, info, warn};
 
 const DELAYED_L1_PROPOSAL_BUFFER: u64 = 4;
 
@@ -36,16 +41,23 @@ pub struct ExecutionLayer {
     preconfer_address: Address,
     contract_addresses: ContractAddresses,
     pacaya_config: taiko_inbox::ITaikoInbox::Config,
-    extra_gas_percentage: u64,
+    propose_batch_builder: ProposeBatchBuilder,
     transaction_monitor: TransactionMonitor,
     metrics: Arc<metrics::Metrics>,
     taiko_wrapper_contract: taiko_wrapper::TaikoWrapper::TaikoWrapperInstance<DynProvider>,
     chain_id: u64,
+    validate_sender_authorization: bool,
+    // last epoch in which the sender was authorized to propose
+    sender_authorized_epoch: Mutex<Option<Epoch>>,
+    slot_clock: Arc<SlotClock>,
+    startup_nonce_source: NonceSource,
+    first_submission_sent: AtomicBool,
 }
 
 impl ExecutionLayer {
     pub async fn new(
         config: EthereumL1Config,
+        slot_clock: Arc<SlotClock>,
         transaction_error_channel: Sender<TransactionError>,
         metrics: Arc<metrics::Metrics>,
     ) -> Result<Self, Error> {
@@ -60,7 +72,12 @@ impl ExecutionLayer {
         .await?;
         info!("Catalyst node address: {}", preconfer_address);
 
-        let extra_gas_percentage = config.extra_gas_percentage;
+        let propose_batch_builder = ProposeBatchBuilder::new(
+            provider.clone(),
+            config.extra_gas_percentage,
+            config.blob_fee_fallback,
+        );
+        let validate_sender_authorization = config.validate_sender_authorization;
 
         let taiko_wrapper_contract = taiko_wrapper::TaikoWrapper::new(
             config.contract_addresses.taiko_wrapper,
@@ -88,16 +105,33 @@ impl ExecutionLayer {
                 .await
                 .map_err(|e| Error::msg(format!("Failed to fetch pacaya config: {e}")))?;
 
+        let nonce_latest = get_nonce(&provider, preconfer_address, NonceSource::Latest).await?;
+        let nonce_pending = get_nonce(&provider, preconfer_address, NonceSource::Pending).await?;
+        if nonce_pending != nonce_latest {
+            warn!(
+                "Found {} sent but not mined txs of the preconfer, nonce latest: {}, nonce pending: {}, the first submission uses the {} nonce",
+                nonce_pending.saturating_sub(nonce_latest),
+                nonce_latest,
+                nonce_pending,
+                config.startup_nonce_source
+            );
+        }
+
         Ok(Self {
             provider,
             preconfer_address,
             contract_addresses: config.contract_addresses,
             pacaya_config,
-            extra_gas_percentage,
+            propose_batch_builder,
             transaction_monitor,
             metrics,
             taiko_wrapper_contract,
             chain_id,
+            validate_sender_authorization,
+            sender_authorized_epoch: Mutex::new(None),
+            slot_clock,
+            startup_nonce_source: config.startup_nonce_source,
+            first_submission_sent: AtomicBool::new(false),
         })
     }
 
@@ -139,6 +173,68 @@ impl ExecutionLayer {
         Ok(operator)
     }
 
+    async fn get_fallback_preconfer(&self) -> Result<Address, Error> {
+        let contract = PreconfRouter::new(self.contract_addresses.preconf_router, &self.provider);
+        contract.fallbackPreconfer().call().await.map_err(|e| {
+            Error::msg(format!(
+                "Failed to get fallback preconfer: {}, contract: {:?}",
+                e, self.contract_addresses.preconf_router
+            ))
+        })
+    }
+
+    /// Fails fast when the PreconfRouter would reject the proposal sent by the preconfer,
+    /// e.g. when the node is configured with a wrong signer.
+    async fn check_sender_authorization(&self) -> Result<(), Error> {
+        let operator = self.get_operator_for_current_epoch().await?;
+        let fallback_preconfer = self.get_fallback_preconfer().await?;
+        if !is_authorized_proposer(self.preconfer_address, operator, fallback_preconfer) {
+            error!(
+                "⛔ Sender {} is not authorized to propose batches, operator of the current epoch: {}, fallback preconfer: {}",
+                self.preconfer_address, operator, fallback_preconfer
+            );
+            return Err(anyhow!(TransactionError::SenderNotAuthorized));
+        }
+        Ok(())
+    }
+
+    /// The operator changes only with the epoch, so an authorized sender is not checked
+    /// again before the next epoch.
+    async fn check_sender_authorization_once_per_epoch(&self) -> Result<(), Error> {
+        let epoch = self.slot_clock.get_current_epoch()?;
+        if *self.lock_sender_authorized_epoch()? == Some(epoch) {
+            return Ok(());
+        }
+        self.check_sender_authorization().await?;
+        *self.lock_sender_authorized_epoch()? = Some(epoch);
+        Ok(())
+    }
+
+    fn lock_sender_authorized_epoch(
+        &self,
+    ) -> Result<std::sync::MutexGuard<'_, Option<Epoch>>, Error> {
+        self.sender_authorized_epoch
+            .lock()
+            .map_err(|e| anyhow!("Failed to lock sender authorized epoch: {}", e))
+    }
+
+    /// Returns the sequencer address registered for the preconfer in the PreconfWhitelist.
+    pub async fn get_registered_sequencer_address(&self) -> Result<Address, Error> {
+        let contract =
+            PreconfWhitelist::new(self.contract_addresses.preconf_whitelist, &self.provider);
+        let operator = contract
+            .operators(self.preconfer_address)
+            .call()
+            .await
+            .map_err(|e| {
+                Error::msg(format!(
+                    "Failed to get registered operator {}: {}, contract: {:?}",
+                    self.preconfer_address, e, self.contract_addresses.preconf_whitelist
+                ))
+            })?;
+        Ok(operator.sequencerAddress)
+    }
+
     pub async fn is_transaction_in_progress(&self) -> Result<bool, Error> {
         self.transaction_monitor.is_transaction_in_progress().await
     }
@@ -167,32 +263,18 @@ impl ExecutionLayer {
             return Err(anyhow::anyhow!(TransactionError::EstimationTooEarly));
         }
 
-        let mut tx_vec = Vec::new();
-        let mut blocks = Vec::new();
-
-        for (i, l2_block) in l2_blocks.iter().enumerate() {
-            let count = u16::try_from(l2_block.prebuilt_tx_list.tx_list.len())?;
-            tx_vec.extend(l2_block.prebuilt_tx_list.tx_list.clone());
+        if self.validate_sender_authorization {
+            self.check_sender_authorization_once_per_epoch().await?;
+        }
 
+        for l2_block in &l2_blocks {
             // Emit metrics for transaction count in this block
-            self.metrics.observe_block_tx_count(u64::from(count));
-
-            /* times_shift is the difference in seconds between the current L2 block and the L2 previous block. */
-            let time_shift: u8 = if i == 0 {
-                /* For first block, we don't have a previous block to compare the timestamp with. */
-                0
-            } else {
-                (l2_block.timestamp_sec - l2_blocks[i - 1].timestamp_sec)
-                    .try_into()
-                    .map_err(|e| Error::msg(format!("Failed to convert time shift to u8: {e}")))?
-            };
-            blocks.push(BlockParams {
-                numTransactions: count,
-                timeShift: time_shift,
-                signalSlots: vec![],
-            });
+            self.metrics
+                .observe_block_tx_count(l2_block.prebuilt_tx_list.tx_list.len() as u64);
         }
 
+        let (tx_vec, blocks) = build_batch_blocks(&l2_blocks)?;
+
         let tx_lists_bytes = encode_and_compress(&tx_vec)?;
 
         info!(
@@ -213,8 +295,8 @@ impl ExecutionLayer {
         );
 
         // Build proposeBatch transaction
-        let builder = ProposeBatchBuilder::new(self.provider.clone(), self.extra_gas_percentage);
-        let tx = builder
+        let tx = self
+            .propose_batch_builder
             .build_propose_batch_tx(
                 self.preconfer_address,
                 self.contract_addresses.preconf_router,
@@ -227,12 +309,18 @@ impl ExecutionLayer {
             )
             .await?;
 
-        let pending_nonce = self.get_preconfer_nonce_pending().await?;
+        let nonce_source = submission_nonce_source(
+            self.startup_nonce_source,
+            self.first_submission_sent.load(Ordering::Relaxed),
+        );
+        let (nonce, replaces_pending_tx) =
+            get_submission_nonce(&self.provider, self.preconfer_address, nonce_source).await?;
         // Spawn a monitor for this transaction
         self.transaction_monitor
-            .monitor_new_transaction(tx, pending_nonce)
+            .monitor_new_transaction(tx, nonce, replaces_pending_tx)
             .await
             .map_err(|e| Error::msg(format!("Sending batch to L1 failed: {e}")))?;
+        self.first_submission_sent.store(true, Ordering::Relaxed);
 
         Ok(())
     }
@@ -352,6 +440,25 @@ impl ExecutionLayer {
             .map_err(|e| Error::msg(format!("Failed to get L1 height: {e}")))
     }
 
+    pub async fn get_l1_base_fee(&self) -> Result<u128, Error> {
+        let block = self
+            .provider
+            .get_block_by_number(BlockNumberOrTag::Latest)
+            .await
+            .map_err(|e| Error::msg(format!("Failed to get latest L1 block: {e}")))?
+            .ok_or(anyhow::anyhow!("Failed to get latest L1 block"))?;
+        block
+            .header
+            .base_fee_per_gas
+            .map(u128::from)
+            .ok_or(anyhow::anyhow!("Latest L1 block has no base fee"))
+    }
+
+    /// Returns the number of the L1 block pointed by the given tag.
+    pub async fn get_l1_height_by_tag(&self, tag: L1BlockTag) -> Result<u64, Error> {
+        get_block_number_by_tag(&self.provider, tag).await
+    }
+
     pub async fn get_block_state_root_by_number(&self, number: u64) -> Result<B256, Error> {
         let block = self
             .provider
@@ -375,33 +482,38 @@ impl ExecutionLayer {
     }
 
     pub async fn get_preconfer_nonce_latest(&self) -> Result<u64, Error> {
-        let nonce_str: String = self
-            .provider
-            .client()
-            .request(
-                "eth_getTransactionCount",
-                (self.preconfer_address, "latest"),
-            )
-            .await
-            .map_err(|e| Error::msg(format!("Failed to get nonce: {e}")))?;
-
-        u64::from_str_radix(nonce_str.trim_start_matches("0x"), 16)
-            .map_err(|e| Error::msg(format!("Failed to convert nonce: {e}")))
+        get_nonce(&self.provider, self.preconfer_address, NonceSource::Latest).await
     }
 
     pub async fn get_preconfer_nonce_pending(&self) -> Result<u64, Error> {
-        let nonce_str: String = self
-            .provider
-            .client()
-            .request(
-                "eth_getTransactionCount",
-                (self.preconfer_address, "pending"),
-            )
-            .await
-            .map_err(|e| Error::msg(format!("Failed to get nonce: {e}")))?;
+        get_nonce(&self.provider, self.preconfer_address, NonceSource::Pending).await
+    }
 
-        u64::from_str_radix(nonce_str.trim_start_matches("0x"), 16)
-            .map_err(|e| Error::msg(format!("Failed to convert nonce: {e}")))
+    /// Whether the next submission replaces the txs sent before the startup and not mined yet,
+    /// which is the case for the first submission with the latest startup nonce source.
+    pub fn replaces_pending_txs(&self) -> bool {
+        self.startup_nonce_source == NonceSource::Latest
+            && !self.first_submission_sent.load(Ordering::Relaxed)
+    }
+
+    /// Waits until the txs sent before the startup are mined, unless the first submission
+    /// replaces them.
+    pub async fn wait_for_sent_transactions(&self, poll_interval: Duration) -> Result<(), Error> {
+        if self.replaces_pending_txs() {
+            info!("Not waiting for the sent transactions, the first submission replaces them");
+            return Ok(());
+        }
+        loop {
+            let nonce_latest = self.get_preconfer_nonce_latest().await?;
+            let nonce_pending = self.get_preconfer_nonce_pending().await?;
+            if nonce_pending == nonce_latest {
+                return Ok(());
+            }
+            debug!(
+                "Waiting for sent transactions to be executed. Nonce Latest: {nonce_latest}, Nonce Pending: {nonce_pending}"
+            );
+            tokio::time::sleep(poll_interval).await;
+        }
     }
 
     pub async fn get_block_timestamp_by_number(&self, block: u64) -> Result<u64, Error> {
@@ -481,15 +593,45 @@ impl ExecutionLayer {
     }
 }
 
+/// Returns the transactions of the whole batch and the block params used by proposeBatch.
+/// Single-block batches take the same path, proposeBatch has no multi-block framing to skip:
+/// the txs of all the blocks go into one compressed list and the first block has no time shift.
+fn build_batch_blocks(
+    l2_blocks: &[L2Block],
+) -> Result<(Vec<Transaction>, Vec<BlockParams>), Error> {
+    let mut tx_vec = Vec::new();
+    let mut blocks = Vec::new();
+
+    for (i, l2_block) in l2_blocks.iter().enumerate() {
+        let count = u16::try_from(l2_block.prebuilt_tx_list.tx_list.len())?;
+        tx_vec.extend(l2_block.prebuilt_tx_list.tx_list.clone());
+
+        /* times_shift is the difference in seconds between the current L2 block and the L2 previous block. */
+        let time_shift: u8 = if i == 0 {
+            /* For first block, we don't have a previous block to compare the timestamp with. */
+            0
+        } else {
+            (l2_block.timestamp_sec - l2_blocks[i - 1].timestamp_sec)
+                .try_into()
+                .map_err(|e| Error::msg(format!("Failed to convert time shift to u8: {e}")))?
+        };
+        blocks.push(BlockParams {
+            numTransactions: count,
+            timeShift: time_shift,
+            signalSlots: vec![],
+        });
+    }
+
+    Ok((tx_vec, blocks))
+}
+
 #[cfg(test)]
 impl ExecutionLayer {
     pub async fn new_from_pk(
         ws_rpc_url: String,
         private_key: elliptic_curve::SecretKey<k256::Secp256k1>,
     ) -> Result<Self, Error> {
-        use super::l1_contracts_bindings::taiko_inbox::ITaikoInbox::ForkHeights;
         use crate::Signer;
-        use crate::metrics::Metrics;
         use alloy::providers::ProviderBuilder;
         use alloy::providers::WsConnect;
         use alloy::{network::EthereumWallet, signers::local::PrivateKeySigner};
@@ -509,13 +651,11 @@ impl ExecutionLayer {
 
         let preconfer_address = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"; // some random address for test
 
-        let (tx_error_sender, _) = tokio::sync::mpsc::channel(1);
-
-        let metrics = Arc::new(Metrics::new());
-
-        let ethereum_l1_config = EthereumL1Config {
-            execution_rpc_urls: vec![ws_rpc_url],
-            contract_addresses: ContractAddresses {
+        Self::new_with_provider(
+            provider_ws,
+            ws_rpc_url,
+            preconfer_address.parse()?,
+            ContractAddresses {
                 taiko_inbox: Address::ZERO,
                 taiko_token: OnceCell::new(),
                 preconf_whitelist: Address::ZERO,
@@ -523,25 +663,56 @@ impl ExecutionLayer {
                 taiko_wrapper: Address::ZERO,
                 forced_inclusion_store: Address::ZERO,
             },
+            Arc::new(Signer::PrivateKey(hex::encode(private_key.to_bytes()))),
+        )
+        .await
+    }
+
+    async fn new_with_provider(
+        provider_ws: DynProvider,
+        rpc_url: String,
+        preconfer_address: Address,
+        contract_addresses: ContractAddresses,
+        signer: Arc<crate::Signer>,
+    ) -> Result<Self, Error> {
+        use super::config::BlobFeeFallback;
+        use super::l1_contracts_bindings::taiko_inbox::ITaikoInbox::ForkHeights;
+        use crate::metrics::Metrics;
+
+        let (tx_error_sender, _) = tokio::sync::mpsc::channel(1);
+
+        let metrics = Arc::new(Metrics::new());
+
+        let ethereum_l1_config = EthereumL1Config {
+            execution_rpc_urls: vec![rpc_url],
+            contract_addresses,
             consensus_rpc_url: "".to_string(),
             slot_duration_sec: 12,
             slots_per_epoch: 32,
             preconf_heartbeat_ms: 1000,
-            signer: Arc::new(Signer::PrivateKey(hex::encode(private_key.to_bytes()))),
-            preconfer_address: Some(preconfer_address.parse()?),
+            signer,
+            preconfer_address: Some(preconfer_address),
             min_priority_fee_per_gas_wei: 1000000000000000000,
             tx_fees_increase_percentage: 5,
             max_attempts_to_send_tx: 4,
             max_attempts_to_wait_tx: 4,
             delay_between_tx_attempts_sec: 15,
+            max_attempts_to_resubmit_evicted_tx: 2,
+            evicted_tx_timeout_sec: 36,
+            tip_escalation_percentage_per_sec: 0,
+            tip_escalation_cap_percentage: 1000,
+            receipt_batch_polling_interval_ms: 0,
+            validate_sender_authorization: true,
             extra_gas_percentage: 5,
+            blob_fee_fallback: BlobFeeFallback::LastKnown,
+            startup_nonce_source: NonceSource::Pending,
         };
 
         // Self::new(ethereum_l1_config, tx_error_sender, metrics.clone()).await
 
         Ok(Self {
             provider: provider_ws.clone(),
-            preconfer_address: preconfer_address.parse()?,
+            preconfer_address,
             contract_addresses: ethereum_l1_config.contract_addresses.clone(),
             pacaya_config: taiko_inbox::ITaikoInbox::Config {
                 chainId: 1,
@@ -575,7 +746,11 @@ impl ExecutionLayer {
                 Address::ZERO,
                 provider_ws.clone(),
             ),
-            extra_gas_percentage: 5,
+            propose_batch_builder: ProposeBatchBuilder::new(
+                provider_ws.clone(),
+                5,
+                ethereum_l1_config.blob_fee_fallback,
+            ),
             transaction_monitor: TransactionMonitor::new(
                 provider_ws.clone(),
                 &ethereum_l1_config,
@@ -587,6 +762,11 @@ impl ExecutionLayer {
             .unwrap(),
             metrics,
             chain_id: 1,
+            validate_sender_authorization: true,
+            sender_authorized_epoch: Mutex::new(None),
+            slot_clock: Arc::new(SlotClock::new(0, 0, 12, 32, 2000)),
+            startup_nonce_source: ethereum_l1_config.startup_nonce_source,
+            first_submission_sent: AtomicBool::new(false),
         })
     }
 
@@ -627,22 +807,89 @@ impl ExecutionLayer {
     }
 }
 
+async fn get_nonce(
+    provider: &DynProvider,
+    address: Address,
+    source: NonceSource,
+) -> Result<u64, Error> {
+    let nonce_str: String = provider
+        .client()
+        .request("eth_getTransactionCount", (address, source.to_string()))
+        .await
+        .map_err(|e| Error::msg(format!("Failed to get nonce: {e}")))?;
+
+    u64::from_str_radix(nonce_str.trim_start_matches("0x"), 16)
+        .map_err(|e| Error::msg(format!("Failed to convert nonce: {e}")))
+}
+
+/// Returns the nonce of the submission and whether a sent but not mined tx already uses it.
+async fn get_submission_nonce(
+    provider: &DynProvider,
+    address: Address,
+    source: NonceSource,
+) -> Result<(u64, bool), Error> {
+    let nonce = get_nonce(provider, address, source).await?;
+    if source == NonceSource::Pending {
+        return Ok((nonce, false));
+    }
+    let nonce_pending = get_nonce(provider, address, NonceSource::Pending).await?;
+    Ok((nonce, nonce < nonce_pending))
+}
+
+/// Only the first submission after the startup uses the configured nonce source, the next
+/// ones follow the txs sent by the node itself.
+fn submission_nonce_source(
+    startup_source: NonceSource,
+    first_submission_sent: bool,
+) -> NonceSource {
+    if first_submission_sent {
+        NonceSource::Pending
+    } else {
+        startup_source
+    }
+}
+
+async fn get_block_number_by_tag(provider: &DynProvider, tag: L1BlockTag) -> Result<u64, Error> {
+    if tag == L1BlockTag::Latest {
+        return provider
+            .get_block_number()
+            .await
+            .map_err(|e| Error::msg(format!("Failed to get L1 height: {e}")));
+    }
+
+    let block = provider
+        .get_block_by_number(tag.into())
+        .await
+        .map_err(|e| Error::msg(format!("Failed to get {tag} L1 block: {e}")))?
+        .ok_or_else(|| anyhow!("Failed to get {} L1 block", tag))?;
+    Ok(block.header.number)
+}
+
+/// Preconfer duty of the node in an epoch
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum EpochDuty {
+    Operator,
+    OtherOperator,
+    // the whitelist has no operator for the epoch, nobody preconfirms its slots
+    NoOperator,
+}
+
 pub trait PreconfOperator {
-    async fn is_operator_for_current_epoch(&self) -> Result<bool, Error>;
-    async fn is_operator_for_next_epoch(&self) -> Result<bool, Error>;
+    async fn get_duty_for_current_epoch(&self) -> Result<EpochDuty, Error>;
+    async fn get_duty_for_next_epoch(&self) -> Result<EpochDuty, Error>;
     async fn is_preconf_router_specified_in_taiko_wrapper(&self) -> Result<bool, Error>;
     async fn get_l2_height_from_taiko_inbox(&self) -> Result<u64, Error>;
 }
 
 impl PreconfOperator for ExecutionLayer {
-    async fn is_operator_for_current_epoch(&self) -> Result<bool, Error> {
+    async fn get_duty_for_current_epoch(&self) -> Result<EpochDuty, Error> {
         let operator = self.get_operator_for_current_epoch().await?;
-        Ok(operator == self.preconfer_address)
+        Ok(epoch_duty(operator, self.preconfer_address))
     }
 
-    async fn is_operator_for_next_epoch(&self) -> Result<bool, Error> {
+    async fn get_duty_for_next_epoch(&self) -> Result<EpochDuty, Error> {
         let operator = self.get_operator_for_next_epoch().await?;
-        Ok(operator == self.preconfer_address)
+        Ok(epoch_duty(operator, self.preconfer_address))
     }
 
     async fn is_preconf_router_specified_in_taiko_wrapper(&self) -> Result<bool, Error> {
@@ -655,9 +902,27 @@ impl PreconfOperator for ExecutionLayer {
     }
 }
 
+/// The whitelist returns the zero address when no operator is designated for the epoch.
+fn epoch_duty(operator: Address, preconfer_address: Address) -> EpochDuty {
+    if operator == Address::ZERO {
+        EpochDuty::NoOperator
+    } else if operator == preconfer_address {
+        EpochDuty::Operator
+    } else {
+        EpochDuty::OtherOperator
+    }
+}
+
+/// The PreconfRouter accepts proposals from the operator of the current epoch
+/// and from the fallback preconfer.
+fn is_authorized_proposer(sender: Address, operator: Address, fallback_preconfer: Address) -> bool {
+    sender != Address::ZERO && (sender == operator || sender == fallback_preconfer)
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
+    use crate::shared::test_utils::{rpc_response, rpc_result};
     use alloy::node_bindings::Anvil;
 
     #[tokio::test]
@@ -671,4 +936,301 @@ mod tests {
             .unwrap();
         el.call_test_contract().await.unwrap();
     }
+
+    #[test]
+    fn test_epoch_duty() {
+        let preconfer = Address::repeat_byte(1);
+        assert_eq!(epoch_duty(preconfer, preconfer), EpochDuty::Operator);
+        assert_eq!(
+            epoch_duty(Address::repeat_byte(2), preconfer),
+            EpochDuty::OtherOperator
+        );
+        assert_eq!(epoch_duty(Address::ZERO, preconfer), EpochDuty::NoOperator);
+    }
+
+    #[test]
+    fn test_is_authorized_proposer() {
+        let sender = Address::repeat_byte(1);
+        let other = Address::repeat_byte(2);
+        assert!(is_authorized_proposer(sender, sender, Address::ZERO));
+        assert!(is_authorized_proposer(sender, other, sender));
+        assert!(!is_authorized_proposer(sender, other, Address::ZERO));
+        assert!(!is_authorized_proposer(sender, Address::ZERO, other));
+        // no operator and no fallback preconfer set
+        assert!(!is_authorized_proposer(
+            Address::ZERO,
+            Address::ZERO,
+            Address::ZERO
+        ));
+    }
+
+    // the PreconfWhitelist and the PreconfRouter of the mock L1
+    fn test_contract_addresses() -> ContractAddresses {
+        ContractAddresses {
+            taiko_inbox: Address::ZERO,
+            taiko_token: tokio::sync::OnceCell::new(),
+            preconf_whitelist: Address::repeat_byte(0x11),
+            preconf_router: Address::repeat_byte(0x22),
+            taiko_wrapper: Address::ZERO,
+            forced_inclusion_store: Address::ZERO,
+        }
+    }
+
+    fn abi_encoded_address(address: Address) -> serde_json::Value {
+        format!("0x{:0>64}", hex::encode(address)).into()
+    }
+
+    /// Mock L1 with the given operator of the current epoch and no fallback preconfer,
+    /// the gas estimation of the proposal reverts with TooManyBlocks.
+    async fn setup_authorization_server(
+        server: &mut mockito::ServerGuard,
+        operator: Address,
+        expected_operator_calls: usize,
+        expected_estimations: usize,
+    ) -> (mockito::Mock, mockito::Mock) {
+        let contract_call = |contract: Address| {
+            mockito::Matcher::AllOf(vec![
+                mockito::Matcher::Regex("eth_call".to_string()),
+                mockito::Matcher::Regex(contract.to_string().to_lowercase()),
+            ])
+        };
+        let addresses = test_contract_addresses();
+        let operator_mock = server
+            .mock("POST", "/")
+            .match_body(contract_call(addresses.preconf_whitelist))
+            .with_body_from_request(rpc_result(abi_encoded_address(operator)))
+            .expect(expected_operator_calls)
+            .create_async()
+            .await;
+        server
+            .mock("POST", "/")
+            .match_body(contract_call(addresses.preconf_router))
+            .with_body_from_request(rpc_result(abi_encoded_address(Address::ZERO)))
+            .create_async()
+            .await;
+        server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_blockNumber".to_string()))
+            .with_body_from_request(rpc_result(serde_json::json!("0x80")))
+            .create_async()
+            .await;
+        let estimation_mock = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_estimateGas".to_string()))
+            .with_body_from_request(rpc_response(serde_json::json!({"error": {
+                "code": 3,
+                "message": "execution reverted: custom error 0x7f06d57a",
+            }})))
+            .expect(expected_estimations)
+            .create_async()
+            .await;
+        (operator_mock, estimation_mock)
+    }
+
+    async fn test_execution_layer(server: &mockito::ServerGuard) -> ExecutionLayer {
+        let provider = alloy_tools::create_alloy_provider_without_wallet(&server.url())
+            .await
+            .unwrap();
+        ExecutionLayer::new_with_provider(
+            provider,
+            server.url(),
+            Address::repeat_byte(1),
+            test_contract_addresses(),
+            Arc::new(crate::Signer::PrivateKey(hex::encode([1u8; 32]))),
+        )
+        .await
+        .unwrap()
+    }
+
+    async fn send_test_batch(el: &ExecutionLayer) -> TransactionError {
+        el.send_batch_to_l1(test_l2_blocks(&[1000]), 0, Address::ZERO, 2000, None)
+            .await
+            .unwrap_err()
+            .downcast::<TransactionError>()
+            .unwrap()
+    }
+
+    #[tokio::test]
+    async fn test_authorized_sender_proceeds_with_submission() {
+        let mut server = mockito::Server::new_async().await;
+        // the operator is checked once for both submissions of the epoch
+        let (operator_mock, estimation_mock) =
+            setup_authorization_server(&mut server, Address::repeat_byte(1), 1, 2).await;
+        let el = test_execution_layer(&server).await;
+
+        for _ in 0..2 {
+            // the proposal is built, its estimation reverts on the mock
+            assert!(matches!(
+                send_test_batch(&el).await,
+                TransactionError::BatchTooLarge
+            ));
+        }
+        operator_mock.assert_async().await;
+        estimation_mock.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_unauthorized_sender_halts_submission() {
+        let mut server = mockito::Server::new_async().await;
+        // not authorized senders are checked again on every submission
+        let (operator_mock, estimation_mock) =
+            setup_authorization_server(&mut server, Address::repeat_byte(2), 2, 0).await;
+        let el = test_execution_layer(&server).await;
+
+        for _ in 0..2 {
+            assert!(matches!(
+                send_test_batch(&el).await,
+                TransactionError::SenderNotAuthorized
+            ));
+        }
+        operator_mock.assert_async().await;
+        estimation_mock.assert_async().await;
+    }
+
+    async fn setup_l1_heads_server() -> mockito::ServerGuard {
+        let mut server = mockito::Server::new_async().await;
+        for (body_regex, result) in [
+            (r#""eth_blockNumber""#, serde_json::json!("0x80")),
+            (r#""safe""#, alloy_tools::test_block_json(0x64, 1)),
+            (r#""finalized""#, alloy_tools::test_block_json(0x40, 1)),
+        ] {
+            server
+                .mock("POST", "/")
+                .match_body(mockito::Matcher::Regex(body_regex.to_string()))
+                .with_body(format!(r#"{{"jsonrpc":"2.0","id":0,"result":{result}}}"#))
+                .create_async()
+                .await;
+        }
+        server
+    }
+
+    #[tokio::test]
+    async fn test_get_block_number_by_tag() {
+        let server = setup_l1_heads_server().await;
+        let url = server.url();
+
+        for (tag, expected) in [
+            (L1BlockTag::Latest, 0x80),
+            (L1BlockTag::Safe, 0x64),
+            (L1BlockTag::Finalized, 0x40),
+        ] {
+            // new provider for every call, so the request id always matches the mocked response
+            let provider = alloy_tools::create_alloy_provider_without_wallet(&url)
+                .await
+                .unwrap();
+            assert_eq!(
+                get_block_number_by_tag(&provider, tag).await.unwrap(),
+                expected,
+                "unexpected height for {tag} tag"
+            );
+        }
+    }
+
+    #[tokio::test]
+    async fn test_first_submission_nonce_source() {
+        let mut server = mockito::Server::new_async().await;
+        // a tx sent before the restart is not mined yet
+        for (body_regex, result) in [(r#""latest""#, "0x5"), (r#""pending""#, "0x6")] {
+            server
+                .mock("POST", "/")
+                .match_body(mockito::Matcher::AllOf(vec![
+                    mockito::Matcher::Regex("eth_getTransactionCount".to_string()),
+                    mockito::Matcher::Regex(body_regex.to_string()),
+                ]))
+                .with_body_from_request(rpc_result(serde_json::json!(result)))
+                .create_async()
+                .await;
+        }
+        let provider = alloy_tools::create_alloy_provider_without_wallet(&server.url())
+            .await
+            .unwrap();
+
+        // the latest nonce replaces the pending tx, the next submissions follow it
+        for (startup_source, first_submission) in [
+            (NonceSource::Latest, (5, true)),
+            (NonceSource::Pending, (6, false)),
+        ] {
+            for (first_submission_sent, expected) in [(false, first_submission), (true, (6, false))]
+            {
+                let source = submission_nonce_source(startup_source, first_submission_sent);
+                assert_eq!(
+                    get_submission_nonce(&provider, Address::ZERO, source)
+                        .await
+                        .unwrap(),
+                    expected,
+                    "unexpected nonce for {startup_source} startup source, first submission sent: {first_submission_sent}"
+                );
+            }
+        }
+    }
+
+    #[tokio::test]
+    async fn test_wait_for_sent_transactions_on_startup() {
+        let mut server = mockito::Server::new_async().await;
+        // a tx sent before the restart is not mined yet
+        for (body_regex, result) in [(r#""latest""#, "0x5"), (r#""pending""#, "0x6")] {
+            server
+                .mock("POST", "/")
+                .match_body(mockito::Matcher::AllOf(vec![
+                    mockito::Matcher::Regex("eth_getTransactionCount".to_string()),
+                    mockito::Matcher::Regex(body_regex.to_string()),
+                ]))
+                .with_body_from_request(rpc_result(serde_json::json!(result)))
+                .create_async()
+                .await;
+        }
+        let mut el = test_execution_layer(&server).await;
+
+        // the warmup waits for the pending tx to be mined
+        assert!(!el.replaces_pending_txs());
+        assert!(keeps_waiting_for_sent_transactions(&el).await);
+
+        // the first submission replaces the pending tx, the warmup goes on
+        el.startup_nonce_source = NonceSource::Latest;
+        assert!(el.replaces_pending_txs());
+        assert!(!keeps_waiting_for_sent_transactions(&el).await);
+
+        // the next submissions do not replace it
+        el.first_submission_sent.store(true, Ordering::Relaxed);
+        assert!(!el.replaces_pending_txs());
+        assert!(keeps_waiting_for_sent_transactions(&el).await);
+    }
+
+    async fn keeps_waiting_for_sent_transactions(el: &ExecutionLayer) -> bool {
+        tokio::time::timeout(
+            Duration::from_millis(200),
+            el.wait_for_sent_transactions(Duration::from_millis(10)),
+        )
+        .await
+        .is_err()
+    }
+
+    fn test_l2_blocks(timestamps: &[u64]) -> Vec<L2Block> {
+        let tx_lists = serde_json::from_str::<Vec<crate::shared::l2_tx_lists::PreBuiltTxList>>(
+            include_str!("../utils/tx_lists_test_response_from_geth.json"),
+        )
+        .unwrap();
+        timestamps
+            .iter()
+            .map(|timestamp| L2Block::new_from(tx_lists[0].clone(), *timestamp))
+            .collect()
+    }
+
+    #[test]
+    fn test_build_batch_blocks() {
+        let l2_blocks = test_l2_blocks(&[1000, 1002, 1005]);
+
+        let (txs, blocks) = build_batch_blocks(&l2_blocks).unwrap();
+        assert_eq!(txs.len(), 6);
+        assert_eq!(blocks.len(), 3);
+        assert_eq!(blocks[0].timeShift, 0);
+        assert_eq!(blocks[1].timeShift, 2);
+        assert_eq!(blocks[2].timeShift, 3);
+
+        let (txs, blocks) = build_batch_blocks(&l2_blocks[..1]).unwrap();
+        assert_eq!(txs.len(), 2);
+        assert_eq!(blocks.len(), 1);
+        assert_eq!(blocks[0].numTransactions, 2);
+        assert_eq!(blocks[0].timeShift, 0);
+    }
 }
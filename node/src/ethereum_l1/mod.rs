@@ -4,6 +4,7 @@ pub mod execution_layer;
 pub mod l1_contracts_bindings;
 mod monitor_transaction;
 mod propose_batch_builder;
+mod receipt_poller;
 pub mod slot_clock;
 mod tools;
 pub mod transaction_error;
@@ -46,8 +47,13 @@ impl EthereumL1 {
             config.preconf_heartbeat_ms,
         ));
 
-        let execution_layer =
-            ExecutionLayer::new(config, transaction_error_channel, metrics).await?;
+        let execution_layer = ExecutionLayer::new(
+            config,
+            slot_clock.clone(),
+            transaction_error_channel,
+            metrics,
+        )
+        .await?;
 
         Ok(Self {
             slot_clock,
@@ -1,4 +1,7 @@
-use super::{config::EthereumL1Config, tools, transaction_error::TransactionError};
+use super::{
+    config::EthereumL1Config, receipt_poller::ReceiptPoller, tools,
+    transaction_error::TransactionError,
+};
 use crate::{
     metrics::Metrics,
     shared::{alloy_tools, signer::Signer},
@@ -16,12 +19,18 @@ use alloy::{
 };
 use alloy_json_rpc::RpcError;
 use anyhow::Error;
-use std::{sync::Arc, time::Duration};
+use std::{
+    sync::Arc,
+    time::{Duration, Instant},
+};
 use tokio::sync::Mutex;
 use tokio::sync::mpsc::Sender;
 use tokio::task::JoinHandle;
 use tracing::{debug, error, info, warn};
 
+// min priority fee increase accepted by the mempool for a replacement tx without blobs
+const REPLACEMENT_MIN_BUMP_PERCENTAGE: u128 = 10;
+
 // Transaction status enum
 #[derive(Debug, Clone, PartialEq)]
 pub enum TxStatus {
@@ -30,6 +39,53 @@ pub enum TxStatus {
     Pending,
 }
 
+/// Where a sent transaction can be found on L1
+#[derive(Debug, Clone, PartialEq)]
+enum TxPresence {
+    Mined,
+    InMempool,
+    NotFound,
+}
+
+/// Outcome of increasing the fees for the next sending attempt
+#[derive(Debug, Clone, Copy, PartialEq)]
+enum FeeIncrease {
+    // enough to replace the pending tx
+    Bumped,
+    // below the min bump required by the mempool yet
+    TooSmall,
+    // the priority fee reached the escalation cap
+    Capped,
+}
+
+/// Escalates the priority fee of a pending tx proportionally to the time it has been pending
+#[derive(Debug, Clone, Copy)]
+struct TipEscalationPolicy {
+    percentage_per_sec: u128,
+    cap_percentage: u128,
+}
+
+impl TipEscalationPolicy {
+    fn is_enabled(&self) -> bool {
+        self.percentage_per_sec != 0
+    }
+
+    /// Priority fee after `pending_for`, capped at `cap_percentage` of the initial priority fee
+    fn escalated_priority_fee(&self, initial_priority_fee: u128, pending_for: Duration) -> u128 {
+        let increase = initial_priority_fee
+            .saturating_mul(self.percentage_per_sec)
+            .saturating_mul(u128::from(pending_for.as_secs()))
+            / 100;
+        initial_priority_fee
+            .saturating_add(increase)
+            .min(self.priority_fee_cap(initial_priority_fee))
+    }
+
+    fn priority_fee_cap(&self, initial_priority_fee: u128) -> u128 {
+        (initial_priority_fee.saturating_mul(self.cap_percentage) / 100).max(initial_priority_fee)
+    }
+}
+
 #[derive(Debug, Clone)]
 pub struct TransactionMonitorConfig {
     min_priority_fee_per_gas_wei: u128,
@@ -37,6 +93,9 @@ pub struct TransactionMonitorConfig {
     max_attempts_to_send_tx: u64,
     max_attempts_to_wait_tx: u64,
     delay_between_tx_attempts: Duration,
+    max_attempts_to_resubmit_evicted_tx: u64,
+    evicted_tx_timeout: Duration,
+    tip_escalation: TipEscalationPolicy,
     execution_rpc_urls: Vec<String>,
     preconfer_address: Option<Address>,
     signer: Arc<Signer>,
@@ -45,7 +104,10 @@ pub struct TransactionMonitorConfig {
 pub struct TransactionMonitorThread {
     provider: DynProvider,
     config: TransactionMonitorConfig,
+    receipt_poller: Option<Arc<ReceiptPoller>>,
     nonce: u64,
+    // the nonce is used by a sent but not mined tx, e.g. sent before a restart
+    replaces_pending_tx: bool,
     error_notification_channel: Sender<TransactionError>,
     metrics: Arc<Metrics>,
     chain_id: u64,
@@ -55,6 +117,8 @@ pub struct TransactionMonitorThread {
 pub struct TransactionMonitor {
     provider: DynProvider,
     config: TransactionMonitorConfig,
+    // shared by the monitor threads, None waits for the receipt of the last sent tx only
+    receipt_poller: Option<Arc<ReceiptPoller>>,
     join_handle: Mutex<Option<JoinHandle<()>>>,
     error_notification_channel: Sender<TransactionError>,
     metrics: Arc<Metrics>,
@@ -70,6 +134,14 @@ impl TransactionMonitor {
         metrics: Arc<Metrics>,
         chain_id: u64,
     ) -> Result<Self, Error> {
+        let receipt_batch_polling_interval =
+            Duration::from_millis(config.receipt_batch_polling_interval_ms);
+        let receipt_poller = (!receipt_batch_polling_interval.is_zero()).then(|| {
+            Arc::new(ReceiptPoller::new(
+                provider.clone(),
+                receipt_batch_polling_interval,
+            ))
+        });
         Ok(Self {
             provider,
             config: TransactionMonitorConfig {
@@ -80,10 +152,17 @@ impl TransactionMonitor {
                 delay_between_tx_attempts: Duration::from_secs(
                     config.delay_between_tx_attempts_sec,
                 ),
+                max_attempts_to_resubmit_evicted_tx: config.max_attempts_to_resubmit_evicted_tx,
+                evicted_tx_timeout: Duration::from_secs(config.evicted_tx_timeout_sec),
+                tip_escalation: TipEscalationPolicy {
+                    percentage_per_sec: u128::from(config.tip_escalation_percentage_per_sec),
+                    cap_percentage: u128::from(config.tip_escalation_cap_percentage),
+                },
                 execution_rpc_urls: config.execution_rpc_urls.clone(),
                 preconfer_address: config.preconfer_address,
                 signer: config.signer.clone(),
             },
+            receipt_poller,
             join_handle: Mutex::new(None),
             error_notification_channel,
             metrics,
@@ -95,10 +174,12 @@ impl TransactionMonitor {
 impl TransactionMonitor {
     /// Monitor a transaction until it is confirmed or fails.
     /// Spawns a new tokio task to monitor the transaction.
+    /// A tx replacing a pending one is sent with the fees bumped for the replacement.
     pub async fn monitor_new_transaction(
         &self,
         tx: TransactionRequest,
         nonce: u64,
+        replaces_pending_tx: bool,
     ) -> Result<(), Error> {
         let mut guard = self.join_handle.lock().await;
         if let Some(join_handle) = guard.as_ref()
@@ -112,7 +193,9 @@ impl TransactionMonitor {
         let monitor_thread = TransactionMonitorThread::new(
             self.provider.clone(),
             self.config.clone(),
+            self.receipt_poller.clone(),
             nonce,
+            replaces_pending_tx,
             self.error_notification_channel.clone(),
             self.metrics.clone(),
             self.chain_id,
@@ -132,10 +215,13 @@ impl TransactionMonitor {
 }
 
 impl TransactionMonitorThread {
+    #[allow(clippy::too_many_arguments)]
     pub fn new(
         provider: DynProvider,
         config: TransactionMonitorConfig,
+        receipt_poller: Option<Arc<ReceiptPoller>>,
         nonce: u64,
+        replaces_pending_tx: bool,
         error_notification_channel: Sender<TransactionError>,
         metrics: Arc<Metrics>,
         chain_id: u64,
@@ -143,7 +229,9 @@ impl TransactionMonitorThread {
         Self {
             provider,
             config,
+            receipt_poller,
             nonce,
+            replaces_pending_tx,
             error_notification_channel,
             metrics,
             chain_id,
@@ -193,13 +281,27 @@ impl TransactionMonitorThread {
             max_priority_fee_per_gas += diff;
         }
 
+        if self.replaces_pending_tx {
+            info!(
+                "Tx nonce {} replaces a sent but not mined tx, bumping its fees",
+                self.nonce
+            );
+            bump_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+            );
+        }
+
+        let initial_priority_fee_per_gas = max_priority_fee_per_gas;
+        let first_sent_at = Instant::now();
         let mut root_provider: Option<RootProvider<alloy::network::Ethereum>> = None;
         let mut l1_block_at_send = 0;
 
         self.metrics.inc_batch_proposed();
         // Sending attempts loop
         let mut tx_hashes = Vec::new();
-        for sending_attempt in 0..self.config.max_attempts_to_send_tx {
+        'sending: for sending_attempt in 0..self.config.max_attempts_to_send_tx {
             let mut tx_clone = tx.clone();
             self.set_tx_parameters(
                 &mut tx_clone,
@@ -257,7 +359,7 @@ impl TransactionMonitorThread {
             if self
                 .is_transaction_handled_by_builder(
                     pending_tx.provider().clone(),
-                    tx_hash,
+                    &tx_hashes,
                     l1_block_at_send,
                     sending_attempt,
                 )
@@ -266,40 +368,134 @@ impl TransactionMonitorThread {
                 return;
             }
 
-            // increase fees for next attempt
-            // replacement requires 100% more for penalty
-            max_fee_per_gas += max_fee_per_gas;
-            max_priority_fee_per_gas += max_priority_fee_per_gas;
-            if let Some(max_fee_per_blob_gas) = &mut max_fee_per_blob_gas {
-                *max_fee_per_blob_gas += *max_fee_per_blob_gas;
+            // increase fees for next attempt, keep waiting while the increase is too small
+            loop {
+                match self.increase_fees(
+                    &mut max_fee_per_gas,
+                    &mut max_priority_fee_per_gas,
+                    &mut max_fee_per_blob_gas,
+                    initial_priority_fee_per_gas,
+                    first_sent_at.elapsed(),
+                ) {
+                    FeeIncrease::Bumped => break,
+                    FeeIncrease::TooSmall => {
+                        debug!(
+                            "Priority fee increase for tx nonce {} too small to replace it, waiting",
+                            self.nonce
+                        );
+                        if self
+                            .is_transaction_handled_by_builder(
+                                pending_tx.provider().clone(),
+                                &tx_hashes,
+                                l1_block_at_send,
+                                sending_attempt,
+                            )
+                            .await
+                        {
+                            return;
+                        }
+                    }
+                    FeeIncrease::Capped => {
+                        info!(
+                            "Priority fee of tx nonce {} reached the escalation cap, waiting without replacing",
+                            self.nonce
+                        );
+                        break 'sending;
+                    }
+                }
             }
         }
 
         //Wait for transaction result
         let mut wait_attempt = 0;
+        let mut evicted_tx_resubmissions = 0;
+        let mut not_found_since: Option<Instant> = None;
         if let Some(root_provider) = root_provider {
-            // We can use unwrap since tx_hashes is updated before root_provider
-            let tx_hash = tx_hashes
-                .last()
-                .expect("assert: tx_hashes is updated before root_provider");
-            while wait_attempt < self.config.max_attempts_to_wait_tx
-                && !self
+            while wait_attempt < self.config.max_attempts_to_wait_tx {
+                if self
                     .is_transaction_handled_by_builder(
                         root_provider.clone(),
-                        *tx_hash,
+                        &tx_hashes,
                         l1_block_at_send,
                         self.config.max_attempts_to_send_tx,
                     )
                     .await
-                && !self
-                    .verify_tx_included(
-                        &tx_hashes,
-                        wait_attempt + self.config.max_attempts_to_send_tx,
-                    )
-                    .await
-            {
+                    || self
+                        .verify_tx_included(
+                            &tx_hashes,
+                            wait_attempt + self.config.max_attempts_to_send_tx,
+                        )
+                        .await
+                {
+                    return;
+                }
                 warn!("🟣 Transaction watcher timed out without a result. Waiting...");
                 wait_attempt += 1;
+
+                // A mined tx (successful or reverted) is reported by is_transaction_handled_by_builder,
+                // here we only look for a tx which silently disappeared from the mempool
+                let presence = self.get_tx_presence(&tx_hashes).await;
+                let not_found_for = if presence == TxPresence::NotFound {
+                    not_found_since.get_or_insert_with(Instant::now).elapsed()
+                } else {
+                    not_found_since = None;
+                    Duration::ZERO
+                };
+                if evicted_tx_resubmissions >= self.config.max_attempts_to_resubmit_evicted_tx
+                    || !is_tx_evicted(&presence, not_found_for, self.config.evicted_tx_timeout)
+                {
+                    continue;
+                }
+
+                let mut tx_clone = tx.clone();
+                self.set_tx_parameters(
+                    &mut tx_clone,
+                    max_fee_per_gas,
+                    max_priority_fee_per_gas,
+                    max_fee_per_blob_gas,
+                );
+                l1_block_at_send = match self.provider.get_block_number().await {
+                    Ok(block_number) => block_number,
+                    Err(e) => {
+                        error!("Failed to get L1 block number: {}", e);
+                        self.send_error_signal(TransactionError::GetBlockNumberFailed)
+                            .await;
+                        return;
+                    }
+                };
+                let sending_attempt =
+                    self.config.max_attempts_to_send_tx + evicted_tx_resubmissions;
+                let pending_tx = if let Some(pending_tx) = self
+                    .send_transaction(tx_clone, &tx_hashes, sending_attempt)
+                    .await
+                {
+                    pending_tx
+                } else {
+                    return;
+                };
+                let tx_hash = *pending_tx.tx_hash();
+                tx_hashes.push(tx_hash);
+                evicted_tx_resubmissions += 1;
+                info!(
+                    "🟠 Resubmit evicted tx nonce: {}, resubmission: {}, l1_block: {}, hash: {},  max_fee_per_gas: {}, max_priority_fee_per_gas: {}, max_fee_per_blob_gas: {:?}",
+                    self.nonce,
+                    evicted_tx_resubmissions,
+                    l1_block_at_send,
+                    tx_hash,
+                    max_fee_per_gas,
+                    max_priority_fee_per_gas,
+                    max_fee_per_blob_gas
+                );
+                // an evicted tx is not replaced, so the fees stay as they are once capped
+                self.increase_fees(
+                    &mut max_fee_per_gas,
+                    &mut max_priority_fee_per_gas,
+                    &mut max_fee_per_blob_gas,
+                    initial_priority_fee_per_gas,
+                    first_sent_at.elapsed(),
+                );
+                not_found_since = None;
+                wait_attempt = 0;
             }
         }
 
@@ -322,13 +518,22 @@ impl TransactionMonitorThread {
     async fn is_transaction_handled_by_builder(
         &self,
         root_provider: RootProvider<alloy::network::Ethereum>,
-        tx_hash: B256,
+        tx_hashes: &[B256],
         l1_block_at_send: u64,
         sending_attempt: u64,
     ) -> bool {
+        // tx_hashes is updated before root_provider
+        let tx_hash = *tx_hashes
+            .last()
+            .expect("assert: tx_hashes is updated before root_provider");
         loop {
-            let check_tx = PendingTransactionBuilder::new(root_provider.clone(), tx_hash);
-            let tx_status = self.wait_for_tx_receipt(check_tx, sending_attempt).await;
+            let tx_status = if let Some(receipt_poller) = &self.receipt_poller {
+                self.poll_tx_receipts(receipt_poller, tx_hashes, sending_attempt)
+                    .await
+            } else {
+                let check_tx = PendingTransactionBuilder::new(root_provider.clone(), tx_hash);
+                self.wait_for_tx_receipt(check_tx, sending_attempt).await
+            };
             match tx_status {
                 TxStatus::Confirmed(_) => return true,
                 TxStatus::Failed(err_str) => {
@@ -492,6 +697,27 @@ impl TransactionMonitorThread {
         false
     }
 
+    async fn get_tx_presence(&self, tx_hashes: &Vec<B256>) -> TxPresence {
+        let mut presence = TxPresence::NotFound;
+        for tx_hash in tx_hashes {
+            match self.provider.get_transaction_by_hash(*tx_hash).await {
+                Ok(Some(tx)) => {
+                    if tx.block_number.is_some() {
+                        return TxPresence::Mined;
+                    }
+                    presence = TxPresence::InMempool;
+                }
+                Ok(None) => {}
+                Err(e) => {
+                    // Do not treat RPC failures as an eviction
+                    warn!("Failed to get transaction {}: {}", tx_hash, e);
+                    presence = TxPresence::InMempool;
+                }
+            }
+        }
+        presence
+    }
+
     async fn wait_for_tx_receipt<N: Network>(
         &self,
         pending_tx: PendingTransactionBuilder<N>,
@@ -505,36 +731,8 @@ impl TransactionMonitorThread {
 
         match receipt {
             Ok(receipt) => {
-                if receipt.status() {
-                    let block_number = if let Some(block_number) = receipt.block_number() {
-                        block_number
-                    } else {
-                        warn!("Block number not found for transaction {}", tx_hash);
-                        0
-                    };
-
-                    info!(
-                        "✅ Transaction {} confirmed in block {}",
-                        tx_hash, block_number
-                    );
-                    self.metrics.observe_batch_propose_tries(sending_attempt);
-                    self.metrics.inc_batch_confirmed();
-                    TxStatus::Confirmed(block_number)
-                } else if let Some(block_number) = receipt.block_number() {
-                    let revert_reason = crate::shared::alloy_tools::check_for_revert_reason(
-                        &self.provider,
-                        tx_hash,
-                        block_number,
-                    )
-                    .await;
-                    error!("Transaction {} reverted: {}", tx_hash, revert_reason);
-                    TxStatus::Failed(revert_reason)
-                } else {
-                    let error_msg =
-                        format!("Transaction {tx_hash} failed, but block number not found");
-                    error!("{}", error_msg);
-                    TxStatus::Failed(error_msg)
-                }
+                self.get_receipt_status(tx_hash, &receipt, sending_attempt)
+                    .await
             }
             Err(e) => match e {
                 PendingTransactionError::TxWatcher(WatchTxError::Timeout) => {
@@ -549,6 +747,90 @@ impl TransactionMonitorThread {
         }
     }
 
+    /// Polls the receipts of all the txs sent for the nonce, any of them can get mined.
+    async fn poll_tx_receipts(
+        &self,
+        receipt_poller: &ReceiptPoller,
+        tx_hashes: &[B256],
+        sending_attempt: u64,
+    ) -> TxStatus {
+        match receipt_poller
+            .wait_for_receipt(tx_hashes, self.config.delay_between_tx_attempts)
+            .await
+        {
+            Some((tx_hash, receipt)) => {
+                self.get_receipt_status(tx_hash, &receipt, sending_attempt)
+                    .await
+            }
+            None => TxStatus::Pending,
+        }
+    }
+
+    async fn get_receipt_status<R: ReceiptResponse>(
+        &self,
+        tx_hash: B256,
+        receipt: &R,
+        sending_attempt: u64,
+    ) -> TxStatus {
+        if receipt.status() {
+            let block_number = if let Some(block_number) = receipt.block_number() {
+                block_number
+            } else {
+                warn!("Block number not found for transaction {}", tx_hash);
+                0
+            };
+
+            info!(
+                "✅ Transaction {} confirmed in block {}",
+                tx_hash, block_number
+            );
+            self.metrics.observe_batch_propose_tries(sending_attempt);
+            self.metrics.inc_batch_confirmed();
+            TxStatus::Confirmed(block_number)
+        } else if let Some(block_number) = receipt.block_number() {
+            let revert_reason = crate::shared::alloy_tools::check_for_revert_reason(
+                &self.provider,
+                tx_hash,
+                block_number,
+            )
+            .await;
+            error!("Transaction {} reverted: {}", tx_hash, revert_reason);
+            TxStatus::Failed(revert_reason)
+        } else {
+            let error_msg = format!("Transaction {tx_hash} failed, but block number not found");
+            error!("{}", error_msg);
+            TxStatus::Failed(error_msg)
+        }
+    }
+
+    /// Increases the fees for the next sending attempt, by fixed bumps or escalated
+    /// by the pending time when the tip escalation is enabled.
+    fn increase_fees(
+        &self,
+        max_fee_per_gas: &mut u128,
+        max_priority_fee_per_gas: &mut u128,
+        max_fee_per_blob_gas: &mut Option<u128>,
+        initial_priority_fee_per_gas: u128,
+        pending_for: Duration,
+    ) -> FeeIncrease {
+        let tip_escalation = &self.config.tip_escalation;
+        if !tip_escalation.is_enabled() {
+            bump_fees_for_replacement(
+                max_fee_per_gas,
+                max_priority_fee_per_gas,
+                max_fee_per_blob_gas,
+            );
+            return FeeIncrease::Bumped;
+        }
+        escalate_fees_for_replacement(
+            max_fee_per_gas,
+            max_priority_fee_per_gas,
+            max_fee_per_blob_gas,
+            tip_escalation.escalated_priority_fee(initial_priority_fee_per_gas, pending_for),
+            tip_escalation.priority_fee_cap(initial_priority_fee_per_gas),
+        )
+    }
+
     fn set_tx_parameters(
         &self,
         tx: &mut TransactionRequest,
@@ -573,3 +855,557 @@ impl TransactionMonitorThread {
         );
     }
 }
+
+/// Replacement requires 100% more for penalty
+fn bump_fees_for_replacement(
+    max_fee_per_gas: &mut u128,
+    max_priority_fee_per_gas: &mut u128,
+    max_fee_per_blob_gas: &mut Option<u128>,
+) {
+    *max_fee_per_gas += *max_fee_per_gas;
+    *max_priority_fee_per_gas += *max_priority_fee_per_gas;
+    if let Some(max_fee_per_blob_gas) = max_fee_per_blob_gas {
+        *max_fee_per_blob_gas += *max_fee_per_blob_gas;
+    }
+}
+
+/// Replacement with the escalated priority fee, the max fees are raised in the same
+/// proportion. Keeps the fees when the escalated priority fee is below the min bump
+/// required by the mempool. A blob tx is replaced only with all its fees at least doubled,
+/// so the escalated fees are taken only when they are higher than the doubled ones.
+fn escalate_fees_for_replacement(
+    max_fee_per_gas: &mut u128,
+    max_priority_fee_per_gas: &mut u128,
+    max_fee_per_blob_gas: &mut Option<u128>,
+    escalated_priority_fee_per_gas: u128,
+    priority_fee_cap: u128,
+) -> FeeIncrease {
+    let previous_priority_fee_per_gas = *max_priority_fee_per_gas;
+    let escalate = |fee: u128| {
+        fee.saturating_mul(escalated_priority_fee_per_gas) / previous_priority_fee_per_gas.max(1)
+    };
+    if let Some(max_fee_per_blob_gas) = max_fee_per_blob_gas {
+        *max_fee_per_gas = escalate(*max_fee_per_gas)
+            .max(escalated_priority_fee_per_gas)
+            .max(max_fee_per_gas.saturating_mul(2));
+        *max_priority_fee_per_gas =
+            escalated_priority_fee_per_gas.max(previous_priority_fee_per_gas.saturating_mul(2));
+        *max_fee_per_blob_gas =
+            escalate(*max_fee_per_blob_gas).max(max_fee_per_blob_gas.saturating_mul(2));
+        return FeeIncrease::Bumped;
+    }
+    let min_priority_fee_per_gas = (previous_priority_fee_per_gas
+        + previous_priority_fee_per_gas * REPLACEMENT_MIN_BUMP_PERCENTAGE / 100)
+        .max(previous_priority_fee_per_gas + 1);
+    if escalated_priority_fee_per_gas < min_priority_fee_per_gas {
+        return if escalated_priority_fee_per_gas >= priority_fee_cap {
+            FeeIncrease::Capped
+        } else {
+            FeeIncrease::TooSmall
+        };
+    }
+    *max_fee_per_gas = escalate(*max_fee_per_gas).max(escalated_priority_fee_per_gas);
+    *max_priority_fee_per_gas = escalated_priority_fee_per_gas;
+    FeeIncrease::Bumped
+}
+
+/// A tx is considered evicted when none of its hashes is known to the L1 node
+/// for at least `eviction_timeout`
+fn is_tx_evicted(
+    presence: &TxPresence,
+    not_found_for: Duration,
+    eviction_timeout: Duration,
+) -> bool {
+    *presence == TxPresence::NotFound && not_found_for >= eviction_timeout
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::ethereum_l1::receipt_poller::tests::receipt;
+    use alloy::{
+        consensus::{Transaction as _, TxEnvelope},
+        eips::eip2718::Decodable2718,
+        primitives::{Bytes, keccak256},
+    };
+    use serde_json::{Value, json};
+    use std::sync::Mutex as StdMutex;
+    use tokio::sync::mpsc::{self, Receiver};
+
+    const TEST_PRIVATE_KEY: &str =
+        "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80";
+
+    // L1 node with a new block at every block number query
+    #[derive(Default)]
+    struct MockL1 {
+        block_number: u64,
+        raw_txs: Vec<Bytes>,
+        // index of the sent tx which gets mined and its receipt status
+        mined_tx: Option<(usize, bool)>,
+        // the tx gets mined only once so many txs are sent
+        mined_after_sent_txs: usize,
+    }
+
+    impl MockL1 {
+        fn response(&mut self, request: &Value) -> Value {
+            let result = match request["method"].as_str().unwrap_or_default() {
+                "eth_blockNumber" => {
+                    self.block_number += 1;
+                    json!(format!("{:#x}", self.block_number))
+                }
+                "eth_chainId" => json!("0x1"),
+                "eth_sendRawTransaction" => {
+                    let raw_tx: Bytes =
+                        serde_json::from_value(request["params"][0].clone()).unwrap();
+                    let tx_hash = keccak256(&raw_tx);
+                    self.raw_txs.push(raw_tx);
+                    json!(tx_hash)
+                }
+                "eth_getTransactionReceipt" => {
+                    let tx_hash: B256 =
+                        serde_json::from_value(request["params"][0].clone()).unwrap();
+                    match self.mined_tx {
+                        Some((index, status))
+                            if self.raw_txs.len() >= self.mined_after_sent_txs
+                                && self.raw_txs.get(index).map(keccak256) == Some(tx_hash) =>
+                        {
+                            receipt(tx_hash, self.block_number, status)
+                        }
+                        _ => Value::Null,
+                    }
+                }
+                // no tx is in the mempool
+                "eth_getTransactionByHash" => Value::Null,
+                _ => {
+                    return json!({
+                        "jsonrpc": "2.0",
+                        "id": request["id"],
+                        "error": { "code": -32601, "message": "method not found" },
+                    });
+                }
+            };
+            json!({ "jsonrpc": "2.0", "id": request["id"], "result": result })
+        }
+    }
+
+    async fn mock_l1(server: &mut mockito::ServerGuard, mock_l1: Arc<StdMutex<MockL1>>) {
+        server
+            .mock("POST", "/")
+            .with_body_from_request(move |request| {
+                let body: Value = serde_json::from_slice(request.body().unwrap()).unwrap();
+                let mut mock_l1 = mock_l1.lock().unwrap();
+                let response = match body {
+                    Value::Array(requests) => Value::Array(
+                        requests
+                            .iter()
+                            .map(|request| mock_l1.response(request))
+                            .collect(),
+                    ),
+                    request => mock_l1.response(&request),
+                };
+                response.to_string().into_bytes()
+            })
+            .create_async()
+            .await;
+    }
+
+    async fn monitor_thread(
+        server: &mockito::ServerGuard,
+    ) -> (TransactionMonitorThread, Receiver<TransactionError>) {
+        monitor_thread_replacing_pending_tx(server, false).await
+    }
+
+    async fn monitor_thread_replacing_pending_tx(
+        server: &mockito::ServerGuard,
+        replaces_pending_tx: bool,
+    ) -> (TransactionMonitorThread, Receiver<TransactionError>) {
+        let signer = Arc::new(Signer::PrivateKey(TEST_PRIVATE_KEY.to_string()));
+        let (provider, _) = alloy_tools::construct_alloy_provider(&signer, &server.url(), None)
+            .await
+            .unwrap();
+        let config = TransactionMonitorConfig {
+            min_priority_fee_per_gas_wei: 0,
+            tx_fees_increase_percentage: 0,
+            max_attempts_to_send_tx: 1,
+            max_attempts_to_wait_tx: 3,
+            delay_between_tx_attempts: Duration::from_millis(50),
+            max_attempts_to_resubmit_evicted_tx: 1,
+            // evicted as soon as it is not found
+            evicted_tx_timeout: Duration::ZERO,
+            tip_escalation: TipEscalationPolicy {
+                percentage_per_sec: 0,
+                cap_percentage: 0,
+            },
+            execution_rpc_urls: vec![server.url()],
+            preconfer_address: None,
+            signer,
+        };
+        let receipt_poller = Arc::new(ReceiptPoller::new(
+            provider.clone(),
+            Duration::from_millis(10),
+        ));
+        let (sender, receiver) = mpsc::channel(10);
+        let thread = TransactionMonitorThread::new(
+            provider,
+            config,
+            Some(receipt_poller),
+            0,
+            replaces_pending_tx,
+            sender,
+            Arc::new(Metrics::new()),
+            1,
+        );
+        (thread, receiver)
+    }
+
+    fn test_tx() -> TransactionRequest {
+        TransactionRequest::default()
+            .with_to(Address::repeat_byte(2))
+            .with_gas_limit(21_000)
+            .with_max_fee_per_gas(10_000_000_000)
+            .with_max_priority_fee_per_gas(1_000_000_000)
+    }
+
+    fn priority_fee(raw_tx: &Bytes) -> Option<u128> {
+        TxEnvelope::decode_2718(&mut raw_tx.as_ref())
+            .unwrap()
+            .max_priority_fee_per_gas()
+    }
+
+    #[tokio::test]
+    async fn test_evicted_tx_is_resubmitted_with_bumped_fees() {
+        let mut server = mockito::Server::new_async().await;
+        // the resubmitted tx gets mined
+        let l1 = Arc::new(StdMutex::new(MockL1 {
+            mined_tx: Some((1, true)),
+            ..Default::default()
+        }));
+        mock_l1(&mut server, l1.clone()).await;
+
+        let (thread, mut errors) = monitor_thread(&server).await;
+        thread.monitor_transaction(test_tx()).await;
+
+        let l1 = l1.lock().unwrap();
+        assert_eq!(l1.raw_txs.len(), 2);
+        assert!(priority_fee(&l1.raw_txs[1]) > priority_fee(&l1.raw_txs[0]));
+        assert!(errors.try_recv().is_err());
+        assert!(thread.metrics.gather().contains("batch_confirmed 1"));
+    }
+
+    #[tokio::test]
+    async fn test_replaced_tx_mined_is_confirmed() {
+        let mut server = mockito::Server::new_async().await;
+        // the original tx gets mined after its replacement is sent, only the batched
+        // polling of all the txs of the nonce sees it
+        let l1 = Arc::new(StdMutex::new(MockL1 {
+            mined_tx: Some((0, true)),
+            mined_after_sent_txs: 2,
+            ..Default::default()
+        }));
+        mock_l1(&mut server, l1.clone()).await;
+
+        let (thread, mut errors) = monitor_thread(&server).await;
+        thread.monitor_transaction(test_tx()).await;
+
+        assert_eq!(l1.lock().unwrap().raw_txs.len(), 2);
+        assert!(errors.try_recv().is_err());
+        assert!(thread.metrics.gather().contains("batch_confirmed 1"));
+    }
+
+    #[tokio::test]
+    async fn test_tx_replacing_pending_tx_is_sent_with_bumped_fees() {
+        for (replaces_pending_tx, expected_priority_fee) in
+            [(false, 1_000_000_000), (true, 2_000_000_000)]
+        {
+            let mut server = mockito::Server::new_async().await;
+            let l1 = Arc::new(StdMutex::new(MockL1 {
+                mined_tx: Some((0, true)),
+                ..Default::default()
+            }));
+            mock_l1(&mut server, l1.clone()).await;
+
+            let (thread, _errors) =
+                monitor_thread_replacing_pending_tx(&server, replaces_pending_tx).await;
+            thread.monitor_transaction(test_tx()).await;
+
+            let l1 = l1.lock().unwrap();
+            assert_eq!(l1.raw_txs.len(), 1);
+            assert_eq!(priority_fee(&l1.raw_txs[0]), Some(expected_priority_fee));
+        }
+    }
+
+    #[tokio::test]
+    async fn test_reverted_tx_is_not_resubmitted() {
+        let mut server = mockito::Server::new_async().await;
+        // the first tx gets mined and reverts
+        let l1 = Arc::new(StdMutex::new(MockL1 {
+            mined_tx: Some((0, false)),
+            ..Default::default()
+        }));
+        mock_l1(&mut server, l1.clone()).await;
+
+        let (thread, mut errors) = monitor_thread(&server).await;
+        thread.monitor_transaction(test_tx()).await;
+
+        assert_eq!(l1.lock().unwrap().raw_txs.len(), 1);
+        assert!(matches!(
+            errors.try_recv(),
+            Ok(TransactionError::TransactionReverted)
+        ));
+        assert!(!thread.metrics.gather().contains("batch_confirmed 1"));
+    }
+
+    #[test]
+    fn test_evicted_tx_is_resubmitted() {
+        assert!(is_tx_evicted(
+            &TxPresence::NotFound,
+            Duration::from_secs(36),
+            Duration::from_secs(36)
+        ));
+        assert!(is_tx_evicted(
+            &TxPresence::NotFound,
+            Duration::from_secs(100),
+            Duration::from_secs(36)
+        ));
+    }
+
+    #[test]
+    fn test_not_found_tx_waits_for_timeout() {
+        assert!(!is_tx_evicted(
+            &TxPresence::NotFound,
+            Duration::from_secs(35),
+            Duration::from_secs(36)
+        ));
+    }
+
+    #[test]
+    fn test_mined_tx_is_not_resubmitted() {
+        // covers mined-but-reverted as well, presence does not depend on the receipt status
+        assert!(!is_tx_evicted(
+            &TxPresence::Mined,
+            Duration::from_secs(100),
+            Duration::from_secs(36)
+        ));
+        assert!(!is_tx_evicted(
+            &TxPresence::InMempool,
+            Duration::from_secs(100),
+            Duration::from_secs(36)
+        ));
+    }
+
+    #[test]
+    fn test_bump_fees_for_replacement() {
+        let mut max_fee_per_gas = 10;
+        let mut max_priority_fee_per_gas = 2;
+        let mut max_fee_per_blob_gas = Some(5);
+        bump_fees_for_replacement(
+            &mut max_fee_per_gas,
+            &mut max_priority_fee_per_gas,
+            &mut max_fee_per_blob_gas,
+        );
+        assert_eq!(max_fee_per_gas, 20);
+        assert_eq!(max_priority_fee_per_gas, 4);
+        assert_eq!(max_fee_per_blob_gas, Some(10));
+
+        let mut max_fee_per_blob_gas = None;
+        bump_fees_for_replacement(
+            &mut max_fee_per_gas,
+            &mut max_priority_fee_per_gas,
+            &mut max_fee_per_blob_gas,
+        );
+        assert_eq!(max_fee_per_blob_gas, None);
+    }
+
+    #[test]
+    fn test_tip_is_escalated_proportionally_to_pending_time() {
+        let policy = TipEscalationPolicy {
+            percentage_per_sec: 2,
+            cap_percentage: 300,
+        };
+        let initial_priority_fee = 1_000_000_000;
+
+        assert_eq!(
+            policy.escalated_priority_fee(initial_priority_fee, Duration::ZERO),
+            initial_priority_fee
+        );
+        // 24s pending, 2% per second
+        assert_eq!(
+            policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(24)),
+            1_480_000_000
+        );
+        assert_eq!(
+            policy.escalated_priority_fee(initial_priority_fee, Duration::from_millis(24_900)),
+            1_480_000_000
+        );
+        // capped at 300% of the initial fee
+        assert_eq!(
+            policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(100)),
+            3_000_000_000
+        );
+        assert_eq!(
+            policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(3600)),
+            3_000_000_000
+        );
+    }
+
+    #[test]
+    fn test_escalate_fees_for_replacement() {
+        let policy = TipEscalationPolicy {
+            percentage_per_sec: 2,
+            cap_percentage: 300,
+        };
+        let initial_priority_fee = 1_000_000_000;
+        let cap = policy.priority_fee_cap(initial_priority_fee);
+        let mut max_fee_per_gas = 10_000_000_000;
+        let mut max_priority_fee_per_gas = initial_priority_fee;
+        let mut max_fee_per_blob_gas = None;
+
+        // pending for 12s, the max fees follow the priority fee
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(12)),
+                cap,
+            ),
+            FeeIncrease::Bumped
+        );
+        assert_eq!(max_priority_fee_per_gas, 1_240_000_000);
+        assert_eq!(max_fee_per_gas, 12_400_000_000);
+
+        // 1s later the increase is too small to replace the tx, but the escalation goes on
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(13)),
+                cap,
+            ),
+            FeeIncrease::TooSmall
+        );
+        assert_eq!(max_priority_fee_per_gas, 1_240_000_000);
+        assert_eq!(max_fee_per_gas, 12_400_000_000);
+
+        for (pending_sec, expected_priority_fee, expected_max_fee) in [
+            (80, 2_600_000_000, 26_000_000_000),
+            (100, 3_000_000_000, 30_000_000_000),
+        ] {
+            assert_eq!(
+                escalate_fees_for_replacement(
+                    &mut max_fee_per_gas,
+                    &mut max_priority_fee_per_gas,
+                    &mut max_fee_per_blob_gas,
+                    policy.escalated_priority_fee(
+                        initial_priority_fee,
+                        Duration::from_secs(pending_sec)
+                    ),
+                    cap,
+                ),
+                FeeIncrease::Bumped
+            );
+            assert_eq!(max_priority_fee_per_gas, expected_priority_fee);
+            assert_eq!(max_fee_per_gas, expected_max_fee);
+        }
+
+        // the cap stops the replacements
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(200)),
+                cap,
+            ),
+            FeeIncrease::Capped
+        );
+        assert_eq!(max_priority_fee_per_gas, 3_000_000_000);
+        assert_eq!(max_fee_per_gas, 30_000_000_000);
+    }
+
+    #[test]
+    fn test_blob_tx_fees_are_at_least_doubled() {
+        let policy = TipEscalationPolicy {
+            percentage_per_sec: 2,
+            cap_percentage: 300,
+        };
+        let initial_priority_fee = 1_000_000_000;
+        let cap = policy.priority_fee_cap(initial_priority_fee);
+        let mut max_fee_per_gas = 10_000_000_000;
+        let mut max_priority_fee_per_gas = initial_priority_fee;
+        let mut max_fee_per_blob_gas = Some(5_000_000);
+
+        // pending for 12s, the escalation is below the bump required by the blob pool
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(12)),
+                cap,
+            ),
+            FeeIncrease::Bumped
+        );
+        assert_eq!(max_priority_fee_per_gas, 2_000_000_000);
+        assert_eq!(max_fee_per_gas, 20_000_000_000);
+        assert_eq!(max_fee_per_blob_gas, Some(10_000_000));
+
+        // the tip escalated above the doubled one is taken, the max fees follow it
+        max_priority_fee_per_gas = initial_priority_fee;
+        max_fee_per_gas = 10_000_000_000;
+        max_fee_per_blob_gas = Some(5_000_000);
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(100)),
+                cap,
+            ),
+            FeeIncrease::Bumped
+        );
+        assert_eq!(max_priority_fee_per_gas, 3_000_000_000);
+        assert_eq!(max_fee_per_gas, 30_000_000_000);
+        assert_eq!(max_fee_per_blob_gas, Some(15_000_000));
+
+        // at the cap the blob tx is still replaced with doubled fees
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(200)),
+                cap,
+            ),
+            FeeIncrease::Bumped
+        );
+        assert_eq!(max_priority_fee_per_gas, 6_000_000_000);
+        assert_eq!(max_fee_per_gas, 60_000_000_000);
+        assert_eq!(max_fee_per_blob_gas, Some(30_000_000));
+    }
+
+    #[test]
+    fn test_escalation_close_to_cap_is_capped() {
+        let policy = TipEscalationPolicy {
+            percentage_per_sec: 2,
+            cap_percentage: 300,
+        };
+        let initial_priority_fee = 1_000_000_000;
+        let mut max_fee_per_gas = 29_000_000_000;
+        let mut max_priority_fee_per_gas = 2_900_000_000;
+        let mut max_fee_per_blob_gas = None;
+        // the cap is less than the min bump above the current priority fee
+        assert_eq!(
+            escalate_fees_for_replacement(
+                &mut max_fee_per_gas,
+                &mut max_priority_fee_per_gas,
+                &mut max_fee_per_blob_gas,
+                policy.escalated_priority_fee(initial_priority_fee, Duration::from_secs(200)),
+                policy.priority_fee_cap(initial_priority_fee),
+            ),
+            FeeIncrease::Capped
+        );
+        assert_eq!(max_priority_fee_per_gas, 2_900_000_000);
+    }
+}
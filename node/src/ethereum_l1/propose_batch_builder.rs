@@ -1,4 +1,6 @@
-use super::{l1_contracts_bindings::*, tools, transaction_error::TransactionError};
+use super::{
+    config::BlobFeeFallback, l1_contracts_bindings::*, tools, transaction_error::TransactionError,
+};
 use crate::forced_inclusion::ForcedInclusionInfo;
 use alloy::{
     network::{TransactionBuilder, TransactionBuilder4844},
@@ -9,11 +11,13 @@ use alloy::{
 };
 use alloy_json_rpc::RpcError;
 use anyhow::{Error, anyhow};
+use std::sync::Mutex;
 use tracing::warn;
 
 struct FeesPerGas {
     base_fee_per_gas: u128,
-    base_fee_per_blob_gas: u128,
+    // None when the blob base fee is unknown and the batch has to be proposed with calldata
+    base_fee_per_blob_gas: Option<u128>,
     max_fee_per_gas: u128,
     max_priority_fee_per_gas: u128,
 }
@@ -21,13 +25,21 @@ struct FeesPerGas {
 pub struct ProposeBatchBuilder {
     provider_ws: DynProvider,
     extra_gas_percentage: u64,
+    blob_fee_fallback: BlobFeeFallback,
+    last_base_fee_per_blob_gas: Mutex<Option<u128>>,
 }
 
 impl ProposeBatchBuilder {
-    pub fn new(provider_ws: DynProvider, extra_gas_percentage: u64) -> Self {
+    pub fn new(
+        provider_ws: DynProvider,
+        extra_gas_percentage: u64,
+        blob_fee_fallback: BlobFeeFallback,
+    ) -> Self {
         Self {
             provider_ws,
             extra_gas_percentage,
+            blob_fee_fallback,
+            last_base_fee_per_blob_gas: Mutex::new(None),
         }
     }
 
@@ -80,8 +92,7 @@ impl ProposeBatchBuilder {
                 match e {
                     RpcError::ErrorResp(err) => {
                         return Err(anyhow!(
-                            tools::convert_error_payload(&err.to_string())
-                                .unwrap_or(TransactionError::EstimationFailed)
+                            self.convert_estimation_error(&err.to_string()).await
                         ));
                     }
                     _ => return Ok(tx_blob),
@@ -100,6 +111,26 @@ impl ProposeBatchBuilder {
             }
         };
 
+        // Blob base fee is unknown, propose with calldata
+        let Some(base_fee_per_blob_gas) = fees_per_gas.base_fee_per_blob_gas else {
+            let tx_calldata = self
+                .build_propose_batch_calldata(
+                    from,
+                    to,
+                    tx_list,
+                    blocks,
+                    last_anchor_origin_height,
+                    last_block_timestamp,
+                    coinbase,
+                    &forced_inclusion,
+                )
+                .await?;
+            let Some(tx_calldata_gas) = self.estimate_calldata_gas(&tx_calldata).await? else {
+                return Err(anyhow!(TransactionError::EstimationFailed));
+            };
+            return Ok(self.update_eip1559(tx_calldata, &fees_per_gas, tx_calldata_gas));
+        };
+
         // Get blob count
         let blob_count = tx_blob
             .sidecar
@@ -108,11 +139,17 @@ impl ProposeBatchBuilder {
 
         // Calculate the cost of the eip4844 transaction
         let eip4844_cost = self
-            .get_eip4844_cost(&fees_per_gas, blob_count, tx_blob_gas)
+            .get_eip4844_cost(
+                &fees_per_gas,
+                base_fee_per_blob_gas,
+                blob_count,
+                tx_blob_gas,
+            )
             .await;
 
         // Update gas params for eip4844 transaction
-        let tx_blob = self.update_eip4844(tx_blob, &fees_per_gas, tx_blob_gas);
+        let tx_blob =
+            self.update_eip4844(tx_blob, &fees_per_gas, base_fee_per_blob_gas, tx_blob_gas);
 
         // Build eip1559 transaction
         let tx_calldata = self
@@ -127,25 +164,20 @@ impl ProposeBatchBuilder {
                 &forced_inclusion,
             )
             .await?;
-        let tx_calldata_gas = match self.provider_ws.estimate_gas(tx_calldata.clone()).await {
-            Ok(gas) => gas,
-            Err(e) => {
-                warn!(
-                    "Build proposeBatch: Failed to estimate gas for calldata transaction: {}",
-                    e
-                );
-                match e {
-                    RpcError::ErrorResp(err) => {
-                        return Err(anyhow!(
-                            tools::convert_error_payload(&err.to_string())
-                                .unwrap_or(TransactionError::EstimationFailed)
-                        ));
-                    }
-                    _ => return Ok(tx_blob), // In case of error return eip4844 transaction
-                }
+        let tx_calldata_gas = match self.estimate_calldata_gas(&tx_calldata).await {
+            Ok(Some(gas)) => gas,
+            // the eip4844 transaction fits into the L1 block gas limit
+            Err(e)
+                if matches!(
+                    e.downcast_ref::<TransactionError>(),
+                    Some(TransactionError::ExceedsBlockGasLimit)
+                ) =>
+            {
+                return Ok(tx_blob);
             }
+            Err(e) => return Err(e),
+            Ok(None) => return Ok(tx_blob), // In case of error return eip4844 transaction
         };
-        let tx_calldata_gas = tx_calldata_gas + tx_calldata_gas * self.extra_gas_percentage / 100;
 
         tracing::debug!(
             "Build proposeBatch: eip1559 gas: {} eip4844 gas: {}",
@@ -177,6 +209,57 @@ impl ProposeBatchBuilder {
         }
     }
 
+    /// Estimated gas with the extra gas percentage, None on a transport error.
+    async fn estimate_calldata_gas(
+        &self,
+        tx_calldata: &TransactionRequest,
+    ) -> Result<Option<u64>, Error> {
+        match self.provider_ws.estimate_gas(tx_calldata.clone()).await {
+            Ok(gas) => Ok(Some(gas + gas * self.extra_gas_percentage / 100)),
+            Err(e) => {
+                warn!(
+                    "Build proposeBatch: Failed to estimate gas for calldata transaction: {}",
+                    e
+                );
+                match e {
+                    RpcError::ErrorResp(err) => Err(anyhow!(
+                        self.convert_estimation_error(&err.to_string()).await
+                    )),
+                    _ => Ok(None),
+                }
+            }
+        }
+    }
+
+    async fn convert_estimation_error(&self, err: &str) -> TransactionError {
+        if let Some(error) = tools::convert_error_payload(err) {
+            return error;
+        }
+        let Some(allowance) = tools::get_exceeded_gas_allowance(err) else {
+            return TransactionError::EstimationFailed;
+        };
+        match self.get_block_gas_limit().await {
+            Ok(block_gas_limit) => {
+                tools::convert_exceeded_gas_allowance(allowance, block_gas_limit)
+            }
+            Err(e) => {
+                warn!(
+                    "Build proposeBatch: Failed to get the L1 block gas limit: {}",
+                    e
+                );
+                TransactionError::EstimationFailed
+            }
+        }
+    }
+
+    async fn get_block_gas_limit(&self) -> Result<u64, Error> {
+        self.provider_ws
+            .get_block_by_number(alloy::eips::BlockNumberOrTag::Latest)
+            .await?
+            .map(|block| block.header.gas_limit)
+            .ok_or_else(|| anyhow::Error::msg("Failed to get the latest block"))
+    }
+
     fn update_eip1559(
         &self,
         tx: TransactionRequest,
@@ -192,12 +275,13 @@ impl ProposeBatchBuilder {
         &self,
         tx: TransactionRequest,
         fees_per_gas: &FeesPerGas,
+        base_fee_per_blob_gas: u128,
         gas_limit: u64,
     ) -> TransactionRequest {
         tx.with_gas_limit(gas_limit)
             .with_max_fee_per_gas(fees_per_gas.max_fee_per_gas)
             .with_max_priority_fee_per_gas(fees_per_gas.max_priority_fee_per_gas)
-            .with_max_fee_per_blob_gas(fees_per_gas.base_fee_per_blob_gas)
+            .with_max_fee_per_blob_gas(base_fee_per_blob_gas)
     }
 
     async fn get_eip1559_cost(&self, fees_per_gas: &FeesPerGas, gas_used: u64) -> u128 {
@@ -208,22 +292,34 @@ impl ProposeBatchBuilder {
     async fn get_eip4844_cost(
         &self,
         fees_per_gas: &FeesPerGas,
+        base_fee_per_blob_gas: u128,
         blob_count: u64,
         gas_used: u64,
     ) -> u128 {
         let blob_gas_used = alloy::eips::eip4844::DATA_GAS_PER_BLOB * blob_count;
         let execution_gas_cost = u128::from(gas_used)
             * (fees_per_gas.base_fee_per_gas + fees_per_gas.max_priority_fee_per_gas);
-        let blob_gas_cost = u128::from(blob_gas_used) * fees_per_gas.base_fee_per_blob_gas;
+        let blob_gas_cost = u128::from(blob_gas_used) * base_fee_per_blob_gas;
         execution_gas_cost + blob_gas_cost
     }
 
     async fn get_fees_per_gas(&self) -> Result<FeesPerGas, Error> {
         // Get base fee per gas
-        let fee_history = self
+        let fee_history = match self
             .provider_ws
             .get_fee_history(2, alloy::eips::BlockNumberOrTag::Latest, &[])
-            .await?;
+            .await
+        {
+            Ok(fee_history) => fee_history,
+            Err(e) => {
+                return self
+                    .get_fees_per_gas_without_fee_history(anyhow!(
+                        "Failed to get fee history: {}",
+                        e
+                    ))
+                    .await;
+            }
+        };
 
         let base_fee_per_gas = fee_history
             .base_fee_per_gas
@@ -235,9 +331,11 @@ impl ProposeBatchBuilder {
             .base_fee_per_blob_gas
             .last()
             .copied()
+            .filter(|fee| *fee != 0)
             .ok_or_else(|| {
                 anyhow::Error::msg("Failed to get base_fee_per_blob_gas from fee history")
-            })?;
+            });
+        let base_fee_per_blob_gas = self.apply_blob_fee_fallback(base_fee_per_blob_gas)?;
 
         let eip1559_estimation = self.provider_ws.estimate_eip1559_fees().await?;
 
@@ -255,6 +353,44 @@ impl ProposeBatchBuilder {
         })
     }
 
+    /// The fee history is the source of the blob base fee, without it the fees are taken
+    /// from the latest block and the blob base fee from the fallback.
+    async fn get_fees_per_gas_without_fee_history(&self, err: Error) -> Result<FeesPerGas, Error> {
+        let base_fee_per_gas = self
+            .provider_ws
+            .get_block_by_number(alloy::eips::BlockNumberOrTag::Latest)
+            .await?
+            .and_then(|block| block.header.base_fee_per_gas)
+            .ok_or_else(|| {
+                anyhow::Error::msg("Failed to get base_fee_per_gas from the latest block")
+            })?;
+        let base_fee_per_gas = u128::from(base_fee_per_gas);
+        let max_priority_fee_per_gas = self.provider_ws.get_max_priority_fee_per_gas().await?;
+        let base_fee_per_blob_gas = self.apply_blob_fee_fallback(Err(err))?;
+
+        Ok(FeesPerGas {
+            base_fee_per_gas,
+            base_fee_per_blob_gas,
+            // as in the eip1559 fees estimation
+            max_fee_per_gas: base_fee_per_gas * 2 + max_priority_fee_per_gas,
+            max_priority_fee_per_gas,
+        })
+    }
+
+    fn apply_blob_fee_fallback(
+        &self,
+        base_fee_per_blob_gas: Result<u128, Error>,
+    ) -> Result<Option<u128>, Error> {
+        Ok(apply_blob_fee_fallback(
+            base_fee_per_blob_gas,
+            self.blob_fee_fallback,
+            &mut self
+                .last_base_fee_per_blob_gas
+                .lock()
+                .map_err(|e| anyhow::anyhow!("Failed to lock last blob base fee: {}", e))?,
+        ))
+    }
+
     #[allow(clippy::too_many_arguments)]
     async fn build_propose_batch_calldata(
         &self,
@@ -411,3 +547,219 @@ impl ProposeBatchBuilder {
         }
     }
 }
+
+/// Returns the blob base fee to use for the proposal, None means proposing with calldata.
+fn apply_blob_fee_fallback(
+    base_fee_per_blob_gas: Result<u128, Error>,
+    fallback: BlobFeeFallback,
+    last_base_fee_per_blob_gas: &mut Option<u128>,
+) -> Option<u128> {
+    let err = match base_fee_per_blob_gas {
+        Ok(fee) => {
+            *last_base_fee_per_blob_gas = Some(fee);
+            return Some(fee);
+        }
+        Err(err) => err,
+    };
+
+    match (fallback, *last_base_fee_per_blob_gas) {
+        (BlobFeeFallback::LastKnown, Some(fee)) => {
+            // the blob base fee may have risen since, doubled as the max fee per gas
+            let fee = fee.saturating_mul(2);
+            warn!(
+                "Build proposeBatch: {}, using doubled last known blob base fee {}",
+                err, fee
+            );
+            Some(fee)
+        }
+        (BlobFeeFallback::LastKnown, None) => {
+            warn!(
+                "Build proposeBatch: {}, no last known blob base fee, proposing with calldata",
+                err
+            );
+            None
+        }
+        (BlobFeeFallback::Calldata, _) => {
+            warn!("Build proposeBatch: {}, proposing with calldata", err);
+            None
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::shared::test_utils::rpc_response;
+    use serde_json::json;
+
+    fn failing_query() -> Result<u128, Error> {
+        Err(anyhow::Error::msg(
+            "Failed to get base_fee_per_blob_gas from fee history",
+        ))
+    }
+
+    #[test]
+    fn test_blob_fee_is_remembered() {
+        let mut last_known = Some(5);
+        assert_eq!(
+            apply_blob_fee_fallback(Ok(7), BlobFeeFallback::Calldata, &mut last_known),
+            Some(7)
+        );
+        assert_eq!(last_known, Some(7));
+    }
+
+    #[test]
+    fn test_last_known_blob_fee_fallback() {
+        let mut last_known = None;
+        assert_eq!(
+            apply_blob_fee_fallback(Ok(7), BlobFeeFallback::LastKnown, &mut last_known),
+            Some(7)
+        );
+        assert_eq!(
+            apply_blob_fee_fallback(failing_query(), BlobFeeFallback::LastKnown, &mut last_known),
+            Some(14)
+        );
+        // the headroom is not compounded on repeated failures
+        assert_eq!(
+            apply_blob_fee_fallback(failing_query(), BlobFeeFallback::LastKnown, &mut last_known),
+            Some(14)
+        );
+        assert_eq!(last_known, Some(7));
+    }
+
+    #[test]
+    fn test_last_known_blob_fee_fallback_without_known_fee() {
+        let mut last_known = None;
+        assert_eq!(
+            apply_blob_fee_fallback(failing_query(), BlobFeeFallback::LastKnown, &mut last_known),
+            None
+        );
+    }
+
+    #[test]
+    fn test_calldata_blob_fee_fallback() {
+        let mut last_known = Some(7);
+        assert_eq!(
+            apply_blob_fee_fallback(failing_query(), BlobFeeFallback::Calldata, &mut last_known),
+            None
+        );
+        assert_eq!(last_known, Some(7));
+    }
+
+    // the fee history fails, the latest block and the priority fee are available
+    async fn builder_without_fee_history(
+        server: &mut mockito::ServerGuard,
+        blob_fee_fallback: BlobFeeFallback,
+    ) -> ProposeBatchBuilder {
+        let mocks = [
+            (
+                "eth_feeHistory",
+                json!({"error": {"code": -32000, "message": "fee history unavailable"}}),
+            ),
+            (
+                "eth_getBlockByNumber",
+                json!({"result": crate::shared::alloy_tools::test_block_json(100, 1_000_000_000)}),
+            ),
+            ("eth_maxPriorityFeePerGas", json!({"result": "0x77359400"})),
+        ];
+        for (method, response) in mocks {
+            server
+                .mock("POST", "/")
+                .match_body(mockito::Matcher::Regex(method.to_string()))
+                .with_body_from_request(rpc_response(response))
+                .create_async()
+                .await;
+        }
+
+        let provider =
+            crate::shared::alloy_tools::create_alloy_provider_without_wallet(&server.url())
+                .await
+                .unwrap();
+        ProposeBatchBuilder::new(provider, 0, blob_fee_fallback)
+    }
+
+    async fn builder_with_exceeded_gas_allowance(
+        server: &mut mockito::ServerGuard,
+        allowance: u64,
+    ) -> ProposeBatchBuilder {
+        let mocks = [
+            (
+                "eth_estimateGas",
+                json!({"error": {
+                    "code": -32000,
+                    "message": format!("gas required exceeds allowance ({allowance})"),
+                }}),
+            ),
+            // block gas limit of 30M
+            (
+                "eth_getBlockByNumber",
+                json!({"result": crate::shared::alloy_tools::test_block_json(100, 1_000_000_000)}),
+            ),
+        ];
+        for (method, response) in mocks {
+            server
+                .mock("POST", "/")
+                .match_body(mockito::Matcher::Regex(method.to_string()))
+                .with_body_from_request(rpc_response(response))
+                .create_async()
+                .await;
+        }
+
+        let provider =
+            crate::shared::alloy_tools::create_alloy_provider_without_wallet(&server.url())
+                .await
+                .unwrap();
+        ProposeBatchBuilder::new(provider, 0, BlobFeeFallback::LastKnown)
+    }
+
+    async fn estimate_calldata_gas_error(allowance: u64) -> TransactionError {
+        let mut server = mockito::Server::new_async().await;
+        let builder = builder_with_exceeded_gas_allowance(&mut server, allowance).await;
+        let err = builder
+            .estimate_calldata_gas(&TransactionRequest::default())
+            .await
+            .unwrap_err();
+        err.downcast::<TransactionError>().unwrap()
+    }
+
+    #[tokio::test]
+    async fn test_allowance_capped_by_block_gas_limit_exceeds_block_gas_limit() {
+        assert!(matches!(
+            estimate_calldata_gas_error(30_000_000).await,
+            TransactionError::ExceedsBlockGasLimit
+        ));
+    }
+
+    #[tokio::test]
+    async fn test_allowance_capped_by_balance_is_not_split() {
+        // the same error of an underfunded proposer must not split the batch
+        assert!(matches!(
+            estimate_calldata_gas_error(1_000_000).await,
+            TransactionError::InsufficientFunds
+        ));
+    }
+
+    #[tokio::test]
+    async fn test_fee_history_failure_uses_last_known_blob_fee() {
+        let mut server = mockito::Server::new_async().await;
+        let builder = builder_without_fee_history(&mut server, BlobFeeFallback::LastKnown).await;
+        *builder.last_base_fee_per_blob_gas.lock().unwrap() = Some(7);
+
+        let fees_per_gas = builder.get_fees_per_gas().await.unwrap();
+        assert_eq!(fees_per_gas.base_fee_per_gas, 1_000_000_000);
+        assert_eq!(fees_per_gas.max_priority_fee_per_gas, 2_000_000_000);
+        assert_eq!(fees_per_gas.max_fee_per_gas, 4_000_000_000);
+        assert_eq!(fees_per_gas.base_fee_per_blob_gas, Some(14));
+    }
+
+    #[tokio::test]
+    async fn test_fee_history_failure_proposes_with_calldata() {
+        let mut server = mockito::Server::new_async().await;
+        let builder = builder_without_fee_history(&mut server, BlobFeeFallback::Calldata).await;
+        *builder.last_base_fee_per_blob_gas.lock().unwrap() = Some(7);
+
+        let fees_per_gas = builder.get_fees_per_gas().await.unwrap();
+        assert_eq!(fees_per_gas.base_fee_per_gas, 1_000_000_000);
+        assert_eq!(fees_per_gas.base_fee_per_blob_gas, None);
+    }
+}
@@ -0,0 +1,363 @@
+use alloy::{
+    primitives::B256,
+    providers::{DynProvider, Provider},
+    rpc::{client::BatchRequest, types::TransactionReceipt},
+};
+use anyhow::Error;
+use std::{
+    collections::HashMap,
+    sync::{Arc, Mutex, MutexGuard, Weak},
+    time::Duration,
+};
+use tokio::sync::oneshot;
+use tracing::{debug, warn};
+
+struct PendingSubmission {
+    tx_hashes: Vec<B256>,
+    sender: oneshot::Sender<(B256, TransactionReceipt)>,
+}
+
+#[derive(Default)]
+struct PendingSubmissions {
+    next_id: u64,
+    submissions: HashMap<u64, PendingSubmission>,
+}
+
+impl PendingSubmissions {
+    fn tx_hashes(&self) -> Vec<B256> {
+        self.submissions
+            .values()
+            .flat_map(|submission| submission.tx_hashes.iter().copied())
+            .collect()
+    }
+
+    /// Hands the receipt of the first mined tx to its submission.
+    fn notify_mined(&mut self, mined: &HashMap<B256, TransactionReceipt>) {
+        let confirmed =
+            self.submissions
+                .iter()
+                .filter_map(|(id, submission)| {
+                    submission.tx_hashes.iter().find_map(|tx_hash| {
+                        mined.get(tx_hash).map(|receipt| (*id, *tx_hash, receipt))
+                    })
+                })
+                .map(|(id, tx_hash, receipt)| (id, tx_hash, receipt.clone()))
+                .collect::<Vec<_>>();
+        for (id, tx_hash, receipt) in confirmed {
+            if let Some(submission) = self.submissions.remove(&id) {
+                // the submission gave up waiting when the receiver is gone
+                let _ = submission.sender.send((tx_hash, receipt));
+            }
+        }
+    }
+}
+
+/// Polls the receipts of the txs of all the pending submissions, for each submission the
+/// original tx and its replacements, with a single batch JSON-RPC request per tick instead
+/// of a request per tx. The monitor sends one nonce at a time, so the batch is made of the
+/// txs sent for that nonce: without the poller only the last replacement is watched and an
+/// earlier tx of the nonce getting mined goes unnoticed. Monitor threads share one poller.
+pub struct ReceiptPoller {
+    pending: Arc<Mutex<PendingSubmissions>>,
+}
+
+impl ReceiptPoller {
+    /// Spawns the polling task, it stops once the poller is dropped.
+    pub fn new(provider: DynProvider, interval: Duration) -> Self {
+        let pending = Arc::new(Mutex::new(PendingSubmissions::default()));
+        tokio::spawn(poll_receipts(provider, interval, Arc::downgrade(&pending)));
+        Self { pending }
+    }
+
+    /// Waits until one of the txs of a submission is mined or the timeout elapses.
+    pub async fn wait_for_receipt(
+        &self,
+        tx_hashes: &[B256],
+        timeout: Duration,
+    ) -> Option<(B256, TransactionReceipt)> {
+        let (sender, receiver) = oneshot::channel();
+        let id = {
+            let Some(mut pending) = lock(&self.pending) else {
+                return None;
+            };
+            let id = pending.next_id;
+            pending.next_id += 1;
+            pending.submissions.insert(
+                id,
+                PendingSubmission {
+                    tx_hashes: tx_hashes.to_vec(),
+                    sender,
+                },
+            );
+            id
+        };
+
+        match tokio::time::timeout(timeout, receiver).await {
+            Ok(Ok(mined)) => Some(mined),
+            _ => {
+                if let Some(mut pending) = lock(&self.pending) {
+                    pending.submissions.remove(&id);
+                }
+                debug!("No receipt for txs {:?} within {:?}", tx_hashes, timeout);
+                None
+            }
+        }
+    }
+}
+
+fn lock(pending: &Mutex<PendingSubmissions>) -> Option<MutexGuard<'_, PendingSubmissions>> {
+    match pending.lock() {
+        Ok(guard) => Some(guard),
+        Err(e) => {
+            warn!("ReceiptPoller: failed to lock pending submissions: {}", e);
+            None
+        }
+    }
+}
+
+async fn poll_receipts(
+    provider: DynProvider,
+    interval: Duration,
+    pending: Weak<Mutex<PendingSubmissions>>,
+) {
+    let mut ticker = tokio::time::interval(interval);
+    loop {
+        ticker.tick().await;
+        let Some(pending) = pending.upgrade() else {
+            return;
+        };
+        let Some(tx_hashes) = lock(&pending).map(|pending| pending.tx_hashes()) else {
+            continue;
+        };
+        if tx_hashes.is_empty() {
+            continue;
+        }
+
+        match get_receipts(&provider, &tx_hashes).await {
+            Ok(receipts) => {
+                let mined = tx_hashes
+                    .into_iter()
+                    .zip(receipts)
+                    .filter_map(|(tx_hash, receipt)| receipt.map(|receipt| (tx_hash, receipt)))
+                    .collect::<HashMap<_, _>>();
+                if !mined.is_empty()
+                    && let Some(mut pending) = lock(&pending)
+                {
+                    pending.notify_mined(&mined);
+                }
+            }
+            Err(e) => warn!("Failed to poll receipts of txs {:?}: {}", tx_hashes, e),
+        }
+    }
+}
+
+/// Returns the receipts in the order of the given hashes, None for a tx not mined yet.
+async fn get_receipts(
+    provider: &DynProvider,
+    tx_hashes: &[B256],
+) -> Result<Vec<Option<TransactionReceipt>>, Error> {
+    let mut batch = BatchRequest::new(provider.client());
+    let waiters = tx_hashes
+        .iter()
+        .map(|tx_hash| {
+            batch.add_call::<_, Option<TransactionReceipt>>(
+                "eth_getTransactionReceipt",
+                &(*tx_hash,),
+            )
+        })
+        .collect::<Result<Vec<_>, _>>()?;
+    batch.send().await?;
+
+    let mut receipts = Vec::with_capacity(waiters.len());
+    for waiter in waiters {
+        receipts.push(waiter.await?);
+    }
+    Ok(receipts)
+}
+
+#[cfg(test)]
+pub(crate) mod tests {
+    use super::*;
+    use crate::shared::alloy_tools;
+    use alloy::network::ReceiptResponse;
+    use serde_json::{Value, json};
+    use std::{
+        collections::HashMap,
+        sync::{Arc, Mutex},
+    };
+
+    pub fn receipt(tx_hash: B256, block_number: u64, status: bool) -> Value {
+        json!({
+            "type": "0x2",
+            "status": if status { "0x1" } else { "0x0" },
+            "cumulativeGasUsed": "0x5208",
+            "logs": [],
+            "logsBloom": format!("0x{}", "00".repeat(256)),
+            "transactionHash": tx_hash,
+            "transactionIndex": "0x0",
+            "blockHash": B256::repeat_byte(0xbb),
+            "blockNumber": format!("{block_number:#x}"),
+            "gasUsed": "0x5208",
+            "effectiveGasPrice": "0x3b9aca00",
+            "from": "0x0000000000000000000000000000000000000001",
+            "to": "0x0000000000000000000000000000000000000002",
+            "contractAddress": null,
+        })
+    }
+
+    // answers every batched eth_getTransactionReceipt with the receipts mined so far
+    fn batch_response(
+        mined: Arc<Mutex<HashMap<B256, Value>>>,
+    ) -> impl Fn(&mockito::Request) -> Vec<u8> + Send + Sync {
+        move |request| {
+            let requests: Vec<Value> = serde_json::from_slice(request.body().unwrap()).unwrap();
+            let mined = mined.lock().unwrap();
+            let responses = requests
+                .iter()
+                .map(|request| {
+                    let tx_hash: B256 =
+                        serde_json::from_value(request["params"][0].clone()).unwrap();
+                    json!({
+                        "jsonrpc": "2.0",
+                        "id": request["id"],
+                        "result": mined.get(&tx_hash).cloned().unwrap_or(Value::Null),
+                    })
+                })
+                .collect::<Vec<_>>();
+            Value::Array(responses).to_string().into_bytes()
+        }
+    }
+
+    async fn provider(server: &mockito::ServerGuard) -> DynProvider {
+        alloy_tools::create_alloy_provider_without_wallet(&server.url())
+            .await
+            .unwrap()
+    }
+
+    async fn poller(server: &mockito::ServerGuard) -> ReceiptPoller {
+        ReceiptPoller::new(provider(server).await, Duration::from_millis(10))
+    }
+
+    #[tokio::test]
+    async fn test_receipts_of_in_flight_txs_are_polled_in_one_request() {
+        let mut server = mockito::Server::new_async().await;
+        let tx_hashes = [
+            B256::repeat_byte(1),
+            B256::repeat_byte(2),
+            B256::repeat_byte(3),
+        ];
+        let mined = Arc::new(Mutex::new(HashMap::from([(
+            tx_hashes[1],
+            receipt(tx_hashes[1], 100, true),
+        )])));
+        let batch = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex(
+                "^\\[.*eth_getTransactionReceipt.*eth_getTransactionReceipt.*eth_getTransactionReceipt.*\\]$"
+                    .into(),
+            ))
+            .with_body_from_request(batch_response(mined))
+            .expect(1)
+            .create_async()
+            .await;
+
+        let receipts = get_receipts(&provider(&server).await, &tx_hashes)
+            .await
+            .unwrap();
+        assert_eq!(receipts.len(), 3);
+        assert!(receipts[0].is_none());
+        assert_eq!(receipts[1].as_ref().unwrap().block_number(), Some(100));
+        assert!(receipts[2].is_none());
+        batch.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_in_flight_txs_confirmed_by_batched_polling() {
+        let mut server = mockito::Server::new_async().await;
+        let tx_hashes = [
+            B256::repeat_byte(1),
+            B256::repeat_byte(2),
+            B256::repeat_byte(3),
+        ];
+        let mined = Arc::new(Mutex::new(HashMap::new()));
+        let batch = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("^\\[".into()))
+            .with_body_from_request(batch_response(mined.clone()))
+            .create_async()
+            .await;
+        let single = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("^\\{".into()))
+            .expect(0)
+            .create_async()
+            .await;
+
+        let poller = poller(&server).await;
+        // nothing mined yet
+        assert!(
+            poller
+                .wait_for_receipt(&tx_hashes, Duration::from_millis(30))
+                .await
+                .is_none()
+        );
+
+        // the last replacement gets mined
+        mined
+            .lock()
+            .unwrap()
+            .insert(tx_hashes[2], receipt(tx_hashes[2], 101, true));
+        let (tx_hash, receipt) = poller
+            .wait_for_receipt(&tx_hashes, Duration::from_millis(30))
+            .await
+            .unwrap();
+        assert_eq!(tx_hash, tx_hashes[2]);
+        assert!(receipt.status());
+        assert_eq!(receipt.block_number(), Some(101));
+        batch.assert_async().await;
+        single.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_pending_submissions_confirmed_by_one_batch() {
+        let mut server = mockito::Server::new_async().await;
+        // the txs of three submissions, the second one with a replacement
+        let submissions = [
+            vec![B256::repeat_byte(1)],
+            vec![B256::repeat_byte(2), B256::repeat_byte(3)],
+            vec![B256::repeat_byte(4)],
+        ];
+        let mined = Arc::new(Mutex::new(HashMap::from([
+            (submissions[0][0], receipt(submissions[0][0], 100, true)),
+            (submissions[1][1], receipt(submissions[1][1], 101, true)),
+            (submissions[2][0], receipt(submissions[2][0], 102, true)),
+        ])));
+        let batch = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex(
+                "^\\[.*eth_getTransactionReceipt.*eth_getTransactionReceipt.*eth_getTransactionReceipt.*eth_getTransactionReceipt.*\\]$"
+                    .into(),
+            ))
+            .with_body_from_request(batch_response(mined))
+            .expect(1)
+            .create_async()
+            .await;
+
+        let poller = poller(&server).await;
+        let timeout = Duration::from_secs(1);
+        let (first, second, third) = tokio::join!(
+            poller.wait_for_receipt(&submissions[0], timeout),
+            poller.wait_for_receipt(&submissions[1], timeout),
+            poller.wait_for_receipt(&submissions[2], timeout),
+        );
+        assert_eq!(first.unwrap().0, submissions[0][0]);
+        let (tx_hash, receipt) = second.unwrap();
+        assert_eq!(tx_hash, submissions[1][1]);
+        assert_eq!(receipt.block_number(), Some(101));
+        assert_eq!(third.unwrap().0, submissions[2][0]);
+        batch.assert_async().await;
+
+        // confirmed submissions are not polled anymore
+        assert!(lock(&poller.pending).unwrap().submissions.is_empty());
+    }
+}
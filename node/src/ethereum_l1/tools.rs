@@ -37,6 +37,29 @@ pub fn check_for_not_the_operator_in_current_epoch(err_str: &str) -> bool {
         || err_str.contains("0xc0ec4b50")
 }
 
+// 0x7f06d57a -> TooManyBlocks()
+pub fn check_for_batch_too_large(err_str: &str) -> bool {
+    err_str.contains("0x7f06d57a")
+}
+
+// returned by the gas estimation when the transaction needs more gas than the estimation cap,
+// the cap is the L1 block gas limit or the gas the sender's balance can pay for
+pub fn get_exceeded_gas_allowance(err_str: &str) -> Option<u64> {
+    let (_, allowance) = err_str.split_once("gas required exceeds allowance (")?;
+    let (allowance, _) = allowance.split_once(')')?;
+    allowance.parse().ok()
+}
+
+/// Only an allowance capped by the L1 block gas limit means the transaction does not fit
+/// into a block, a lower allowance is capped by the sender's balance.
+pub fn convert_exceeded_gas_allowance(allowance: u64, block_gas_limit: u64) -> TransactionError {
+    if allowance >= block_gas_limit {
+        TransactionError::ExceedsBlockGasLimit
+    } else {
+        TransactionError::InsufficientFunds
+    }
+}
+
 pub fn convert_error_payload(err: &str) -> Option<TransactionError> {
     // TimestampTooLarge or ZeroAnchorBlockHash contract error
     if check_for_too_early_estimation(err) {
@@ -54,5 +77,41 @@ pub fn convert_error_payload(err: &str) -> Option<TransactionError> {
     if check_for_not_the_operator_in_current_epoch(err) {
         return Some(TransactionError::NotTheOperatorInCurrentEpoch);
     }
+    if check_for_batch_too_large(err) {
+        return Some(TransactionError::BatchTooLarge);
+    }
     None
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_get_exceeded_gas_allowance() {
+        assert_eq!(
+            get_exceeded_gas_allowance(
+                "server returned an error response: error code -32000: gas required exceeds allowance (30000000)"
+            ),
+            Some(30_000_000)
+        );
+        assert_eq!(
+            get_exceeded_gas_allowance("gas required exceeds allowance"),
+            None
+        );
+        assert_eq!(get_exceeded_gas_allowance("execution reverted"), None);
+    }
+
+    #[test]
+    fn test_convert_exceeded_gas_allowance() {
+        assert!(matches!(
+            convert_exceeded_gas_allowance(30_000_000, 30_000_000),
+            TransactionError::ExceedsBlockGasLimit
+        ));
+        // capped by the balance of an underfunded proposer
+        assert!(matches!(
+            convert_exceeded_gas_allowance(1_000_000, 30_000_000),
+            TransactionError::InsufficientFunds
+        ));
+    }
+}
@@ -11,6 +11,10 @@ pub enum TransactionError {
     ReanchorRequired,
     OldestForcedInclusionDue,
     NotTheOperatorInCurrentEpoch,
+    ExceedsBlockGasLimit,
+    BatchTooLarge,
+    SenderNotAuthorized,
+    RejectedByPreSealHook,
 }
 
 impl std::fmt::Display for TransactionError {
@@ -7,6 +7,10 @@ use tracing::{error, info, warn};
 
 use crate::{ethereum_l1, metrics::Metrics, taiko::Taiko};
 
+pub mod proposing_balance_guard;
+
+use proposing_balance_guard::ProposingBalanceGuard;
+
 pub struct FundsMonitor {
     ethereum_l1: Arc<ethereum_l1::EthereumL1>,
     taiko: Arc<Taiko>,
@@ -17,6 +21,7 @@ pub struct FundsMonitor {
     cancel_token: CancellationToken,
     bridge_relayer_fee: u64,
     bridge_transaction_fee: u64,
+    proposing_balance_guard: Arc<ProposingBalanceGuard>,
 }
 
 const MONITOR_INTERVAL_SEC: u64 = 60;
@@ -39,6 +44,7 @@ impl FundsMonitor {
         cancel_token: CancellationToken,
         bridge_relayer_fee: u64,
         bridge_transaction_fee: u64,
+        proposing_balance_guard: Arc<ProposingBalanceGuard>,
     ) -> Self {
         Self {
             ethereum_l1,
@@ -53,6 +59,7 @@ impl FundsMonitor {
             cancel_token,
             bridge_relayer_fee,
             bridge_transaction_fee,
+            proposing_balance_guard,
         }
     }
 
@@ -131,6 +138,7 @@ impl FundsMonitor {
         let eth_balance_str = match eth_balance.as_ref() {
             Ok(balance) => {
                 self.metrics.set_preconfer_eth_balance(*balance);
+                self.proposing_balance_guard.update(*balance);
                 balance.to_string()
             }
             Err(e) => {
@@ -0,0 +1,91 @@
+use crate::metrics::Metrics;
+use alloy::primitives::U256;
+use std::sync::{
+    Arc,
+    atomic::{AtomicBool, Ordering},
+};
+use tracing::{error, info};
+
+/// Pauses batch proposing while the preconfer L1 balance is below the configured minimum.
+pub struct ProposingBalanceGuard {
+    // zero disables the guard
+    min_balance: U256,
+    paused: AtomicBool,
+    metrics: Arc<Metrics>,
+}
+
+impl ProposingBalanceGuard {
+    pub fn new(min_balance: u128, metrics: Arc<Metrics>) -> Self {
+        Self {
+            min_balance: U256::from(min_balance),
+            paused: AtomicBool::new(false),
+            metrics,
+        }
+    }
+
+    pub fn update(&self, l1_balance: U256) {
+        if self.min_balance.is_zero() {
+            return;
+        }
+
+        let paused = l1_balance < self.min_balance;
+        let was_paused = self.paused.swap(paused, Ordering::SeqCst);
+        if paused && !was_paused {
+            error!(
+                "⛔ Preconfer L1 balance ({}) is below the minimum required for proposing ({}), pausing batch proposing",
+                l1_balance, self.min_balance
+            );
+        } else if !paused && was_paused {
+            info!(
+                "✅ Preconfer L1 balance ({}) is sufficient again, resuming batch proposing",
+                l1_balance
+            );
+        }
+        self.metrics.set_proposing_paused_by_low_balance(paused);
+    }
+
+    pub fn is_proposing_paused(&self) -> bool {
+        self.paused.load(Ordering::SeqCst)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_proposing_paused_and_resumed() {
+        let metrics = Arc::new(Metrics::new());
+        let guard = ProposingBalanceGuard::new(1000, metrics.clone());
+        assert!(!guard.is_proposing_paused());
+
+        guard.update(U256::from(1000));
+        assert!(!guard.is_proposing_paused());
+
+        guard.update(U256::from(999));
+        assert!(guard.is_proposing_paused());
+        assert!(
+            metrics
+                .gather()
+                .contains("proposing_paused_by_low_l1_balance 1")
+        );
+
+        guard.update(U256::from(500));
+        assert!(guard.is_proposing_paused());
+
+        guard.update(U256::from(2000));
+        assert!(!guard.is_proposing_paused());
+        assert!(
+            metrics
+                .gather()
+                .contains("proposing_paused_by_low_l1_balance 0")
+        );
+    }
+
+    #[test]
+    fn test_guard_disabled() {
+        let guard = ProposingBalanceGuard::new(0, Arc::new(Metrics::new()));
+        guard.update(U256::ZERO);
+        assert!(!guard.is_proposing_paused());
+    }
+}
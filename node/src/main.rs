@@ -35,6 +35,7 @@ struct Args {
 }
 
 const SIGNER_TIMEOUT: Duration = Duration::from_secs(10);
+const SIGNER_HEALTH_CACHE_DURATION: Duration = Duration::from_secs(10);
 
 #[tokio::main]
 async fn main() -> Result<(), Error> {
@@ -45,7 +46,9 @@ async fn main() -> Result<(), Error> {
     let config = utils::config::Config::read_env_variables();
     let cancel_token = CancellationToken::new();
 
-    let metrics = Arc::new(Metrics::new());
+    let metrics = Arc::new(Metrics::new_with_operator_label(
+        config.metrics_operator_label.as_deref(),
+    ));
 
     // Set up panic hook to cancel token on panic
     let panic_cancel_token = cancel_token.clone();
@@ -61,15 +64,22 @@ async fn main() -> Result<(), Error> {
         config.web3signer_l1_url.clone(),
         config.catalyst_node_ecdsa_private_key.clone(),
         config.preconfer_address.clone(),
+        create_signer_fallback(&config, "L1", metrics.clone())?,
     )
     .await?;
     let l2_signer = create_signer(
         config.web3signer_l2_url.clone(),
         config.catalyst_node_ecdsa_private_key.clone(),
         config.preconfer_address.clone(),
+        create_signer_fallback(&config, "L2", metrics.clone())?,
     )
     .await?;
 
+    let signer_health = Arc::new(shared::signer::SignerHealthCheck::new(
+        vec![("L1", l1_signer.clone()), ("L2", l2_signer.clone())],
+        SIGNER_HEALTH_CACHE_DURATION,
+    ));
+
     let ethereum_l1 = ethereum_l1::EthereumL1::new(
         ethereum_l1::config::EthereumL1Config {
             execution_rpc_urls: config.l1_rpc_urls.clone(),
@@ -83,12 +93,20 @@ async fn main() -> Result<(), Error> {
             max_attempts_to_send_tx: config.max_attempts_to_send_tx,
             max_attempts_to_wait_tx: config.max_attempts_to_wait_tx,
             delay_between_tx_attempts_sec: config.delay_between_tx_attempts_sec,
+            max_attempts_to_resubmit_evicted_tx: config.max_attempts_to_resubmit_evicted_tx,
+            evicted_tx_timeout_sec: config.evicted_tx_timeout_sec,
+            tip_escalation_percentage_per_sec: config.tip_escalation_percentage_per_sec,
+            tip_escalation_cap_percentage: config.tip_escalation_cap_percentage,
+            receipt_batch_polling_interval_ms: config.receipt_batch_polling_interval_ms,
             signer: l1_signer,
             preconfer_address: config.preconfer_address.clone().map(|s| {
                 s.parse()
                     .expect("Preconfer address is not a valid Ethereum address")
             }),
             extra_gas_percentage: config.extra_gas_percentage,
+            validate_sender_authorization: config.validate_sender_authorization,
+            blob_fee_fallback: config.blob_fee_fallback,
+            startup_nonce_source: config.startup_nonce_source,
         },
         transaction_error_sender,
         metrics.clone(),
@@ -117,6 +135,13 @@ async fn main() -> Result<(), Error> {
     }
 
     let jwt_secret_bytes = utils::file_operations::read_jwt_secret(&config.jwt_secret_file_path)?;
+    let engine_api_export_jwt_secret_bytes = config
+        .engine_api_export_url
+        .as_ref()
+        .map(|_| {
+            utils::file_operations::read_jwt_secret(&config.engine_api_export_jwt_secret_file_path)
+        })
+        .transpose()?;
     let taiko = Arc::new(
         taiko::Taiko::new(
             ethereum_l1.clone(),
@@ -134,6 +159,16 @@ async fn main() -> Result<(), Error> {
                 config.rpc_l2_execution_layer_timeout,
                 config.rpc_driver_preconf_timeout,
                 config.rpc_driver_status_timeout,
+                config.tx_selection.clone(),
+                config.block_validation.clone(),
+                config.fee_recipients.clone(),
+                config.preconf_summary_push.clone(),
+                config.driver_head_reconcile_blocks,
+                config.engine_api_export_url.clone(),
+                engine_api_export_jwt_secret_bytes,
+                config.engine_api_export_timeout,
+                config.equivocation_guard_file.clone(),
+                config.propagate_trace_context,
                 l2_signer,
             )?,
         )
@@ -186,6 +221,25 @@ async fn main() -> Result<(), Error> {
         .await
         .map_err(|e| anyhow::anyhow!("Failed to start ChainMonitor: {}", e))?;
 
+    let proposing_balance_guard = Arc::new(
+        funds_monitor::proposing_balance_guard::ProposingBalanceGuard::new(
+            config.min_l1_balance_for_proposing,
+            metrics.clone(),
+        ),
+    );
+
+    let pre_seal_hook: Option<Arc<dyn node::batch_manager::pre_seal_hook::PreSealHook>> =
+        match config.pre_seal_hook_url.as_deref() {
+            Some(url) => Some(Arc::new(
+                node::batch_manager::pre_seal_hook::HttpPreSealHook::new(
+                    url,
+                    config.pre_seal_hook_timeout,
+                )
+                .map_err(|e| anyhow::anyhow!("Failed to create pre-seal hook: {}", e))?,
+            )),
+            None => None,
+        };
+
     let node = node::Node::new(
         cancel_token.clone(),
         taiko.clone(),
@@ -193,14 +247,32 @@ async fn main() -> Result<(), Error> {
         chain_monitor.clone(),
         transaction_error_receiver,
         metrics.clone(),
+        proposing_balance_guard.clone(),
+        signer_health.clone(),
         node::NodeConfig {
             preconf_heartbeat_ms: config.preconf_heartbeat_ms,
             handover_window_slots: config.handover_window_slots,
             handover_start_buffer_ms: config.handover_start_buffer_ms,
             l1_height_lag: config.l1_height_lag,
+            l1_anchor_block_tag: config.l1_anchor_block_tag,
             propose_forced_inclusion: config.propose_forced_inclusion,
             simulate_not_submitting_at_the_end_of_epoch: config
                 .simulate_not_submitting_at_the_end_of_epoch,
+            discard_unsafe_blocks_on_startup: config.discard_unsafe_blocks_on_startup,
+            preconf_cycle_deadline_ms: config.preconf_cycle_deadline_ms,
+            l1_halt_timeout_sec: config.l1_halt_timeout_sec,
+            l2_halt_timeout_sec: config.l2_halt_timeout_sec,
+            adaptive_min_blocks_per_batch: config.adaptive_min_blocks_per_batch,
+            congested_l1_base_fee_wei: config.congested_l1_base_fee_wei,
+            congested_l1_inclusion_delay_sec: config.congested_l1_inclusion_delay_sec,
+            signer_failure_threshold: config.signer_failure_threshold,
+            signer_failure_backoff_sec: config.signer_failure_backoff_sec,
+            signer_failure_max_backoff_sec: config.signer_failure_max_backoff_sec,
+            follow_on_duty_loss: config.follow_on_duty_loss,
+            preconf_loop_stall_timeout_sec: config.preconf_loop_stall_timeout_sec,
+            max_reorgs_per_window: config.max_reorgs_per_window,
+            reorg_rate_window_sec: config.reorg_rate_window_sec,
+            slot_report_file: config.slot_report_file.clone(),
         },
         node::batch_manager::config::BatchBuilderConfig {
             max_bytes_size_of_batch: config.max_bytes_size_of_batch,
@@ -209,10 +281,16 @@ async fn main() -> Result<(), Error> {
             max_time_shift_between_blocks_sec: config.max_time_shift_between_blocks_sec,
             max_anchor_height_offset: max_anchor_height_offset
                 - config.max_anchor_height_offset_reduction,
-            default_coinbase: ethereum_l1.execution_layer.get_preconfer_alloy_address(),
+            default_coinbase: taiko.get_fee_recipient(),
             preconf_min_txs: config.preconf_min_txs,
             preconf_max_skipped_l2_slots: config.preconf_max_skipped_l2_slots,
+            max_sealed_batches: config.max_sealed_batches,
+            split_batches_exceeding_l1_gas_limit: config.split_batches_exceeding_l1_gas_limit,
+            shrink_batches_on_size_revert: config.shrink_batches_on_size_revert,
+            preconf_to_anchor_latency_alert_sec: config.preconf_to_anchor_latency_alert_sec,
+            batch_metadata: config.batch_metadata.clone(),
         },
+        pre_seal_hook,
     )
     .await
     .map_err(|e| anyhow::anyhow!("Failed to create Node: {}", e))?;
@@ -232,13 +310,23 @@ async fn main() -> Result<(), Error> {
         cancel_token.clone(),
         config.bridge_relayer_fee,
         config.bridge_transaction_fee,
+        proposing_balance_guard,
     );
     funds_monitor.run();
 
-    metrics::server::serve_metrics(metrics.clone(), cancel_token.clone());
+    metrics::server::serve_metrics(metrics.clone(), signer_health, cancel_token.clone());
 
     wait_for_the_termination(cancel_token, config.l1_slot_duration_sec).await;
 
+    if let Some(metrics_snapshot_file) = &config.metrics_snapshot_file {
+        metrics::snapshot::write_on_shutdown(
+            &metrics,
+            metrics_snapshot_file,
+            config.metrics_snapshot_timeout,
+        )
+        .await;
+    }
+
     Ok(())
 }
 
@@ -246,6 +334,7 @@ async fn create_signer(
     web3signer_url: Option<String>,
     catalyst_node_ecdsa_private_key: Option<String>,
     preconfer_address: Option<String>,
+    fallback: Option<shared::signer_fallback::SignerFallback>,
 ) -> Result<Arc<Signer>, Error> {
     Ok(Arc::new(if let Some(web3signer_url) = web3signer_url {
         Signer::Web3signer(Arc::new(
@@ -255,6 +344,7 @@ async fn create_signer(
                 preconfer_address
                     .as_ref()
                     .expect("preconfer address is required for web3signer usage"),
+                fallback,
             )
             .await?,
         ))
@@ -265,6 +355,26 @@ async fn create_signer(
     }))
 }
 
+fn create_signer_fallback(
+    config: &utils::config::Config,
+    name: &'static str,
+    metrics: Arc<Metrics>,
+) -> Result<Option<shared::signer_fallback::SignerFallback>, Error> {
+    let (Some(private_key), Some(preconfer_address)) = (
+        config.signer_fallback_private_key.as_ref(),
+        config.preconfer_address.as_ref(),
+    ) else {
+        return Ok(None);
+    };
+    Ok(Some(shared::signer_fallback::SignerFallback::new(
+        name,
+        private_key,
+        preconfer_address.parse()?,
+        Duration::from_secs(config.signer_fallback_retry_remote_sec),
+        metrics,
+    )?))
+}
+
 async fn wait_for_the_termination(cancel_token: CancellationToken, shutdown_delay_secs: u64) {
     info!("Starting signal handler...");
     let mut sigterm = signal(SignalKind::terminate()).expect("Failed to set up SIGTERM handler");
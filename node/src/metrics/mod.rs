@@ -1,10 +1,14 @@
 use prometheus::{
-    Counter, CounterVec, Encoder, Gauge, Histogram, HistogramOpts, HistogramVec, Opts, Registry,
-    TextEncoder,
+    Counter, CounterVec, Encoder, Gauge, GaugeVec, Histogram, HistogramOpts, HistogramVec, Opts,
+    Registry, TextEncoder,
 };
+use std::collections::HashMap;
 use tracing::error;
 
 pub mod server;
+pub mod snapshot;
+
+const OPERATOR_LABEL: &str = "operator";
 
 pub struct Metrics {
     preconfer_eth_balance: Gauge,
@@ -23,12 +27,47 @@ pub struct Metrics {
     rpc_driver_call: CounterVec,
     rpc_driver_call_error: CounterVec,
     skipped_l2_slots_by_low_txs_count: Counter,
+    skipped_l2_slots_by_full_batch_queue: Counter,
+    batches_rejected_by_pre_seal_hook: Counter,
+    preconf_cycle_phase_duration: HistogramVec,
+    preconf_cycle_deadline_exceeded: CounterVec,
+    chain_halted: GaugeVec,
+    proposing_paused_by_low_l1_balance: Gauge,
+    signer_failures: Counter,
+    proposing_paused_by_signer_failures: Gauge,
+    max_blocks_per_batch: Gauge,
+    reorgs: CounterVec,
+    reorg_depth: Histogram,
+    preconf_loop_stalls: Counter,
+    preconf_to_anchor_latency_sec: Gauge,
+    preconf_to_anchor_latency_alert: Gauge,
+    tx_buffer_size: Gauge,
+    self_equivocations_prevented: Counter,
+    signer_fallback_active: GaugeVec,
+    signer_fallback_signatures: CounterVec,
+    proposing_paused_by_reorg_rate: Gauge,
     registry: Registry,
 }
 
 impl Metrics {
     pub fn new() -> Self {
-        let registry = Registry::new();
+        Self::new_with_operator_label(None)
+    }
+
+    /// The operator label is added to all metrics, so the metrics of several nodes
+    /// scraped into one Prometheus can be told apart.
+    pub fn new_with_operator_label(operator: Option<&str>) -> Self {
+        let registry = match operator {
+            Some(operator) => Registry::new_custom(
+                None,
+                Some(HashMap::from([(
+                    OPERATOR_LABEL.to_string(),
+                    operator.to_string(),
+                )])),
+            )
+            .expect("Failed to create metrics registry with the operator label"),
+            None => Registry::new(),
+        };
 
         let preconfer_eth_balance = Gauge::new(
             "preconfer_eth_balance",
@@ -222,6 +261,265 @@ impl Metrics {
             );
         }
 
+        let skipped_l2_slots_by_full_batch_queue = Counter::new(
+            "skipped_l2_slots_by_full_batch_queue",
+            "Number of skipped L2 slots because the queue of sealed batches is full",
+        )
+        .expect("Failed to create skipped_l2_slots_by_full_batch_queue counter");
+
+        if let Err(err) = registry.register(Box::new(skipped_l2_slots_by_full_batch_queue.clone()))
+        {
+            error!(
+                "Error: Failed to register skipped_l2_slots_by_full_batch_queue: {}",
+                err
+            );
+        }
+
+        let opts = HistogramOpts::new(
+            "preconf_cycle_phase_duration_seconds",
+            "Duration of each phase of the preconfirmation cycle in seconds",
+        )
+        .buckets(vec![
+            0.01, 0.05, 0.1, 0.25, 0.5, 0.75, 1.0, 1.5, 2.0, 3.0, 5.0, 10.0,
+        ]);
+
+        let preconf_cycle_phase_duration = match HistogramVec::new(opts, &["phase"]) {
+            Ok(histogram) => histogram,
+            Err(err) => panic!("Failed to create preconf_cycle_phase_duration histogram: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(preconf_cycle_phase_duration.clone())) {
+            error!(
+                "Error: Failed to register preconf_cycle_phase_duration: {}",
+                err
+            );
+        }
+
+        let preconf_cycle_deadline_exceeded = match CounterVec::new(
+            Opts::new(
+                "preconf_cycle_deadline_exceeded",
+                "Number of preconfirmation cycle phases skipped because of the cycle deadline",
+            ),
+            &["phase"],
+        ) {
+            Ok(counter) => counter,
+            Err(err) => panic!("Failed to create preconf_cycle_deadline_exceeded counter: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(preconf_cycle_deadline_exceeded.clone())) {
+            error!(
+                "Error: Failed to register preconf_cycle_deadline_exceeded: {}",
+                err
+            );
+        }
+
+        let chain_halted = match GaugeVec::new(
+            Opts::new(
+                "chain_halted",
+                "Set to 1 when the chain has not produced a new block within the configured timeout",
+            ),
+            &["chain"],
+        ) {
+            Ok(gauge) => gauge,
+            Err(err) => panic!("Failed to create chain_halted gauge: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(chain_halted.clone())) {
+            error!("Error: Failed to register chain_halted: {}", err);
+        }
+
+        let proposing_paused_by_low_l1_balance = Gauge::new(
+            "proposing_paused_by_low_l1_balance",
+            "Set to 1 when batch proposing is paused because of low preconfer L1 balance",
+        )
+        .expect("Failed to create proposing_paused_by_low_l1_balance gauge");
+
+        if let Err(err) = registry.register(Box::new(proposing_paused_by_low_l1_balance.clone())) {
+            error!(
+                "Error: Failed to register proposing_paused_by_low_l1_balance: {}",
+                err
+            );
+        }
+
+        let signer_failures = Counter::new(
+            "signer_failures",
+            "Number of failed signer checks before batch submission",
+        )
+        .expect("Failed to create signer_failures counter");
+
+        if let Err(err) = registry.register(Box::new(signer_failures.clone())) {
+            error!("Error: Failed to register signer_failures: {}", err);
+        }
+
+        let proposing_paused_by_signer_failures = Gauge::new(
+            "proposing_paused_by_signer_failures",
+            "Set to 1 when batch proposing is backed off because of repeated signer failures",
+        )
+        .expect("Failed to create proposing_paused_by_signer_failures gauge");
+
+        if let Err(err) = registry.register(Box::new(proposing_paused_by_signer_failures.clone())) {
+            error!(
+                "Error: Failed to register proposing_paused_by_signer_failures: {}",
+                err
+            );
+        }
+
+        let max_blocks_per_batch = Gauge::new(
+            "max_blocks_per_batch",
+            "Max number of L2 blocks per batch currently in use",
+        )
+        .expect("Failed to create max_blocks_per_batch gauge");
+
+        if let Err(err) = registry.register(Box::new(max_blocks_per_batch.clone())) {
+            error!("Error: Failed to register max_blocks_per_batch: {}", err);
+        }
+
+        let batches_rejected_by_pre_seal_hook = Counter::new(
+            "batches_rejected_by_pre_seal_hook",
+            "Number of batch submission attempts rejected by the pre-seal hook",
+        )
+        .expect("Failed to create batches_rejected_by_pre_seal_hook counter");
+
+        if let Err(err) = registry.register(Box::new(batches_rejected_by_pre_seal_hook.clone())) {
+            error!(
+                "Error: Failed to register batches_rejected_by_pre_seal_hook: {}",
+                err
+            );
+        }
+
+        let reorgs = match CounterVec::new(
+            Opts::new(
+                "reorgs_total",
+                "Number of L2 reorgs done by the node by result",
+            ),
+            &["result"],
+        ) {
+            Ok(counter) => counter,
+            Err(err) => panic!("Failed to create reorgs_total counter: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(reorgs.clone())) {
+            error!("Error: Failed to register reorgs_total: {}", err);
+        }
+
+        let opts = HistogramOpts::new("reorg_depth", "Number of L2 blocks replaced by a reorg")
+            .buckets(vec![
+                0.0, 1.0, 2.0, 4.0, 8.0, 16.0, 32.0, 64.0, 128.0, 256.0, 512.0,
+            ]);
+        let reorg_depth = match Histogram::with_opts(opts) {
+            Ok(histogram) => histogram,
+            Err(err) => panic!("Failed to create reorg_depth histogram: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(reorg_depth.clone())) {
+            error!("Error: Failed to register reorg_depth: {}", err);
+        }
+
+        let preconf_loop_stalls = Counter::new(
+            "preconf_loop_stalls",
+            "Number of times the preconfirmation loop stopped ticking",
+        )
+        .expect("Failed to create preconf_loop_stalls counter");
+
+        if let Err(err) = registry.register(Box::new(preconf_loop_stalls.clone())) {
+            error!("Error: Failed to register preconf_loop_stalls: {}", err);
+        }
+
+        let preconf_to_anchor_latency_sec = Gauge::new(
+            "preconf_to_anchor_latency_sec",
+            "Time between the anchor L1 block and the last preconfirmed L2 block in seconds",
+        )
+        .expect("Failed to create preconf_to_anchor_latency_sec gauge");
+
+        if let Err(err) = registry.register(Box::new(preconf_to_anchor_latency_sec.clone())) {
+            error!(
+                "Error: Failed to register preconf_to_anchor_latency_sec: {}",
+                err
+            );
+        }
+
+        let preconf_to_anchor_latency_alert = Gauge::new(
+            "preconf_to_anchor_latency_alert",
+            "Set to 1 when the preconf-to-anchor latency exceeds the alert threshold",
+        )
+        .expect("Failed to create preconf_to_anchor_latency_alert gauge");
+
+        if let Err(err) = registry.register(Box::new(preconf_to_anchor_latency_alert.clone())) {
+            error!(
+                "Error: Failed to register preconf_to_anchor_latency_alert: {}",
+                err
+            );
+        }
+
+        let tx_buffer_size = Gauge::new(
+            "tx_buffer_size",
+            "Max number of txs taken from a single tx pool poll",
+        )
+        .expect("Failed to create tx_buffer_size gauge");
+
+        if let Err(err) = registry.register(Box::new(tx_buffer_size.clone())) {
+            error!("Error: Failed to register tx_buffer_size: {}", err);
+        }
+
+        let self_equivocations_prevented = Counter::new(
+            "self_equivocations_prevented",
+            "Number of conflicting blocks refused for an already preconfirmed L2 slot",
+        )
+        .expect("Failed to create self_equivocations_prevented counter");
+
+        if let Err(err) = registry.register(Box::new(self_equivocations_prevented.clone())) {
+            error!(
+                "Error: Failed to register self_equivocations_prevented: {}",
+                err
+            );
+        }
+
+        let signer_fallback_active = match GaugeVec::new(
+            Opts::new(
+                "signer_fallback_active",
+                "Whether txs are signed by the local fallback signer instead of the remote signer",
+            ),
+            &["signer"],
+        ) {
+            Ok(gauge) => gauge,
+            Err(err) => panic!("Failed to create signer_fallback_active gauge: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(signer_fallback_active.clone())) {
+            error!("Error: Failed to register signer_fallback_active: {}", err);
+        }
+
+        let signer_fallback_signatures = match CounterVec::new(
+            Opts::new(
+                "signer_fallback_signatures",
+                "Number of txs signed by the local fallback signer",
+            ),
+            &["signer"],
+        ) {
+            Ok(counter) => counter,
+            Err(err) => panic!("Failed to create signer_fallback_signatures counter: {err}"),
+        };
+
+        if let Err(err) = registry.register(Box::new(signer_fallback_signatures.clone())) {
+            error!(
+                "Error: Failed to register signer_fallback_signatures: {}",
+                err
+            );
+        }
+
+        let proposing_paused_by_reorg_rate = Gauge::new(
+            "proposing_paused_by_reorg_rate",
+            "Set to 1 when batch proposing is paused because of too frequent L2 reorgs",
+        )
+        .expect("Failed to create proposing_paused_by_reorg_rate gauge");
+
+        if let Err(err) = registry.register(Box::new(proposing_paused_by_reorg_rate.clone())) {
+            error!(
+                "Error: Failed to register proposing_paused_by_reorg_rate: {}",
+                err
+            );
+        }
+
         Self {
             preconfer_eth_balance,
             preconfer_taiko_balance,
@@ -239,6 +537,25 @@ impl Metrics {
             rpc_driver_call,
             rpc_driver_call_error,
             skipped_l2_slots_by_low_txs_count,
+            skipped_l2_slots_by_full_batch_queue,
+            batches_rejected_by_pre_seal_hook,
+            preconf_cycle_phase_duration,
+            preconf_cycle_deadline_exceeded,
+            chain_halted,
+            proposing_paused_by_low_l1_balance,
+            signer_failures,
+            proposing_paused_by_signer_failures,
+            max_blocks_per_batch,
+            reorgs,
+            reorg_depth,
+            preconf_loop_stalls,
+            preconf_to_anchor_latency_sec,
+            preconf_to_anchor_latency_alert,
+            tx_buffer_size,
+            self_equivocations_prevented,
+            signer_fallback_active,
+            signer_fallback_signatures,
+            proposing_paused_by_reorg_rate,
             registry,
         }
     }
@@ -339,6 +656,138 @@ impl Metrics {
         self.skipped_l2_slots_by_low_txs_count.inc();
     }
 
+    pub fn inc_skipped_l2_slots_by_full_batch_queue(&self) {
+        self.skipped_l2_slots_by_full_batch_queue.inc();
+    }
+
+    pub fn inc_batches_rejected_by_pre_seal_hook(&self) {
+        self.batches_rejected_by_pre_seal_hook.inc();
+    }
+
+    pub fn set_chain_halted(&self, chain: &str, halted: bool) {
+        if let Ok(metric) = self.chain_halted.get_metric_with_label_values(&[chain]) {
+            metric.set(if halted { 1.0 } else { 0.0 });
+        } else {
+            error!("Failed to set chain halted gauge for chain: {}", chain);
+        }
+    }
+
+    pub fn set_proposing_paused_by_low_balance(&self, paused: bool) {
+        self.proposing_paused_by_low_l1_balance
+            .set(if paused { 1.0 } else { 0.0 });
+    }
+
+    pub fn inc_signer_failures(&self) {
+        self.signer_failures.inc();
+    }
+
+    pub fn set_proposing_paused_by_signer_failures(&self, paused: bool) {
+        self.proposing_paused_by_signer_failures
+            .set(if paused { 1.0 } else { 0.0 });
+    }
+
+    pub fn set_max_blocks_per_batch(&self, max_blocks_per_batch: u16) {
+        self.max_blocks_per_batch
+            .set(f64::from(max_blocks_per_batch));
+    }
+
+    pub fn observe_preconf_cycle_phase_duration(&self, phase: &str, duration: f64) {
+        if let Ok(metric) = self
+            .preconf_cycle_phase_duration
+            .get_metric_with_label_values(&[phase])
+        {
+            metric.observe(duration);
+        } else {
+            error!(
+                "Failed to observe preconf cycle phase duration for phase: {}",
+                phase
+            );
+        }
+    }
+
+    pub fn inc_preconf_cycle_deadline_exceeded(&self, phase: &str) {
+        if let Ok(metric) = self
+            .preconf_cycle_deadline_exceeded
+            .get_metric_with_label_values(&[phase])
+        {
+            metric.inc();
+        } else {
+            error!(
+                "Failed to increment preconf cycle deadline exceeded counter for phase: {}",
+                phase
+            );
+        }
+    }
+
+    pub fn inc_reorgs(&self, result: &str) {
+        if let Ok(metric) = self.reorgs.get_metric_with_label_values(&[result]) {
+            metric.inc();
+        } else {
+            error!("Failed to increment reorgs counter for result: {}", result);
+        }
+    }
+
+    #[allow(clippy::cast_precision_loss)]
+    pub fn observe_reorg_depth(&self, depth: u64) {
+        self.reorg_depth.observe(depth as f64);
+    }
+
+    pub fn inc_preconf_loop_stalls(&self) {
+        self.preconf_loop_stalls.inc();
+    }
+
+    #[allow(clippy::cast_precision_loss)]
+    pub fn set_preconf_to_anchor_latency_sec(&self, latency_sec: u64) {
+        self.preconf_to_anchor_latency_sec.set(latency_sec as f64);
+    }
+
+    pub fn set_preconf_to_anchor_latency_alert(&self, alerting: bool) {
+        self.preconf_to_anchor_latency_alert
+            .set(if alerting { 1.0 } else { 0.0 });
+    }
+
+    #[allow(clippy::cast_precision_loss)]
+    pub fn set_tx_buffer_size(&self, size: u64) {
+        self.tx_buffer_size.set(size as f64);
+    }
+
+    pub fn inc_self_equivocations_prevented(&self) {
+        self.self_equivocations_prevented.inc();
+    }
+
+    pub fn set_signer_fallback_active(&self, signer: &str, active: bool) {
+        if let Ok(metric) = self
+            .signer_fallback_active
+            .get_metric_with_label_values(&[signer])
+        {
+            metric.set(if active { 1.0 } else { 0.0 });
+        } else {
+            error!(
+                "Failed to set signer fallback active gauge for signer: {}",
+                signer
+            );
+        }
+    }
+
+    pub fn inc_signer_fallback_signatures(&self, signer: &str) {
+        if let Ok(metric) = self
+            .signer_fallback_signatures
+            .get_metric_with_label_values(&[signer])
+        {
+            metric.inc();
+        } else {
+            error!(
+                "Failed to increment signer fallback signatures for signer: {}",
+                signer
+            );
+        }
+    }
+
+    pub fn set_proposing_paused_by_reorg_rate(&self, paused: bool) {
+        self.proposing_paused_by_reorg_rate
+            .set(if paused { 1.0 } else { 0.0 });
+    }
+
     fn u256_to_f64(balance: alloy::primitives::U256) -> f64 {
         let balance_str = balance.to_string();
         let len = balance_str.len();
@@ -395,6 +844,13 @@ mod tests {
         metrics.observe_batch_info(5, 1000);
         metrics.observe_block_tx_count(3);
         metrics.inc_skipped_l2_slots_by_low_txs_count();
+        metrics.inc_skipped_l2_slots_by_full_batch_queue();
+        metrics.inc_batches_rejected_by_pre_seal_hook();
+        metrics.observe_preconf_cycle_phase_duration("Preconfirm", 0.5);
+        metrics.inc_preconf_cycle_deadline_exceeded("Submit");
+        metrics.set_chain_halted("L1", true);
+        metrics.set_proposing_paused_by_low_balance(true);
+        metrics.set_max_blocks_per_batch(20);
 
         let output = metrics.gather();
         println!("{output}");
@@ -413,6 +869,28 @@ mod tests {
         assert!(output.contains("block_tx_count_count 1"));
         assert!(output.contains("block_tx_count_sum 3"));
         assert!(output.contains("skipped_l2_slots_by_low_txs_count 1"));
+        assert!(output.contains("skipped_l2_slots_by_full_batch_queue 1"));
+        assert!(output.contains("batches_rejected_by_pre_seal_hook 1"));
+        assert!(
+            output.contains("preconf_cycle_phase_duration_seconds_sum{phase=\"Preconfirm\"} 0.5")
+        );
+        assert!(output.contains("preconf_cycle_deadline_exceeded{phase=\"Submit\"} 1"));
+        assert!(output.contains("chain_halted{chain=\"L1\"} 1"));
+        assert!(output.contains("proposing_paused_by_low_l1_balance 1"));
+        assert!(output.contains("max_blocks_per_batch 20"));
+    }
+
+    #[test]
+    fn test_operator_label() {
+        let metrics = Metrics::new_with_operator_label(Some("catalyst-1"));
+        metrics.inc_blocks_preconfirmed();
+        metrics.set_chain_halted("L2", true);
+
+        let output = metrics.gather();
+        assert!(output.contains("blocks_preconfirmed{operator=\"catalyst-1\"} 1"));
+        assert!(output.contains("chain_halted{chain=\"L2\",operator=\"catalyst-1\"} 1"));
+        assert!(output.contains("preconfer_eth_balance{operator=\"catalyst-1\"} 0"));
+        assert!(!Metrics::new().gather().contains("operator="));
     }
 
     #[test]
@@ -1,18 +1,36 @@
-use crate::metrics::Metrics;
+use crate::{metrics::Metrics, shared::signer::SignerHealthCheck};
 use std::sync::Arc;
 use tokio_util::sync::CancellationToken;
 use tracing::info;
-use warp::Filter;
+use warp::{Filter, http::StatusCode};
 
-pub fn serve_metrics(metrics: Arc<Metrics>, cancel_token: CancellationToken) {
+pub fn serve_metrics(
+    metrics: Arc<Metrics>,
+    signer_health: Arc<SignerHealthCheck>,
+    cancel_token: CancellationToken,
+) {
     tokio::spawn(async move {
-        let route = warp::path!("metrics").map(move || {
+        let metrics_route = warp::path!("metrics").map(move || {
             let output = metrics.gather();
             warp::reply::with_header(output, "Content-Type", "text/plain; version=0.0.4")
         });
 
-        let (addr, server) =
-            warp::serve(route).bind_with_graceful_shutdown(([0, 0, 0, 0], 9898), async move {
+        let readyz_route = warp::path!("readyz").and_then(move || {
+            let signer_health = signer_health.clone();
+            async move {
+                let reply = match signer_health.check().await {
+                    Ok(()) => warp::reply::with_status("ready".to_string(), StatusCode::OK),
+                    Err(err) => warp::reply::with_status(
+                        format!("not ready: {err}"),
+                        StatusCode::SERVICE_UNAVAILABLE,
+                    ),
+                };
+                Ok::<_, warp::Rejection>(reply)
+            }
+        });
+
+        let (addr, server) = warp::serve(metrics_route.or(readyz_route))
+            .bind_with_graceful_shutdown(([0, 0, 0, 0], 9898), async move {
                 cancel_token.cancelled().await;
                 info!("Shutdown signal received, stopping metrics server...");
             });
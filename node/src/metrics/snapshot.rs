@@ -0,0 +1,66 @@
+use super::Metrics;
+use anyhow::Error;
+use std::time::Duration;
+use tracing::{info, warn};
+
+/// Writes the last state of the metrics to a file on graceful shutdown, for post-mortems.
+/// The write is bounded by the timeout, so a stuck disk does not hold the shutdown.
+pub async fn write_on_shutdown(metrics: &Metrics, path: &str, timeout: Duration) -> bool {
+    match tokio::time::timeout(timeout, write_snapshot(metrics, path)).await {
+        Ok(Ok(())) => {
+            info!("Metrics snapshot written to {}", path);
+            true
+        }
+        Ok(Err(err)) => {
+            warn!("Failed to write metrics snapshot to {}: {}", path, err);
+            false
+        }
+        Err(_) => {
+            warn!(
+                "Writing metrics snapshot to {} timed out after {}ms",
+                path,
+                timeout.as_millis()
+            );
+            false
+        }
+    }
+}
+
+async fn write_snapshot(metrics: &Metrics, path: &str) -> Result<(), Error> {
+    tokio::fs::write(path, metrics.gather())
+        .await
+        .map_err(|e| anyhow::anyhow!("Failed to write file: {}", e))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tempfile::TempDir;
+
+    #[tokio::test]
+    async fn test_snapshot_is_written_on_shutdown() {
+        let metrics = Metrics::new();
+        metrics.inc_blocks_preconfirmed();
+        let dir = TempDir::new().unwrap();
+        let path = dir.path().join("snapshot").to_string_lossy().to_string();
+
+        assert!(write_on_shutdown(&metrics, &path, Duration::from_secs(1)).await);
+        let snapshot = std::fs::read_to_string(&path).unwrap();
+        assert_eq!(snapshot, metrics.gather());
+        assert!(snapshot.contains("blocks_preconfirmed 1"));
+    }
+
+    #[tokio::test]
+    async fn test_snapshot_failure_does_not_block_shutdown() {
+        let metrics = Metrics::new();
+        let dir = TempDir::new().unwrap();
+        let path = dir
+            .path()
+            .join("missing_dir")
+            .join("snapshot")
+            .to_string_lossy()
+            .to_string();
+
+        assert!(!write_on_shutdown(&metrics, &path, Duration::from_secs(1)).await);
+    }
+}
@@ -0,0 +1,100 @@
+use crate::metrics::Metrics;
+use std::sync::Arc;
+use tracing::{error, info};
+
+/// Alerts when the preconfirmed L2 blocks anchor L1 blocks that are too old, which means
+/// the anchoring falls behind and the batches are close to the max anchor height offset.
+#[derive(Clone)]
+pub struct AnchorLatencyAlert {
+    // zero disables the alert
+    threshold_sec: u64,
+    alerting: bool,
+    metrics: Arc<Metrics>,
+}
+
+impl AnchorLatencyAlert {
+    pub fn new(threshold_sec: u64, metrics: Arc<Metrics>) -> Self {
+        Self {
+            threshold_sec,
+            alerting: false,
+            metrics,
+        }
+    }
+
+    /// Called for every preconfirmed L2 block with the timestamp of its anchor block.
+    pub fn observe(&mut self, l2_block_timestamp_sec: u64, anchor_block_timestamp_sec: u64) {
+        let latency_sec = l2_block_timestamp_sec.saturating_sub(anchor_block_timestamp_sec);
+        self.metrics.set_preconf_to_anchor_latency_sec(latency_sec);
+        if self.threshold_sec == 0 {
+            return;
+        }
+
+        if latency_sec > self.threshold_sec {
+            if !self.alerting {
+                error!(
+                    "⛔ Preconf-to-anchor latency {}s exceeds the alert threshold of {}s",
+                    latency_sec, self.threshold_sec
+                );
+                self.alerting = true;
+            }
+        } else if self.alerting {
+            info!(
+                "✅ Preconf-to-anchor latency recovered to {}s, alert threshold {}s",
+                latency_sec, self.threshold_sec
+            );
+            self.alerting = false;
+        }
+        self.metrics
+            .set_preconf_to_anchor_latency_alert(self.alerting);
+    }
+
+    pub fn is_alerting(&self) -> bool {
+        self.alerting
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const ANCHOR_TIMESTAMP: u64 = 1_000;
+
+    #[test]
+    fn test_alert_fires_and_clears() {
+        let mut alert = AnchorLatencyAlert::new(60, Arc::new(Metrics::new()));
+
+        alert.observe(ANCHOR_TIMESTAMP + 60, ANCHOR_TIMESTAMP);
+        assert!(!alert.is_alerting());
+
+        // the anchor block is not updated while L2 blocks keep coming
+        alert.observe(ANCHOR_TIMESTAMP + 61, ANCHOR_TIMESTAMP);
+        assert!(alert.is_alerting());
+        let metrics = alert.metrics.gather();
+        assert!(metrics.contains("preconf_to_anchor_latency_alert 1"));
+        assert!(metrics.contains("preconf_to_anchor_latency_sec 61"));
+
+        alert.observe(ANCHOR_TIMESTAMP + 120, ANCHOR_TIMESTAMP);
+        assert!(alert.is_alerting());
+
+        // a new batch anchors a recent L1 block
+        alert.observe(ANCHOR_TIMESTAMP + 122, ANCHOR_TIMESTAMP + 110);
+        assert!(!alert.is_alerting());
+        let metrics = alert.metrics.gather();
+        assert!(metrics.contains("preconf_to_anchor_latency_alert 0"));
+        assert!(metrics.contains("preconf_to_anchor_latency_sec 12"));
+    }
+
+    #[test]
+    fn test_alert_disabled() {
+        let mut alert = AnchorLatencyAlert::new(0, Arc::new(Metrics::new()));
+
+        alert.observe(ANCHOR_TIMESTAMP + 3600, ANCHOR_TIMESTAMP);
+        assert!(!alert.is_alerting());
+        assert!(
+            alert
+                .metrics
+                .gather()
+                .contains("preconf_to_anchor_latency_sec 3600")
+        );
+    }
+}
@@ -1,6 +1,8 @@
 use crate::shared::l2_block::L2Block;
 use crate::shared::l2_tx_lists::encode_and_compress;
+use crate::utils::blob::constants::MAX_BLOB_DATA_SIZE;
 use alloy::primitives::Address;
+use anyhow::Error;
 use std::time::Instant;
 use tracing::{debug, warn};
 
@@ -34,6 +36,80 @@ impl Batch {
         let duration = start.elapsed();
         debug!("Batch compression completed in {} ms", duration.as_millis());
     }
+
+    /// Splits the batch into two batches with the same anchor and coinbase,
+    /// returns None when the batch has less than two blocks.
+    pub fn split_in_half(mut self) -> Option<(Batch, Batch)> {
+        if self.l2_blocks.len() < 2 {
+            return None;
+        }
+        let second_half_blocks = self.l2_blocks.split_off(self.l2_blocks.len() / 2);
+        let second_half = Batch {
+            total_bytes: total_bytes_of(&second_half_blocks),
+            l2_blocks: second_half_blocks,
+            coinbase: self.coinbase,
+            anchor_block_id: self.anchor_block_id,
+            anchor_block_timestamp_sec: self.anchor_block_timestamp_sec,
+        };
+        self.total_bytes = total_bytes_of(&self.l2_blocks);
+        Some((self, second_half))
+    }
+
+    /// Number of blobs needed for the compressed tx lists of the batch.
+    pub fn blobs_needed(&self) -> Result<u64, Error> {
+        blobs_needed_for(&self.l2_blocks)
+    }
+
+    /// Keeps the longest prefix of blocks which fits into `max_blobs` blobs and returns
+    /// the rest of the blocks as a new batch with the same anchor and coinbase.
+    /// Returns None when the batch already fits or even its first block does not fit.
+    pub fn split_off_over_blobs(&mut self, max_blobs: u64) -> Result<Option<Batch>, Error> {
+        if blobs_needed_for(&self.l2_blocks)? <= max_blobs
+            || blobs_needed_for(&self.l2_blocks[..1])? > max_blobs
+        {
+            return Ok(None);
+        }
+
+        // the compressed size grows with the number of blocks
+        let mut fitting = 1;
+        let mut not_fitting = self.l2_blocks.len();
+        while not_fitting - fitting > 1 {
+            let middle = fitting + (not_fitting - fitting) / 2;
+            if blobs_needed_for(&self.l2_blocks[..middle])? <= max_blobs {
+                fitting = middle;
+            } else {
+                not_fitting = middle;
+            }
+        }
+
+        let rest_blocks = self.l2_blocks.split_off(fitting);
+        self.compress();
+        let mut rest = Batch {
+            l2_blocks: rest_blocks,
+            total_bytes: 0,
+            coinbase: self.coinbase,
+            anchor_block_id: self.anchor_block_id,
+            anchor_block_timestamp_sec: self.anchor_block_timestamp_sec,
+        };
+        rest.compress();
+        Ok(Some(rest))
+    }
+}
+
+fn blobs_needed_for(l2_blocks: &[L2Block]) -> Result<u64, Error> {
+    let tx_vec: Vec<_> = l2_blocks
+        .iter()
+        .flat_map(|block| block.prebuilt_tx_list.tx_list.clone())
+        .collect();
+    let size = encode_and_compress(&tx_vec)?.len();
+    Ok(u64::try_from(size.div_ceil(MAX_BLOB_DATA_SIZE))?)
+}
+
+fn total_bytes_of(l2_blocks: &[L2Block]) -> u64 {
+    l2_blocks
+        .iter()
+        .map(|block| block.prebuilt_tx_list.bytes_length)
+        .sum()
 }
 
 // add test
@@ -92,4 +168,49 @@ mod tests {
 
         assert_eq!(batch.total_bytes, 249);
     }
+
+    #[test]
+    fn test_split_in_half() {
+        let batch = Batch {
+            l2_blocks: (1..=5)
+                .map(|i| L2Block {
+                    prebuilt_tx_list: shared::l2_tx_lists::PreBuiltTxList {
+                        tx_list: vec![],
+                        estimated_gas_used: 0,
+                        bytes_length: i * 10,
+                    },
+                    timestamp_sec: 1000 + i,
+                })
+                .collect(),
+            total_bytes: 150,
+            coinbase: Address::repeat_byte(1),
+            anchor_block_id: 7,
+            anchor_block_timestamp_sec: 990,
+        };
+
+        let (first, second) = batch.split_in_half().unwrap();
+        let timestamps = |batch: &Batch| {
+            batch
+                .l2_blocks
+                .iter()
+                .map(|block| block.timestamp_sec)
+                .collect::<Vec<_>>()
+        };
+        assert_eq!(timestamps(&first), vec![1001, 1002]);
+        assert_eq!(timestamps(&second), vec![1003, 1004, 1005]);
+        assert_eq!(first.total_bytes, 30);
+        assert_eq!(second.total_bytes, 120);
+        for half in [&first, &second] {
+            assert_eq!(half.coinbase, Address::repeat_byte(1));
+            assert_eq!(half.anchor_block_id, 7);
+            assert_eq!(half.anchor_block_timestamp_sec, 990);
+        }
+
+        assert!(first.split_in_half().is_some());
+        let single_block_batch = Batch {
+            l2_blocks: vec![L2Block::new_empty(1000)],
+            ..Default::default()
+        };
+        assert!(single_block_batch.split_in_half().is_none());
+    }
 }
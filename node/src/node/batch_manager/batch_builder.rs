@@ -1,6 +1,10 @@
 use std::{collections::VecDeque, sync::Arc};
 
-use super::config::{BatchesToSend, ForcedInclusionBatch};
+use super::{
+    anchor_latency_alert::AnchorLatencyAlert,
+    config::{BatchesToSend, ForcedInclusionBatch},
+    pre_seal_hook::PreSealHook,
+};
 use crate::{
     ethereum_l1::{EthereumL1, slot_clock::SlotClock, transaction_error::TransactionError},
     metrics::Metrics,
@@ -9,7 +13,7 @@ use crate::{
 };
 use alloy::primitives::Address;
 use anyhow::Error;
-use tracing::{debug, error, trace, warn};
+use tracing::{debug, error, info, trace, warn};
 
 pub struct BatchBuilder {
     config: BatchBuilderConfig,
@@ -18,6 +22,10 @@ pub struct BatchBuilder {
     current_forced_inclusion: ForcedInclusionBatch,
     slot_clock: Arc<SlotClock>,
     metrics: Arc<Metrics>,
+    pre_seal_hook: Option<Arc<dyn PreSealHook>>,
+    anchor_latency_alert: AnchorLatencyAlert,
+    // upper bound of the blocks per batch learnt from the reverts on the on-chain limit
+    max_blocks_per_batch_limit: u16,
 }
 
 impl Drop for BatchBuilder {
@@ -35,7 +43,11 @@ impl BatchBuilder {
         config: BatchBuilderConfig,
         slot_clock: Arc<SlotClock>,
         metrics: Arc<Metrics>,
+        pre_seal_hook: Option<Arc<dyn PreSealHook>>,
     ) -> Self {
+        let anchor_latency_alert =
+            AnchorLatencyAlert::new(config.preconf_to_anchor_latency_alert_sec, metrics.clone());
+        let max_blocks_per_batch_limit = config.max_blocks_per_batch;
         Self {
             config,
             batches_to_send: VecDeque::new(),
@@ -43,6 +55,9 @@ impl BatchBuilder {
             current_forced_inclusion: None,
             slot_clock,
             metrics,
+            pre_seal_hook,
+            anchor_latency_alert,
+            max_blocks_per_batch_limit,
         }
     }
 
@@ -53,6 +68,15 @@ impl BatchBuilder {
         &self.config
     }
 
+    pub fn get_pre_seal_hook(&self) -> Option<Arc<dyn PreSealHook>> {
+        self.pre_seal_hook.clone()
+    }
+
+    pub fn set_max_blocks_per_batch(&mut self, max_blocks_per_batch: u16) {
+        self.config.max_blocks_per_batch =
+            max_blocks_per_batch.min(self.max_blocks_per_batch_limit);
+    }
+
     pub fn can_consume_l2_block(&mut self, l2_block: &L2Block) -> bool {
         let is_time_shift_expired = self.is_time_shift_expired(l2_block.timestamp_sec);
         self.current_batch.as_mut().is_some_and(|batch| {
@@ -96,6 +120,17 @@ impl BatchBuilder {
         }
     }
 
+    /// Returns false when the current batch has blocks but the queue of sealed batches
+    /// waiting for submission is full, so sealing has to wait until the queue drains.
+    pub fn can_seal_current_batch(&self) -> bool {
+        self.current_batch
+            .as_ref()
+            .is_none_or(|batch| batch.l2_blocks.is_empty())
+            || self
+                .config
+                .is_within_sealed_batches_limit(self.get_number_of_batches_ready_to_send())
+    }
+
     pub fn has_current_forced_inclusion(&self) -> bool {
         self.current_forced_inclusion.is_some()
     }
@@ -155,6 +190,8 @@ impl BatchBuilder {
         coinbase: Option<Address>,
     ) {
         self.finalize_current_batch();
+        self.anchor_latency_alert
+            .observe(l2_block.timestamp_sec, anchor_block_timestamp_sec);
         self.current_batch = Some(Batch {
             total_bytes: l2_block.prebuilt_tx_list.bytes_length,
             l2_blocks: vec![l2_block],
@@ -170,6 +207,10 @@ impl BatchBuilder {
         l2_block: L2Block,
     ) -> Result<u64, Error> {
         if let Some(current_batch) = self.current_batch.as_mut() {
+            self.anchor_latency_alert.observe(
+                l2_block.timestamp_sec,
+                current_batch.anchor_block_timestamp_sec,
+            );
             current_batch.total_bytes += l2_block.prebuilt_tx_list.bytes_length;
             current_batch.l2_blocks.push(l2_block);
             debug!(
@@ -276,6 +317,7 @@ impl BatchBuilder {
         submit_only_full_batches: bool,
     ) -> Result<(), Error> {
         if self.current_batch.is_some()
+            && self.can_seal_current_batch()
             && (!submit_only_full_batches
                 || !self.config.is_within_block_limit(
                     u16::try_from(
@@ -289,7 +331,9 @@ impl BatchBuilder {
             self.finalize_current_batch();
         }
 
-        if let Some((forced_inclusion, batch)) = self.batches_to_send.front() {
+        self.repack_oldest_batch_over_blob_limit();
+
+        if !self.batches_to_send.is_empty() {
             if ethereum_l1
                 .execution_layer
                 .is_transaction_in_progress()
@@ -303,6 +347,11 @@ impl BatchBuilder {
                 return Ok(());
             }
 
+            self.check_oldest_batch_with_pre_seal_hook().await?;
+            let Some((forced_inclusion, batch)) = self.batches_to_send.front() else {
+                return Ok(());
+            };
+
             debug!(
                 anchor_block_id = %batch.anchor_block_id,
                 coinbase = %batch.coinbase,
@@ -312,6 +361,14 @@ impl BatchBuilder {
                 current_batch = %self.current_batch.is_some(),
                 "Submitting batch"
             );
+            if !self.config.batch_metadata.is_empty() {
+                info!(
+                    "Submitting batch with anchor block id {} and {} blocks, metadata: {}",
+                    batch.anchor_block_id,
+                    batch.l2_blocks.len(),
+                    self.config.batch_metadata
+                );
+            }
 
             if let Err(err) = ethereum_l1
                 .execution_layer
@@ -325,7 +382,24 @@ impl BatchBuilder {
                 .await
             {
                 if let Some(transaction_error) = err.downcast_ref::<TransactionError>() {
-                    if !matches!(transaction_error, TransactionError::EstimationTooEarly) {
+                    if matches!(transaction_error, TransactionError::ExceedsBlockGasLimit)
+                        && self.config.split_batches_exceeding_l1_gas_limit
+                        && self.split_oldest_batch("exceeding the L1 block gas limit")
+                    {
+                        return Ok(());
+                    }
+                    if matches!(transaction_error, TransactionError::BatchTooLarge)
+                        && self.config.shrink_batches_on_size_revert
+                        && self.shrink_oldest_batch()
+                    {
+                        return Ok(());
+                    }
+                    // nothing was sent, the batches are submitted on the next attempt
+                    if !matches!(
+                        transaction_error,
+                        TransactionError::EstimationTooEarly
+                            | TransactionError::SenderNotAuthorized
+                    ) {
                         debug!("BatchBuilder: Transaction error, removing all batches");
                         self.batches_to_send.clear();
                     }
@@ -339,6 +413,131 @@ impl BatchBuilder {
         Ok(())
     }
 
+    /// Splits the oldest batch in two, the forced inclusion is kept with the first part.
+    /// Returns false when the batch has a single block and cannot be split.
+    fn split_oldest_batch(&mut self, reason: &str) -> bool {
+        if self
+            .batches_to_send
+            .front()
+            .is_none_or(|(_, batch)| batch.l2_blocks.len() < 2)
+        {
+            return false;
+        }
+        let Some((forced_inclusion, batch)) = self.batches_to_send.pop_front() else {
+            return false;
+        };
+        let blocks = batch.l2_blocks.len();
+        let Some((first, second)) = batch.split_in_half() else {
+            return false;
+        };
+        warn!(
+            "Splitting batch with {} blocks {} into batches with {} and {} blocks",
+            blocks,
+            reason,
+            first.l2_blocks.len(),
+            second.l2_blocks.len()
+        );
+        self.batches_to_send.push_front((None, second));
+        self.batches_to_send.push_front((forced_inclusion, first));
+        true
+    }
+
+    /// Splits the oldest batch reverted on the on-chain batch size limit and keeps the next
+    /// batches below its size, so the submission does not loop at the same size.
+    /// Returns false when the batch has a single block and cannot be split.
+    fn shrink_oldest_batch(&mut self) -> bool {
+        let Some(blocks) = self
+            .batches_to_send
+            .front()
+            .and_then(|(_, batch)| u16::try_from(batch.l2_blocks.len()).ok())
+        else {
+            return false;
+        };
+        if !self.split_oldest_batch("over the on-chain batch size limit") {
+            return false;
+        }
+        let limit = blocks - 1;
+        if limit < self.max_blocks_per_batch_limit {
+            warn!(
+                "Lowering the max blocks per batch from {} to {} after a batch size revert",
+                self.max_blocks_per_batch_limit, limit
+            );
+            self.max_blocks_per_batch_limit = limit;
+            self.config.max_blocks_per_batch = self.config.max_blocks_per_batch.min(limit);
+        }
+        true
+    }
+
+    /// Moves the blocks of the oldest batch which do not fit into the blobs of a batch
+    /// to a new batch right after it, the forced inclusion is kept with the first part.
+    /// Blocks added without the bytes limit check, e.g. on reanchoring, can exceed them.
+    /// Returns false when the batch was not repacked.
+    fn repack_oldest_batch_over_blob_limit(&mut self) -> bool {
+        let max_blobs = self.config.max_blobs_per_batch();
+        let Some((_, batch)) = self.batches_to_send.front_mut() else {
+            return false;
+        };
+        // the sum of compressed block sizes is not below the compressed batch size,
+        // the whole batch is compressed only when it can be over the limit
+        if self.config.is_within_bytes_limit(batch.total_bytes) {
+            return false;
+        }
+
+        let blocks = batch.l2_blocks.len();
+        match batch.split_off_over_blobs(max_blobs) {
+            Ok(Some(rest)) => {
+                warn!(
+                    "Repacking batch with {} blocks over the limit of {} blobs into batches with {} and {} blocks",
+                    blocks,
+                    max_blobs,
+                    batch.l2_blocks.len(),
+                    rest.l2_blocks.len()
+                );
+                self.batches_to_send.insert(1, (None, rest));
+                true
+            }
+            Ok(None) => false,
+            Err(err) => {
+                warn!("Failed to repack batch over the blob limit: {}", err);
+                false
+            }
+        }
+    }
+
+    /// The batches waiting for submission are built on top of each other, so a rejected batch
+    /// drops all of them and its blocks have to be reanchored.
+    async fn check_oldest_batch_with_pre_seal_hook(&mut self) -> Result<(), Error> {
+        let Some((_, batch)) = self.batches_to_send.front() else {
+            return Ok(());
+        };
+        if self.is_accepted_by_pre_seal_hook(batch).await {
+            return Ok(());
+        }
+        warn!(
+            "Dropping {} batches waiting for submission after the pre-seal hook rejection",
+            self.batches_to_send.len()
+        );
+        self.batches_to_send.clear();
+        Err(anyhow::anyhow!(TransactionError::RejectedByPreSealHook))
+    }
+
+    async fn is_accepted_by_pre_seal_hook(&self, batch: &Batch) -> bool {
+        let Some(pre_seal_hook) = &self.pre_seal_hook else {
+            return true;
+        };
+        if let Err(err) = pre_seal_hook.check(batch).await {
+            error!(
+                "⛔ Batch with anchor block id {} and {} blocks rejected by pre-seal hook: {}",
+                batch.anchor_block_id,
+                batch.l2_blocks.len(),
+                err
+            );
+            self.metrics.inc_batches_rejected_by_pre_seal_hook();
+            return false;
+        }
+        true
+    }
+
     pub fn is_time_shift_expired(&self, current_l2_slot_timestamp: u64) -> bool {
         if let Some(current_batch) = self.current_batch.as_ref() {
             if let Some(last_block) = current_batch.l2_blocks.last() {
@@ -460,6 +659,9 @@ impl BatchBuilder {
             current_forced_inclusion: None,
             slot_clock: self.slot_clock.clone(),
             metrics: self.metrics.clone(),
+            pre_seal_hook: self.pre_seal_hook.clone(),
+            anchor_latency_alert: self.anchor_latency_alert.clone(),
+            max_blocks_per_batch_limit: self.max_blocks_per_batch_limit,
         }
     }
 
@@ -484,23 +686,15 @@ impl BatchBuilder {
 #[cfg(test)]
 mod tests {
     use super::*;
-    use crate::shared;
+    use crate::{shared, utils::blob::constants::MAX_BLOB_DATA_SIZE};
 
     #[test]
     fn test_is_the_last_l1_slot_to_add_an_empty_l2_block() {
         let batch_builder = BatchBuilder::new(
-            BatchBuilderConfig {
-                max_bytes_size_of_batch: 1000,
-                max_blocks_per_batch: 10,
-                l1_slot_duration_sec: 12,
-                max_time_shift_between_blocks_sec: 255,
-                max_anchor_height_offset: 10,
-                default_coinbase: Address::ZERO,
-                preconf_min_txs: 5,
-                preconf_max_skipped_l2_slots: 3,
-            },
+            test_config(),
             Arc::new(SlotClock::new(0, 5, 12, 32, 3000)),
             Arc::new(Metrics::new()),
+            None,
         );
 
         assert!(!batch_builder.is_the_last_l1_slot_to_add_an_empty_l2_block(100, 0));
@@ -570,13 +764,7 @@ mod tests {
     fn test_can_consume_l2_block(max_bytes_size_of_batch: u64) -> (bool, u64) {
         let config = BatchBuilderConfig {
             max_bytes_size_of_batch,
-            max_blocks_per_batch: 10,
-            l1_slot_duration_sec: 12,
-            max_time_shift_between_blocks_sec: 255,
-            max_anchor_height_offset: 10,
-            default_coinbase: Address::ZERO,
-            preconf_min_txs: 5,
-            preconf_max_skipped_l2_slots: 3,
+            ..test_config()
         };
 
         let mut batch = Batch {
@@ -599,14 +787,13 @@ mod tests {
         };
         batch.l2_blocks.push(l2_block);
 
-        let mut batch_builder = BatchBuilder {
+        let mut batch_builder = BatchBuilder::new(
             config,
-            current_batch: Some(batch),
-            batches_to_send: VecDeque::new(),
-            current_forced_inclusion: None,
-            slot_clock: Arc::new(SlotClock::new(0, 5, 12, 32, 3000)),
-            metrics: Arc::new(Metrics::new()),
-        };
+            Arc::new(SlotClock::new(0, 5, 12, 32, 3000)),
+            Arc::new(Metrics::new()),
+            None,
+        );
+        batch_builder.current_batch = Some(batch);
 
         let tx2 = build_tx_2();
 
@@ -654,20 +841,58 @@ mod tests {
     }
 
     #[test]
-    fn test_should_new_block_be_created() {
+    fn test_sealing_pauses_when_sealed_batches_queue_is_full() {
         let config = BatchBuilderConfig {
-            max_bytes_size_of_batch: 1000,
-            max_blocks_per_batch: 10,
-            l1_slot_duration_sec: 12,
-            max_time_shift_between_blocks_sec: 255,
-            max_anchor_height_offset: 10,
-            default_coinbase: Address::ZERO,
-            preconf_min_txs: 5,
-            preconf_max_skipped_l2_slots: 3,
+            max_sealed_batches: 2,
+            ..test_config()
         };
+        let mut batch_builder = build_batch_builder(config, None);
 
-        let slot_clock = Arc::new(SlotClock::new(0, 5, 12, 32, 2000));
-        let mut batch_builder = BatchBuilder::new(config, slot_clock, Arc::new(Metrics::new()));
+        // nothing to seal
+        assert!(batch_builder.can_seal_current_batch());
+
+        for anchor_block_id in 0..3 {
+            assert!(batch_builder.can_seal_current_batch());
+            batch_builder.create_new_batch_and_add_l2_block(
+                anchor_block_id,
+                0,
+                L2Block::new_empty(1000),
+                None,
+            );
+        }
+        assert_eq!(batch_builder.get_number_of_batches_ready_to_send(), 2);
+        assert!(!batch_builder.can_seal_current_batch());
+
+        // submission of the oldest batch frees space in the queue
+        batch_builder.batches_to_send.pop_front();
+        assert!(batch_builder.can_seal_current_batch());
+        batch_builder.finalize_current_batch();
+        assert_eq!(batch_builder.get_number_of_batches_ready_to_send(), 2);
+        assert!(batch_builder.current_batch.is_none());
+
+        // an empty current batch does not need to be sealed
+        batch_builder.create_new_batch(3, 0);
+        assert!(batch_builder.can_seal_current_batch());
+    }
+
+    #[test]
+    fn test_unlimited_sealed_batches_queue() {
+        let mut batch_builder = build_batch_builder(test_config(), None);
+
+        for anchor_block_id in 0..10 {
+            batch_builder.create_new_batch_and_add_l2_block(
+                anchor_block_id,
+                0,
+                L2Block::new_empty(1000),
+                None,
+            );
+            assert!(batch_builder.can_seal_current_batch());
+        }
+    }
+
+    #[test]
+    fn test_should_new_block_be_created() {
+        let mut batch_builder = build_batch_builder(test_config(), None);
 
         // Test case 1: Should create new block when pending transactions >= preconf_min_txs
         assert!(batch_builder.should_new_block_be_created(5, 1000, false));
@@ -721,4 +946,305 @@ mod tests {
         // Test case 9: Should create new block when is_empty_block_required is true and end_of_sequencing is true
         assert!(batch_builder.should_new_block_be_created(0, 1260, true));
     }
+
+    struct TestPreSealHook {
+        reject_reason: Option<&'static str>,
+    }
+
+    #[async_trait::async_trait]
+    impl PreSealHook for TestPreSealHook {
+        async fn check(&self, _batch: &Batch) -> Result<(), Error> {
+            match self.reject_reason {
+                Some(reason) => Err(anyhow::anyhow!(reason)),
+                None => Ok(()),
+            }
+        }
+    }
+
+    fn test_config() -> BatchBuilderConfig {
+        BatchBuilderConfig {
+            max_bytes_size_of_batch: 1000,
+            max_blocks_per_batch: 10,
+            l1_slot_duration_sec: 12,
+            max_time_shift_between_blocks_sec: 255,
+            max_anchor_height_offset: 10,
+            default_coinbase: Address::ZERO,
+            preconf_min_txs: 5,
+            preconf_max_skipped_l2_slots: 3,
+            max_sealed_batches: 0,
+            split_batches_exceeding_l1_gas_limit: true,
+            shrink_batches_on_size_revert: true,
+            preconf_to_anchor_latency_alert_sec: 0,
+            batch_metadata: Default::default(),
+        }
+    }
+
+    fn build_batch_builder(
+        config: BatchBuilderConfig,
+        pre_seal_hook: Option<Arc<dyn PreSealHook>>,
+    ) -> BatchBuilder {
+        let slot_clock = Arc::new(SlotClock::new(0, 5, 12, 32, 2000));
+        BatchBuilder::new(config, slot_clock, Arc::new(Metrics::new()), pre_seal_hook)
+    }
+
+    fn build_batch_builder_with_pre_seal_hook(reject_reason: Option<&'static str>) -> BatchBuilder {
+        build_batch_builder(
+            test_config(),
+            Some(Arc::new(TestPreSealHook { reject_reason })),
+        )
+    }
+
+    #[tokio::test]
+    async fn test_pre_seal_hook_accepts_batch() {
+        let mut batch_builder = build_batch_builder_with_pre_seal_hook(None);
+        batch_builder.create_new_batch_and_add_l2_block(0, 0, L2Block::new_empty(1000), None);
+        batch_builder.finalize_current_batch();
+
+        let (_, batch) = batch_builder.batches_to_send.front().unwrap();
+        assert!(batch_builder.is_accepted_by_pre_seal_hook(batch).await);
+    }
+
+    #[tokio::test]
+    async fn test_pre_seal_hook_rejects_batch() {
+        let mut batch_builder = build_batch_builder_with_pre_seal_hook(Some("compliance"));
+        batch_builder.create_new_batch_and_add_l2_block(0, 0, L2Block::new_empty(1000), None);
+        batch_builder.create_new_batch_and_add_l2_block(1, 12, L2Block::new_empty(1012), None);
+        batch_builder.finalize_current_batch();
+        assert_eq!(batch_builder.get_number_of_batches_ready_to_send(), 2);
+
+        let err = batch_builder
+            .check_oldest_batch_with_pre_seal_hook()
+            .await
+            .unwrap_err();
+        assert!(matches!(
+            err.downcast_ref::<TransactionError>(),
+            Some(TransactionError::RejectedByPreSealHook)
+        ));
+        assert!(
+            batch_builder
+                .metrics
+                .gather()
+                .contains("batches_rejected_by_pre_seal_hook 1")
+        );
+        // the rejected batch and the batch built on top of it are dropped,
+        // so the hook is not called again for them
+        assert_eq!(batch_builder.get_number_of_batches_ready_to_send(), 0);
+        assert!(
+            batch_builder
+                .check_oldest_batch_with_pre_seal_hook()
+                .await
+                .is_ok()
+        );
+        assert!(
+            batch_builder
+                .metrics
+                .gather()
+                .contains("batches_rejected_by_pre_seal_hook 1")
+        );
+        // the hook is kept when the builder is cloned
+        assert!(
+            batch_builder
+                .clone_without_batches()
+                .pre_seal_hook
+                .is_some()
+        );
+    }
+
+    #[test]
+    fn test_split_oldest_batch_exceeding_l1_gas_limit() {
+        let mut batch_builder = build_batch_builder(test_config(), None);
+        // oversized batch with 5 blocks followed by a fresh batch
+        batch_builder.create_new_batch_and_add_l2_block(0, 0, L2Block::new_empty(1000), None);
+        for timestamp in 1001..1005 {
+            batch_builder
+                .add_l2_block_and_get_current_anchor_block_id(L2Block::new_empty(timestamp))
+                .unwrap();
+        }
+        batch_builder.create_new_batch_and_add_l2_block(1, 0, L2Block::new_empty(1005), None);
+        batch_builder.finalize_current_batch();
+
+        // assume that only batches with up to 2 blocks fit into the L1 block gas limit
+        let mut submitted = Vec::new();
+        while let Some((_, batch)) = batch_builder.batches_to_send.front() {
+            if batch.l2_blocks.len() > 2 {
+                assert!(batch_builder.split_oldest_batch("exceeding the L1 block gas limit"));
+            } else {
+                submitted.push(batch_builder.batches_to_send.pop_front().unwrap().1);
+            }
+        }
+
+        let submitted = submitted
+            .iter()
+            .map(|batch| {
+                (
+                    batch.anchor_block_id,
+                    batch
+                        .l2_blocks
+                        .iter()
+                        .map(|block| block.timestamp_sec)
+                        .collect::<Vec<_>>(),
+                )
+            })
+            .collect::<Vec<_>>();
+        assert_eq!(
+            submitted,
+            vec![
+                (0, vec![1000, 1001]),
+                (0, vec![1002]),
+                (0, vec![1003, 1004]),
+                (1, vec![1005]),
+            ]
+        );
+
+        // a single block batch cannot be split
+        batch_builder.create_new_batch_and_add_l2_block(2, 0, L2Block::new_empty(1006), None);
+        batch_builder.finalize_current_batch();
+        assert!(!batch_builder.split_oldest_batch("exceeding the L1 block gas limit"));
+        assert_eq!(batch_builder.get_number_of_batches_ready_to_send(), 1);
+    }
+
+    #[test]
+    fn test_shrink_batches_after_size_revert() {
+        let mut batch_builder = build_batch_builder(test_config(), None);
+        // oversized batch with 10 blocks
+        batch_builder.create_new_batch_and_add_l2_block(0, 0, L2Block::new_empty(1000), None);
+        for timestamp in 1001..1010 {
+            batch_builder
+                .add_l2_block_and_get_current_anchor_block_id(L2Block::new_empty(timestamp))
+                .unwrap();
+        }
+        batch_builder.finalize_current_batch();
+
+        // the inbox reverts with TooManyBlocks on batches with more than 3 blocks
+        let mut submitted = Vec::new();
+        while let Some((_, batch)) = batch_builder.batches_to_send.front() {
+            if batch.l2_blocks.len() > 3 {
+                assert!(batch_builder.shrink_oldest_batch());
+            } else {
+                submitted.push(batch_builder.batches_to_send.pop_front().unwrap().1);
+            }
+        }
+        let submitted = submitted
+            .iter()
+            .map(|batch| batch.l2_blocks.len())
+            .collect::<Vec<_>>();
+        assert_eq!(submitted, vec![2, 3, 2, 3]);
+
+        // the next batches are smaller than the reverted ones, also after a batch size adjustment
+        assert_eq!(batch_builder.get_config().max_blocks_per_batch, 4);
+        batch_builder.set_max_blocks_per_batch(10);
+        assert_eq!(batch_builder.get_config().max_blocks_per_batch, 4);
+        batch_builder.set_max_blocks_per_batch(2);
+        assert_eq!(batch_builder.get_config().max_blocks_per_batch, 2);
+        assert_eq!(
+            batch_builder
+                .clone_without_batches()
+                .max_blocks_per_batch_limit,
+            4
+        );
+
+        // a single block batch cannot be split
+        batch_builder.create_new_batch_and_add_l2_block(1, 0, L2Block::new_empty(1010), None);
+        batch_builder.finalize_current_batch();
+        assert!(!batch_builder.shrink_oldest_batch());
+    }
+
+    // block with a single tx with a random calldata, so it does not compress
+    fn build_incompressible_block(timestamp_sec: u64, data_size: usize) -> L2Block {
+        use alloy::{
+            consensus::{SignableTransaction, TxEnvelope, TxLegacy, transaction::Recovered},
+            primitives::{Bytes, Signature, TxKind, U256, keccak256},
+        };
+
+        let mut input = Vec::with_capacity(data_size + 32);
+        let mut hash = keccak256(timestamp_sec.to_be_bytes());
+        while input.len() < data_size {
+            input.extend_from_slice(hash.as_slice());
+            hash = keccak256(hash);
+        }
+        input.truncate(data_size);
+        let tx = TxLegacy {
+            chain_id: Some(167000),
+            nonce: timestamp_sec,
+            gas_price: 1,
+            gas_limit: 30_000_000,
+            to: TxKind::Call(Address::ZERO),
+            value: U256::ZERO,
+            input: Bytes::from(input),
+        };
+        let tx = alloy::rpc::types::Transaction {
+            inner: Recovered::new_unchecked(
+                TxEnvelope::Legacy(tx.into_signed(Signature::test_signature())),
+                Address::ZERO,
+            ),
+            block_hash: None,
+            block_number: None,
+            transaction_index: None,
+            effective_gas_price: None,
+        };
+        let tx_list = vec![tx];
+        let bytes_length = shared::l2_tx_lists::encode_and_compress(&tx_list)
+            .unwrap()
+            .len() as u64;
+        L2Block {
+            prebuilt_tx_list: PreBuiltTxList {
+                tx_list,
+                estimated_gas_used: 0,
+                bytes_length,
+            },
+            timestamp_sec,
+        }
+    }
+
+    #[test]
+    fn test_repack_batch_over_blob_limit() {
+        let config = BatchBuilderConfig {
+            max_bytes_size_of_batch: 6 * MAX_BLOB_DATA_SIZE as u64,
+            ..test_config()
+        };
+        let mut batch_builder = build_batch_builder(config, None);
+        // 8 blocks of 120KB need 8 blobs, over the limit of 6 blobs per batch
+        batch_builder.create_new_batch_and_add_l2_block(
+            0,
+            0,
+            build_incompressible_block(1000, 120_000),
+            None,
+        );
+        for timestamp in 1001..1008 {
+            batch_builder
+                .add_l2_block_and_get_current_anchor_block_id(build_incompressible_block(
+                    timestamp, 120_000,
+                ))
+                .unwrap();
+        }
+        batch_builder.create_new_batch_and_add_l2_block(1, 0, L2Block::new_empty(1008), None);
+        batch_builder.finalize_current_batch();
+        assert_eq!(
+            batch_builder.batches_to_send[0].1.blobs_needed().unwrap(),
+            8
+        );
+
+        assert!(batch_builder.repack_oldest_batch_over_blob_limit());
+        let timestamps = |batch: &Batch| {
+            batch
+                .l2_blocks
+                .iter()
+                .map(|block| block.timestamp_sec)
+                .collect::<Vec<_>>()
+        };
+        let (_, first) = batch_builder.batches_to_send.pop_front().unwrap();
+        assert_eq!(timestamps(&first), (1000..1006).collect::<Vec<_>>());
+        assert_eq!(first.blobs_needed().unwrap(), 6);
+        assert!(first.total_bytes <= 6 * MAX_BLOB_DATA_SIZE as u64);
+
+        // the rest is submitted next, before the following batch
+        assert!(!batch_builder.repack_oldest_batch_over_blob_limit());
+        let (forced_inclusion, second) = batch_builder.batches_to_send.pop_front().unwrap();
+        assert!(forced_inclusion.is_none());
+        assert_eq!(timestamps(&second), vec![1006, 1007]);
+        assert_eq!(second.blobs_needed().unwrap(), 2);
+        assert_eq!(second.anchor_block_id, 0);
+        let (_, third) = batch_builder.batches_to_send.pop_front().unwrap();
+        assert_eq!(timestamps(&third), vec![1008]);
+    }
 }
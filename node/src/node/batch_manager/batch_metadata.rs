@@ -0,0 +1,76 @@
+use std::{fmt, str::FromStr};
+
+/// Operator defined key-value pairs, e.g. the build version or a config hash, logged
+/// with every batch submission for debugging across deploys. The node has no batch status
+/// store, so the submission log is the only record, they are not posted on L1 either.
+#[derive(Clone, Debug, Default, PartialEq)]
+pub struct BatchMetadata(Vec<(String, String)>);
+
+impl BatchMetadata {
+    pub fn is_empty(&self) -> bool {
+        self.0.is_empty()
+    }
+}
+
+impl FromStr for BatchMetadata {
+    type Err = anyhow::Error;
+
+    /// Parses comma separated `key=value` pairs
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let mut pairs: Vec<(String, String)> = Vec::new();
+        for pair in s.split(',').map(str::trim).filter(|pair| !pair.is_empty()) {
+            let (key, value) = pair.split_once('=').ok_or_else(|| {
+                anyhow::anyhow!("Batch metadata {} is not a key=value pair", pair)
+            })?;
+            let key = key.trim();
+            if key.is_empty() {
+                return Err(anyhow::anyhow!("Batch metadata {} has an empty key", pair));
+            }
+            if pairs.iter().any(|(k, _)| k == key) {
+                return Err(anyhow::anyhow!("Duplicate batch metadata key {}", key));
+            }
+            pairs.push((key.to_string(), value.trim().to_string()));
+        }
+        Ok(Self(pairs))
+    }
+}
+
+impl fmt::Display for BatchMetadata {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let pairs = self
+            .0
+            .iter()
+            .map(|(key, value)| format!("{key}={value}"))
+            .collect::<Vec<_>>();
+        write!(f, "{}", pairs.join(","))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_batch_metadata_from_str() {
+        let metadata =
+            BatchMetadata::from_str("version=v1.2.0, config_hash=0xabcd ,region=eu").unwrap();
+        assert_eq!(
+            metadata.to_string(),
+            "version=v1.2.0,config_hash=0xabcd,region=eu"
+        );
+
+        assert!(BatchMetadata::from_str("").unwrap().is_empty());
+        assert!(BatchMetadata::from_str(" , ").unwrap().is_empty());
+        assert_eq!(
+            BatchMetadata::from_str("empty=").unwrap().to_string(),
+            "empty="
+        );
+    }
+
+    #[test]
+    fn test_invalid_batch_metadata() {
+        assert!(BatchMetadata::from_str("version").is_err());
+        assert!(BatchMetadata::from_str("=v1.2.0").is_err());
+        assert!(BatchMetadata::from_str("version=v1,version=v2").is_err());
+    }
+}
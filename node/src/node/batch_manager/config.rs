@@ -1,5 +1,7 @@
-use super::batch::Batch;
-use crate::ethereum_l1::l1_contracts_bindings::BatchParams;
+use super::{batch::Batch, batch_metadata::BatchMetadata};
+use crate::{
+    ethereum_l1::l1_contracts_bindings::BatchParams, utils::blob::constants::MAX_BLOB_DATA_SIZE,
+};
 use alloy::primitives::Address;
 use std::collections::VecDeque;
 
@@ -25,6 +27,16 @@ pub struct BatchBuilderConfig {
     pub preconf_min_txs: u64,
     /// Maximum number of skipped slots in a preconfirmed block
     pub preconf_max_skipped_l2_slots: u64,
+    /// Maximum number of sealed batches waiting for submission, 0 means unlimited
+    pub max_sealed_batches: u64,
+    /// Split a batch whose proposal transaction would exceed the L1 block gas limit
+    pub split_batches_exceeding_l1_gas_limit: bool,
+    /// Split a batch reverting on the on-chain batch size limit and keep the next ones smaller
+    pub shrink_batches_on_size_revert: bool,
+    /// Preconf-to-anchor latency in seconds above which an alert is raised, 0 disables the alert
+    pub preconf_to_anchor_latency_alert_sec: u64,
+    /// Key-value pairs recorded with every batch submission
+    pub batch_metadata: BatchMetadata,
 }
 
 impl BatchBuilderConfig {
@@ -32,7 +44,16 @@ impl BatchBuilderConfig {
         num_blocks <= self.max_blocks_per_batch
     }
 
+    pub fn is_within_sealed_batches_limit(&self, num_sealed_batches: u64) -> bool {
+        self.max_sealed_batches == 0 || num_sealed_batches < self.max_sealed_batches
+    }
+
     pub fn is_within_bytes_limit(&self, total_bytes: u64) -> bool {
         total_bytes <= self.max_bytes_size_of_batch
     }
+
+    /// Number of blobs of a batch, the bytes limit is BLOBS_PER_BATCH blobs.
+    pub fn max_blobs_per_batch(&self) -> u64 {
+        self.max_bytes_size_of_batch / MAX_BLOB_DATA_SIZE as u64
+    }
 }
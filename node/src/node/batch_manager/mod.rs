@@ -1,9 +1,12 @@
+mod anchor_latency_alert;
 pub mod batch;
 mod batch_builder;
+pub mod batch_metadata;
 pub mod config;
+pub mod pre_seal_hook;
 
 use crate::{
-    ethereum_l1::EthereumL1,
+    ethereum_l1::{EthereumL1, config::L1BlockTag},
     forced_inclusion::ForcedInclusion,
     metrics::Metrics,
     node::batch_manager::config::BatchesToSend,
@@ -17,6 +20,7 @@ use alloy::{consensus::BlockHeader, consensus::Transaction, primitives::Address}
 use anyhow::Error;
 use batch_builder::BatchBuilder;
 use config::BatchBuilderConfig;
+use pre_seal_hook::PreSealHook;
 use std::sync::Arc;
 use tracing::{debug, error, info, warn};
 
@@ -33,6 +37,7 @@ pub struct BatchManager {
     ethereum_l1: Arc<EthereumL1>,
     pub taiko: Arc<Taiko>,
     l1_height_lag: u64,
+    l1_anchor_block_tag: L1BlockTag,
     forced_inclusion: Arc<ForcedInclusion>,
     cached_forced_inclusion_txs: CachedForcedInclusion,
     metrics: Arc<Metrics>,
@@ -41,10 +46,12 @@ pub struct BatchManager {
 impl BatchManager {
     pub fn new(
         l1_height_lag: u64,
+        l1_anchor_block_tag: L1BlockTag,
         config: BatchBuilderConfig,
         ethereum_l1: Arc<EthereumL1>,
         taiko: Arc<Taiko>,
         metrics: Arc<Metrics>,
+        pre_seal_hook: Option<Arc<dyn PreSealHook>>,
     ) -> Self {
         info!(
             "Batch builder config:\n\
@@ -52,12 +59,22 @@ impl BatchManager {
              max_blocks_per_batch: {}\n\
              l1_slot_duration_sec: {}\n\
              max_time_shift_between_blocks_sec: {}\n\
-             max_anchor_height_offset: {}",
+             max_anchor_height_offset: {}\n\
+             max_sealed_batches: {}\n\
+             split_batches_exceeding_l1_gas_limit: {}\n\
+             shrink_batches_on_size_revert: {}\n\
+             preconf_to_anchor_latency_alert_sec: {}\n\
+             batch_metadata: {}",
             config.max_bytes_size_of_batch,
             config.max_blocks_per_batch,
             config.l1_slot_duration_sec,
             config.max_time_shift_between_blocks_sec,
             config.max_anchor_height_offset,
+            config.max_sealed_batches,
+            config.split_batches_exceeding_l1_gas_limit,
+            config.shrink_batches_on_size_revert,
+            config.preconf_to_anchor_latency_alert_sec,
+            config.batch_metadata,
         );
         let forced_inclusion = Arc::new(ForcedInclusion::new(ethereum_l1.clone()));
         Self {
@@ -65,10 +82,12 @@ impl BatchManager {
                 config,
                 ethereum_l1.slot_clock.clone(),
                 metrics.clone(),
+                pre_seal_hook,
             ),
             ethereum_l1,
             taiko,
             l1_height_lag,
+            l1_anchor_block_tag,
             forced_inclusion,
             cached_forced_inclusion_txs: CachedForcedInclusion::Empty,
             metrics,
@@ -696,6 +715,15 @@ impl BatchManager {
                 .add_only_l2_block(l2_block, l2_slot_info, end_of_sequencing, operation_type)
                 .await?;
             Ok((None, preconfed_block))
+        } else if !self.batch_builder.can_seal_current_batch() {
+            // Backpressure: a new batch would seal the current one while the queue of
+            // sealed batches is full, wait for submission to drain the queue
+            warn!(
+                "⏸️ Sealed batches queue is full ({} batches), skipping L2 block until batches are submitted",
+                self.batch_builder.get_number_of_batches_ready_to_send()
+            );
+            self.metrics.inc_skipped_l2_slots_by_full_batch_queue();
+            Ok((None, None))
         } else {
             self.add_new_l2_block_with_optional_forced_inclusion(
                 l2_block,
@@ -741,8 +769,13 @@ impl BatchManager {
             .taiko
             .get_last_synced_anchor_block_id_from_taiko_anchor()
             .await?;
-        let l1_height = self.ethereum_l1.execution_layer.get_l1_height().await?;
-        let l1_height_with_lag = l1_height - self.l1_height_lag;
+        let l1_height = self
+            .ethereum_l1
+            .execution_layer
+            .get_l1_height_by_tag(self.l1_anchor_block_tag)
+            .await?;
+        let l1_height_with_lag =
+            apply_l1_height_lag(self.l1_anchor_block_tag, l1_height, self.l1_height_lag);
         let anchor_id_from_last_l2_block =
             match self.taiko.get_last_synced_anchor_block_id_from_geth().await {
                 Ok(height) => height,
@@ -770,6 +803,11 @@ impl BatchManager {
             .await
     }
 
+    pub fn set_max_blocks_per_batch(&mut self, max_blocks_per_batch: u16) {
+        self.batch_builder
+            .set_max_blocks_per_batch(max_blocks_per_batch);
+    }
+
     pub fn has_batches(&self) -> bool {
         !self.batch_builder.is_empty()
     }
@@ -795,6 +833,7 @@ impl BatchManager {
             self.batch_builder.get_config().clone(),
             self.ethereum_l1.slot_clock.clone(),
             self.metrics.clone(),
+            self.batch_builder.get_pre_seal_hook(),
         );
 
         Ok(())
@@ -806,6 +845,7 @@ impl BatchManager {
             ethereum_l1: self.ethereum_l1.clone(),
             taiko: self.taiko.clone(),
             l1_height_lag: self.l1_height_lag,
+            l1_anchor_block_tag: self.l1_anchor_block_tag,
             forced_inclusion: self.forced_inclusion.clone(),
             cached_forced_inclusion_txs: CachedForcedInclusion::Empty,
             metrics: self.metrics.clone(),
@@ -831,3 +871,25 @@ impl BatchManager {
         self.batch_builder.take_batches_to_send()
     }
 }
+
+/// Safe and finalized L1 blocks are not expected to be reorged, so the lag
+/// is only applied when anchoring to the latest L1 block.
+fn apply_l1_height_lag(tag: L1BlockTag, l1_height: u64, l1_height_lag: u64) -> u64 {
+    match tag {
+        L1BlockTag::Latest => l1_height.saturating_sub(l1_height_lag),
+        L1BlockTag::Safe | L1BlockTag::Finalized => l1_height,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_apply_l1_height_lag() {
+        assert_eq!(apply_l1_height_lag(L1BlockTag::Latest, 100, 4), 96);
+        assert_eq!(apply_l1_height_lag(L1BlockTag::Latest, 2, 4), 0);
+        assert_eq!(apply_l1_height_lag(L1BlockTag::Safe, 90, 4), 90);
+        assert_eq!(apply_l1_height_lag(L1BlockTag::Finalized, 70, 4), 70);
+    }
+}
@@ -0,0 +1,130 @@
+use super::batch::Batch;
+use alloy::{primitives::Address, rpc::types::Transaction};
+use anyhow::Error;
+use async_trait::async_trait;
+use serde::Serialize;
+use std::time::Duration;
+
+/// Custom validation of a sealed batch, run before the batch is submitted to L1.
+#[async_trait]
+pub trait PreSealHook: Send + Sync {
+    /// Returns the reason of the rejection when the batch must not be submitted.
+    async fn check(&self, batch: &Batch) -> Result<(), Error>;
+}
+
+#[derive(Serialize)]
+struct PreSealHookBlock<'a> {
+    timestamp_sec: u64,
+    transactions: &'a [Transaction],
+}
+
+#[derive(Serialize)]
+struct PreSealHookRequest<'a> {
+    anchor_block_id: u64,
+    anchor_block_timestamp_sec: u64,
+    coinbase: Address,
+    blocks: Vec<PreSealHookBlock<'a>>,
+}
+
+/// Sends the batch to an external service, the batch is accepted on a success status.
+pub struct HttpPreSealHook {
+    client: reqwest::Client,
+    url: reqwest::Url,
+}
+
+impl HttpPreSealHook {
+    pub fn new(url: &str, timeout: Duration) -> Result<Self, Error> {
+        let client = reqwest::Client::builder().timeout(timeout).build()?;
+        Ok(Self {
+            client,
+            url: reqwest::Url::parse(url)?,
+        })
+    }
+}
+
+#[async_trait]
+impl PreSealHook for HttpPreSealHook {
+    async fn check(&self, batch: &Batch) -> Result<(), Error> {
+        let request = PreSealHookRequest {
+            anchor_block_id: batch.anchor_block_id,
+            anchor_block_timestamp_sec: batch.anchor_block_timestamp_sec,
+            coinbase: batch.coinbase,
+            blocks: batch
+                .l2_blocks
+                .iter()
+                .map(|block| PreSealHookBlock {
+                    timestamp_sec: block.timestamp_sec,
+                    transactions: &block.prebuilt_tx_list.tx_list,
+                })
+                .collect(),
+        };
+
+        let response = self
+            .client
+            .post(self.url.clone())
+            .header("content-type", "application/json")
+            .body(serde_json::to_vec(&request)?)
+            .send()
+            .await
+            .map_err(|e| anyhow::anyhow!("Pre-seal hook request failed: {}", e))?;
+
+        let status = response.status();
+        if !status.is_success() {
+            let reason = response.text().await.unwrap_or_default();
+            return Err(anyhow::anyhow!(
+                "Pre-seal hook rejected the batch with status {}: {}",
+                status,
+                reason
+            ));
+        }
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::shared::l2_block::L2Block;
+
+    fn test_batch() -> Batch {
+        Batch {
+            l2_blocks: vec![L2Block::new_empty(1000), L2Block::new_empty(1002)],
+            total_bytes: 0,
+            coinbase: Address::ZERO,
+            anchor_block_id: 10,
+            anchor_block_timestamp_sec: 990,
+        }
+    }
+
+    #[tokio::test]
+    async fn test_http_pre_seal_hook_accepts_batch() {
+        let mut server = mockito::Server::new_async().await;
+        let mock = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::PartialJsonString(
+                r#"{"anchor_block_id": 10, "anchor_block_timestamp_sec": 990}"#.to_string(),
+            ))
+            .with_status(200)
+            .create_async()
+            .await;
+
+        let hook = HttpPreSealHook::new(&server.url(), Duration::from_secs(1)).unwrap();
+        assert!(hook.check(&test_batch()).await.is_ok());
+        mock.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_http_pre_seal_hook_rejects_batch() {
+        let mut server = mockito::Server::new_async().await;
+        server
+            .mock("POST", "/")
+            .with_status(403)
+            .with_body("sanctioned address")
+            .create_async()
+            .await;
+
+        let hook = HttpPreSealHook::new(&server.url(), Duration::from_secs(1)).unwrap();
+        let err = hook.check(&test_batch()).await.unwrap_err();
+        assert!(err.to_string().contains("sanctioned address"));
+    }
+}
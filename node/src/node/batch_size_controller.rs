@@ -0,0 +1,184 @@
+use crate::metrics::Metrics;
+use std::sync::Arc;
+use tokio::time::{Duration, Instant};
+use tracing::info;
+
+// number of adjustments needed to go from the lower to the upper bound
+const ADJUSTMENT_STEPS: u16 = 4;
+
+/// Adjusts the max number of blocks per batch to the L1 congestion.
+/// Congested L1 makes the batches bigger to reduce the number of submissions,
+/// calm L1 makes them smaller to reduce the proposing latency.
+pub struct BatchSizeController {
+    min_blocks_per_batch: u16,
+    max_blocks_per_batch: u16,
+    current_blocks_per_batch: u16,
+    congested_base_fee_wei: u128,
+    congested_inclusion_delay: Duration,
+    proposal_started_at: Option<Instant>,
+    metrics: Arc<Metrics>,
+}
+
+impl BatchSizeController {
+    /// `min_blocks_per_batch` equal to 0 disables the adjustment.
+    pub fn new(
+        min_blocks_per_batch: u16,
+        max_blocks_per_batch: u16,
+        congested_base_fee_wei: u128,
+        congested_inclusion_delay: Duration,
+        metrics: Arc<Metrics>,
+    ) -> Self {
+        metrics.set_max_blocks_per_batch(max_blocks_per_batch);
+        Self {
+            min_blocks_per_batch: min_blocks_per_batch.min(max_blocks_per_batch),
+            max_blocks_per_batch,
+            current_blocks_per_batch: max_blocks_per_batch,
+            congested_base_fee_wei,
+            congested_inclusion_delay,
+            proposal_started_at: None,
+            metrics,
+        }
+    }
+
+    pub fn is_enabled(&self) -> bool {
+        self.min_blocks_per_batch != 0 && self.min_blocks_per_batch < self.max_blocks_per_batch
+    }
+
+    /// Tracks the proposal transaction and returns its inclusion delay once it is finished.
+    pub fn on_transaction_status(&mut self, transaction_in_progress: bool) -> Option<Duration> {
+        self.on_transaction_status_at(transaction_in_progress, Instant::now())
+    }
+
+    fn on_transaction_status_at(
+        &mut self,
+        transaction_in_progress: bool,
+        now: Instant,
+    ) -> Option<Duration> {
+        if transaction_in_progress {
+            self.proposal_started_at.get_or_insert(now);
+            return None;
+        }
+        self.proposal_started_at
+            .take()
+            .map(|started_at| now.duration_since(started_at))
+    }
+
+    /// Returns the new max number of blocks per batch, `base_fee_wei` is None when it is unknown.
+    pub fn adjust(&mut self, base_fee_wei: Option<u128>, inclusion_delay: Duration) -> u16 {
+        let is_congested = inclusion_delay >= self.congested_inclusion_delay
+            || base_fee_wei.is_some_and(|base_fee| base_fee >= self.congested_base_fee_wei);
+        let step =
+            ((self.max_blocks_per_batch - self.min_blocks_per_batch) / ADJUSTMENT_STEPS).max(1);
+
+        let new_blocks_per_batch = if is_congested {
+            self.current_blocks_per_batch
+                .saturating_add(step)
+                .min(self.max_blocks_per_batch)
+        } else {
+            self.current_blocks_per_batch
+                .saturating_sub(step)
+                .max(self.min_blocks_per_batch)
+        };
+
+        if new_blocks_per_batch != self.current_blocks_per_batch {
+            info!(
+                "Adjusting max blocks per batch from {} to {}, L1 congested: {}, base fee: {:?}, inclusion delay: {}s",
+                self.current_blocks_per_batch,
+                new_blocks_per_batch,
+                is_congested,
+                base_fee_wei,
+                inclusion_delay.as_secs()
+            );
+            self.current_blocks_per_batch = new_blocks_per_batch;
+            self.metrics.set_max_blocks_per_batch(new_blocks_per_batch);
+        }
+
+        self.current_blocks_per_batch
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const CONGESTED_BASE_FEE_WEI: u128 = 20_000_000_000;
+    const CONGESTED_INCLUSION_DELAY: Duration = Duration::from_secs(36);
+    const CALM_INCLUSION_DELAY: Duration = Duration::from_secs(12);
+
+    fn controller(min_blocks: u16, max_blocks: u16) -> BatchSizeController {
+        BatchSizeController::new(
+            min_blocks,
+            max_blocks,
+            CONGESTED_BASE_FEE_WEI,
+            CONGESTED_INCLUSION_DELAY,
+            Arc::new(Metrics::new()),
+        )
+    }
+
+    #[test]
+    fn test_batch_size_adapts_within_bounds() {
+        let mut controller = controller(10, 50);
+        assert!(controller.is_enabled());
+
+        // calm L1 shrinks the batches down to the lower bound
+        for expected in [40, 30, 20, 10, 10] {
+            assert_eq!(
+                controller.adjust(Some(1_000_000_000), CALM_INCLUSION_DELAY),
+                expected
+            );
+        }
+
+        // slow inclusion grows the batches
+        assert_eq!(controller.adjust(None, CONGESTED_INCLUSION_DELAY), 20);
+        // high base fee grows the batches up to the upper bound
+        for expected in [30, 40, 50, 50] {
+            assert_eq!(
+                controller.adjust(Some(CONGESTED_BASE_FEE_WEI), CALM_INCLUSION_DELAY),
+                expected
+            );
+        }
+        assert!(
+            controller
+                .metrics
+                .gather()
+                .contains("max_blocks_per_batch 50")
+        );
+    }
+
+    #[test]
+    fn test_small_range_is_adjusted_by_one_block() {
+        let mut controller = controller(2, 4);
+        assert_eq!(controller.adjust(None, CALM_INCLUSION_DELAY), 3);
+        assert_eq!(controller.adjust(None, CALM_INCLUSION_DELAY), 2);
+        assert_eq!(controller.adjust(None, CALM_INCLUSION_DELAY), 2);
+        assert_eq!(controller.adjust(None, CONGESTED_INCLUSION_DELAY), 3);
+    }
+
+    #[test]
+    fn test_disabled() {
+        assert!(!controller(0, 50).is_enabled());
+        assert!(!controller(50, 50).is_enabled());
+        assert!(!controller(60, 50).is_enabled());
+    }
+
+    #[test]
+    fn test_inclusion_delay_is_measured() {
+        let mut controller = controller(10, 50);
+        let start = Instant::now();
+
+        assert_eq!(controller.on_transaction_status_at(false, start), None);
+        assert_eq!(controller.on_transaction_status_at(true, start), None);
+        assert_eq!(
+            controller.on_transaction_status_at(true, start + Duration::from_secs(12)),
+            None
+        );
+        assert_eq!(
+            controller.on_transaction_status_at(false, start + Duration::from_secs(24)),
+            Some(Duration::from_secs(24))
+        );
+        assert_eq!(
+            controller.on_transaction_status_at(false, start + Duration::from_secs(36)),
+            None
+        );
+    }
+}
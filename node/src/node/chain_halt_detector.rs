@@ -0,0 +1,207 @@
+use crate::{metrics::Metrics, utils::types::Slot};
+use std::{fmt, sync::Arc};
+use tokio::time::{Duration, Instant};
+use tracing::{error, info};
+
+#[derive(Copy, Clone, Debug, PartialEq)]
+pub enum Chain {
+    L1,
+    L2,
+}
+
+impl fmt::Display for Chain {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let s = match self {
+            Chain::L1 => "L1",
+            Chain::L2 => "L2",
+        };
+        write!(f, "{s}")
+    }
+}
+
+struct ChainHead {
+    chain: Chain,
+    // zero disables the detection
+    timeout: Duration,
+    height: Option<u64>,
+    last_advance: Instant,
+    halted: bool,
+}
+
+impl ChainHead {
+    fn new(chain: Chain, timeout: Duration, now: Instant) -> Self {
+        Self {
+            chain,
+            timeout,
+            height: None,
+            last_advance: now,
+            halted: false,
+        }
+    }
+
+    fn update(&mut self, height: u64, now: Instant, metrics: &Metrics) {
+        if self.timeout.is_zero() {
+            return;
+        }
+
+        if self.height.is_none_or(|last_height| height > last_height) {
+            self.height = Some(height);
+            self.last_advance = now;
+            if self.halted {
+                info!("✅ {} chain resumed at block {}", self.chain, height);
+                self.halted = false;
+                metrics.set_chain_halted(&self.chain.to_string(), false);
+            }
+            return;
+        }
+
+        let stalled_for = now.duration_since(self.last_advance);
+        if !self.halted && stalled_for >= self.timeout {
+            error!(
+                "⛔ {} chain halted: no new block for {}s, last block {}",
+                self.chain,
+                stalled_for.as_secs(),
+                height
+            );
+            self.halted = true;
+            metrics.set_chain_halted(&self.chain.to_string(), true);
+        }
+    }
+}
+
+/// Detects L1 and L2 chains that stop producing blocks for longer than the configured timeout.
+pub struct ChainHaltDetector {
+    l1: ChainHead,
+    l2: ChainHead,
+    l1_sampled_slot: Option<Slot>,
+    metrics: Arc<Metrics>,
+}
+
+impl ChainHaltDetector {
+    pub fn new(l1_timeout: Duration, l2_timeout: Duration, metrics: Arc<Metrics>) -> Self {
+        let now = Instant::now();
+        Self {
+            l1: ChainHead::new(Chain::L1, l1_timeout, now),
+            l2: ChainHead::new(Chain::L2, l2_timeout, now),
+            l1_sampled_slot: None,
+            metrics,
+        }
+    }
+
+    /// The L1 head can only advance once per L1 slot, so it is sampled once per slot
+    /// instead of on every heartbeat.
+    pub fn should_sample_l1_height(&mut self, l1_slot: Slot) -> bool {
+        if self.l1.timeout.is_zero() || self.l1_sampled_slot == Some(l1_slot) {
+            return false;
+        }
+        self.l1_sampled_slot = Some(l1_slot);
+        true
+    }
+
+    pub fn update(&mut self, l1_height: Option<u64>, l2_height: u64) {
+        self.update_at(l1_height, l2_height, Instant::now());
+    }
+
+    fn update_at(&mut self, l1_height: Option<u64>, l2_height: u64, now: Instant) {
+        if let Some(l1_height) = l1_height {
+            self.l1.update(l1_height, now, &self.metrics);
+        }
+        self.l2.update(l2_height, now, &self.metrics);
+    }
+
+    pub fn is_halted(&self, chain: Chain) -> bool {
+        match chain {
+            Chain::L1 => self.l1.halted,
+            Chain::L2 => self.l2.halted,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn detector(l1_timeout_sec: u64, l2_timeout_sec: u64) -> (ChainHaltDetector, Instant) {
+        let detector = ChainHaltDetector::new(
+            Duration::from_secs(l1_timeout_sec),
+            Duration::from_secs(l2_timeout_sec),
+            Arc::new(Metrics::new()),
+        );
+        let start = detector.l1.last_advance;
+        (detector, start)
+    }
+
+    #[test]
+    fn test_halt_detected_and_cleared() {
+        let (mut detector, start) = detector(60, 300);
+
+        detector.update_at(Some(100), 10, start);
+        detector.update_at(Some(100), 11, start + Duration::from_secs(59));
+        assert!(!detector.is_halted(Chain::L1));
+
+        detector.update_at(Some(100), 12, start + Duration::from_secs(60));
+        assert!(detector.is_halted(Chain::L1));
+        assert!(!detector.is_halted(Chain::L2));
+        assert!(
+            detector
+                .metrics
+                .gather()
+                .contains("chain_halted{chain=\"L1\"} 1")
+        );
+
+        detector.update_at(Some(101), 13, start + Duration::from_secs(61));
+        assert!(!detector.is_halted(Chain::L1));
+        assert!(
+            detector
+                .metrics
+                .gather()
+                .contains("chain_halted{chain=\"L1\"} 0")
+        );
+    }
+
+    #[test]
+    fn test_l2_halt_detected() {
+        let (mut detector, start) = detector(60, 300);
+
+        for sec in (0..300).step_by(12) {
+            detector.update_at(Some(100 + sec), 10, start + Duration::from_secs(sec));
+        }
+        assert!(!detector.is_halted(Chain::L2));
+
+        detector.update_at(Some(400), 10, start + Duration::from_secs(300));
+        assert!(detector.is_halted(Chain::L2));
+        assert!(!detector.is_halted(Chain::L1));
+
+        detector.update_at(Some(401), 11, start + Duration::from_secs(302));
+        assert!(!detector.is_halted(Chain::L2));
+    }
+
+    #[test]
+    fn test_detection_disabled() {
+        let (mut detector, start) = detector(0, 0);
+        assert!(!detector.should_sample_l1_height(1));
+
+        detector.update_at(Some(100), 10, start);
+        detector.update_at(Some(100), 10, start + Duration::from_secs(3600));
+        assert!(!detector.is_halted(Chain::L1));
+        assert!(!detector.is_halted(Chain::L2));
+    }
+
+    #[test]
+    fn test_reorged_head_is_not_an_advance() {
+        let (mut detector, start) = detector(60, 0);
+
+        detector.update_at(Some(100), 10, start);
+        detector.update_at(Some(99), 10, start + Duration::from_secs(60));
+        assert!(detector.is_halted(Chain::L1));
+    }
+
+    #[test]
+    fn test_l1_height_sampled_once_per_slot() {
+        let (mut detector, _) = detector(60, 0);
+
+        assert!(detector.should_sample_l1_height(10));
+        assert!(!detector.should_sample_l1_height(10));
+        assert!(detector.should_sample_l1_height(11));
+    }
+}
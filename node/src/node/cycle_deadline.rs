@@ -0,0 +1,132 @@
+use crate::metrics::Metrics;
+use std::{fmt, sync::Arc};
+use tokio::time::{Duration, Instant};
+use tracing::warn;
+
+#[derive(Copy, Clone, Debug, PartialEq)]
+pub enum CyclePhase {
+    Status,
+    Preconfirm,
+    Submit,
+}
+
+impl fmt::Display for CyclePhase {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let s = match self {
+            CyclePhase::Status => "Status",
+            CyclePhase::Preconfirm => "Preconfirm",
+            CyclePhase::Submit => "Submit",
+        };
+        write!(f, "{s}")
+    }
+}
+
+/// Tracks the time spent in each phase of a single preconfirmation cycle.
+/// Phases that would start after the deadline are skipped, a zero deadline disables skipping.
+pub struct CycleDeadline {
+    start: Instant,
+    phase_start: Instant,
+    deadline: Duration,
+    metrics: Arc<Metrics>,
+}
+
+impl CycleDeadline {
+    pub fn new(deadline: Duration, metrics: Arc<Metrics>) -> Self {
+        let now = Instant::now();
+        Self {
+            start: now,
+            phase_start: now,
+            deadline,
+            metrics,
+        }
+    }
+
+    pub fn is_exceeded(&self) -> bool {
+        self.is_exceeded_at(Instant::now())
+    }
+
+    fn is_exceeded_at(&self, now: Instant) -> bool {
+        !self.deadline.is_zero() && now.duration_since(self.start) >= self.deadline
+    }
+
+    /// Records the duration of the finished phase and starts measuring the next one.
+    pub fn end_phase(&mut self, phase: CyclePhase) {
+        self.end_phase_at(phase, Instant::now());
+    }
+
+    fn end_phase_at(&mut self, phase: CyclePhase, now: Instant) {
+        self.metrics.observe_preconf_cycle_phase_duration(
+            &phase.to_string(),
+            now.duration_since(self.phase_start).as_secs_f64(),
+        );
+        self.phase_start = now;
+    }
+
+    /// Returns true if the phase should be skipped because the cycle deadline is blown.
+    pub fn skip_phase(&self, phase: CyclePhase) -> bool {
+        self.skip_phase_at(phase, Instant::now())
+    }
+
+    fn skip_phase_at(&self, phase: CyclePhase, now: Instant) -> bool {
+        if !self.is_exceeded_at(now) {
+            return false;
+        }
+        warn!(
+            "Skipping {} phase: preconfirmation cycle deadline of {} ms exceeded, elapsed {} ms",
+            phase,
+            self.deadline.as_millis(),
+            now.duration_since(self.start).as_millis()
+        );
+        self.metrics
+            .inc_preconf_cycle_deadline_exceeded(&phase.to_string());
+        true
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const DEADLINE: Duration = Duration::from_millis(200);
+
+    #[test]
+    fn test_phases_within_deadline_are_not_skipped() {
+        let cycle = CycleDeadline::new(DEADLINE, Arc::new(Metrics::new()));
+        assert!(!cycle.skip_phase_at(CyclePhase::Preconfirm, cycle.start));
+        assert!(!cycle.skip_phase_at(CyclePhase::Submit, cycle.start + DEADLINE / 2));
+        assert!(!cycle.skip_phase_at(
+            CyclePhase::Submit,
+            cycle.start + DEADLINE - Duration::from_millis(1)
+        ));
+    }
+
+    #[test]
+    fn test_phases_after_deadline_are_skipped() {
+        let metrics = Arc::new(Metrics::new());
+        let mut cycle = CycleDeadline::new(DEADLINE, metrics.clone());
+        let start = cycle.start;
+        cycle.end_phase_at(CyclePhase::Status, start + DEADLINE);
+
+        assert!(cycle.is_exceeded_at(start + DEADLINE));
+        assert!(cycle.skip_phase_at(CyclePhase::Preconfirm, start + DEADLINE));
+        assert!(cycle.skip_phase_at(CyclePhase::Submit, start + DEADLINE * 2));
+
+        let output = metrics.gather();
+        assert!(output.contains("preconf_cycle_deadline_exceeded{phase=\"Preconfirm\"} 1"));
+        assert!(output.contains("preconf_cycle_deadline_exceeded{phase=\"Submit\"} 1"));
+        assert!(output.contains("preconf_cycle_phase_duration_seconds_sum{phase=\"Status\"} 0.2"));
+    }
+
+    #[test]
+    fn test_zero_deadline_never_skips() {
+        let metrics = Arc::new(Metrics::new());
+        let cycle = CycleDeadline::new(Duration::ZERO, metrics.clone());
+        assert!(!cycle.skip_phase_at(CyclePhase::Preconfirm, cycle.start));
+        assert!(!cycle.skip_phase_at(CyclePhase::Submit, cycle.start + Duration::from_secs(60)));
+        assert!(
+            !metrics
+                .gather()
+                .contains("preconf_cycle_deadline_exceeded{")
+        );
+    }
+}
@@ -0,0 +1,121 @@
+use crate::metrics::Metrics;
+use std::sync::{Arc, Mutex};
+use tokio::time::{Duration, Instant};
+use tokio_util::sync::CancellationToken;
+use tracing::error;
+
+/// Shuts the node down when the preconfirmation loop stops ticking, e.g. when a call
+/// to a dependency never returns, so the supervisor can restart the node.
+#[derive(Clone)]
+pub struct LoopStallWatchdog {
+    last_tick: Arc<Mutex<Instant>>,
+}
+
+impl LoopStallWatchdog {
+    pub fn new() -> Self {
+        Self {
+            last_tick: Arc::new(Mutex::new(Instant::now())),
+        }
+    }
+
+    /// Called by the loop on every heartbeat.
+    pub fn tick(&self) {
+        if let Ok(mut last_tick) = self.last_tick.lock() {
+            *last_tick = Instant::now();
+        }
+    }
+
+    fn stalled_for(&self, now: Instant) -> Duration {
+        self.last_tick
+            .lock()
+            .map(|last_tick| now.saturating_duration_since(*last_tick))
+            .unwrap_or_default()
+    }
+
+    /// Starts checking the loop progress in the background, zero timeout disables the check.
+    pub fn spawn(
+        &self,
+        stall_timeout: Duration,
+        cancel_token: CancellationToken,
+        metrics: Arc<Metrics>,
+    ) {
+        if stall_timeout.is_zero() {
+            return;
+        }
+        self.tick();
+        let watchdog = self.clone();
+        let check_interval = (stall_timeout / 4).max(Duration::from_millis(10));
+        tokio::spawn(async move {
+            loop {
+                tokio::select! {
+                    _ = cancel_token.cancelled() => return,
+                    _ = tokio::time::sleep(check_interval) => {}
+                }
+                let stalled_for = watchdog.stalled_for(Instant::now());
+                if stalled_for >= stall_timeout {
+                    error!(
+                        "⛔ Preconfirmation loop stalled for {}ms, shutting down...",
+                        stalled_for.as_millis()
+                    );
+                    metrics.inc_preconf_loop_stalls();
+                    cancel_token.cancel();
+                    return;
+                }
+            }
+        });
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const STALL_TIMEOUT: Duration = Duration::from_millis(100);
+
+    #[tokio::test]
+    async fn test_watchdog_fires_on_hung_loop() {
+        let watchdog = LoopStallWatchdog::new();
+        let cancel_token = CancellationToken::new();
+        let metrics = Arc::new(Metrics::new());
+        watchdog.spawn(STALL_TIMEOUT, cancel_token.clone(), metrics.clone());
+
+        // the build step hangs, the loop does not tick anymore
+        tokio::time::timeout(Duration::from_secs(2), cancel_token.cancelled())
+            .await
+            .expect("watchdog must cancel the node");
+        assert!(metrics.gather().contains("preconf_loop_stalls 1"));
+    }
+
+    #[tokio::test]
+    async fn test_watchdog_is_quiet_while_loop_ticks() {
+        let watchdog = LoopStallWatchdog::new();
+        let cancel_token = CancellationToken::new();
+        watchdog.spawn(
+            STALL_TIMEOUT,
+            cancel_token.clone(),
+            Arc::new(Metrics::new()),
+        );
+
+        for _ in 0..10 {
+            tokio::time::sleep(STALL_TIMEOUT / 4).await;
+            watchdog.tick();
+        }
+        assert!(!cancel_token.is_cancelled());
+        assert!(watchdog.stalled_for(Instant::now()) < STALL_TIMEOUT);
+        cancel_token.cancel();
+    }
+
+    #[tokio::test]
+    async fn test_watchdog_disabled() {
+        let watchdog = LoopStallWatchdog::new();
+        let cancel_token = CancellationToken::new();
+        watchdog.spawn(
+            Duration::ZERO,
+            cancel_token.clone(),
+            Arc::new(Metrics::new()),
+        );
+
+        tokio::time::sleep(STALL_TIMEOUT * 2).await;
+        assert!(!cancel_token.is_cancelled());
+    }
+}
@@ -1,21 +1,39 @@
 pub(crate) mod batch_manager;
+mod batch_size_controller;
 pub mod blob_parser;
+mod chain_halt_detector;
+mod cycle_deadline;
 mod l2_head_verifier;
+mod loop_stall_watchdog;
 mod operator;
+mod reorg_rate_limiter;
+mod reorg_reporter;
+mod signer_circuit_breaker;
+mod slot_report;
 mod verifier;
 
 use crate::chain_monitor;
 use crate::{
-    ethereum_l1::{EthereumL1, transaction_error::TransactionError},
+    ethereum_l1::{EthereumL1, config::L1BlockTag, transaction_error::TransactionError},
+    funds_monitor::proposing_balance_guard::ProposingBalanceGuard,
     metrics::Metrics,
     node::l2_head_verifier::L2HeadVerifier,
-    shared::{l2_slot_info::L2SlotInfo, l2_tx_lists::PreBuiltTxList},
-    taiko::{Taiko, preconf_blocks::BuildPreconfBlockResponse},
+    shared::{l2_slot_info::L2SlotInfo, l2_tx_lists::PreBuiltTxList, signer::SignerHealthCheck},
+    taiko::{PreconfBlocksDriver, Taiko, preconf_blocks::BuildPreconfBlockResponse},
 };
+use alloy::primitives::Address;
 use anyhow::Error;
-use batch_manager::{BatchManager, config::BatchBuilderConfig};
+use batch_manager::{BatchManager, config::BatchBuilderConfig, pre_seal_hook::PreSealHook};
+use batch_size_controller::BatchSizeController;
+use chain_halt_detector::{Chain, ChainHaltDetector};
 use chain_monitor::ChainMonitor;
+use cycle_deadline::{CycleDeadline, CyclePhase};
+use loop_stall_watchdog::LoopStallWatchdog;
 use operator::{Operator, Status as OperatorStatus};
+use reorg_rate_limiter::ReorgRateLimiter;
+use reorg_reporter::{ReorgEvent, ReorgReporter};
+use signer_circuit_breaker::SignerCircuitBreaker;
+use slot_report::{SlotReport, SlotReporter};
 use std::sync::Arc;
 use tokio::{
     sync::mpsc::{Receiver, error::TryRecvError},
@@ -30,8 +48,24 @@ pub struct NodeConfig {
     pub handover_window_slots: u64,
     pub handover_start_buffer_ms: u64,
     pub l1_height_lag: u64,
+    pub l1_anchor_block_tag: L1BlockTag,
     pub propose_forced_inclusion: bool,
     pub simulate_not_submitting_at_the_end_of_epoch: bool,
+    pub discard_unsafe_blocks_on_startup: bool,
+    pub preconf_cycle_deadline_ms: u64,
+    pub l1_halt_timeout_sec: u64,
+    pub l2_halt_timeout_sec: u64,
+    pub adaptive_min_blocks_per_batch: u16,
+    pub congested_l1_base_fee_wei: u128,
+    pub congested_l1_inclusion_delay_sec: u64,
+    pub signer_failure_threshold: u64,
+    pub signer_failure_backoff_sec: u64,
+    pub signer_failure_max_backoff_sec: u64,
+    pub follow_on_duty_loss: bool,
+    pub preconf_loop_stall_timeout_sec: u64,
+    pub max_reorgs_per_window: u64,
+    pub reorg_rate_window_sec: u64,
+    pub slot_report_file: Option<String>,
 }
 
 pub struct Node {
@@ -45,7 +79,15 @@ pub struct Node {
     transaction_error_channel: Receiver<TransactionError>,
     metrics: Arc<Metrics>,
     watchdog: u64,
+    loop_stall_watchdog: LoopStallWatchdog,
     head_verifier: L2HeadVerifier,
+    chain_halt_detector: ChainHaltDetector,
+    reorg_reporter: ReorgReporter,
+    reorg_rate_limiter: ReorgRateLimiter,
+    batch_size_controller: BatchSizeController,
+    proposing_balance_guard: Arc<ProposingBalanceGuard>,
+    signer_circuit_breaker: SignerCircuitBreaker,
+    slot_reporter: Option<SlotReporter>,
     config: NodeConfig,
 }
 
@@ -58,8 +100,11 @@ impl Node {
         chain_monitor: Arc<ChainMonitor>,
         transaction_error_channel: Receiver<TransactionError>,
         metrics: Arc<Metrics>,
+        proposing_balance_guard: Arc<ProposingBalanceGuard>,
+        signer_health: Arc<SignerHealthCheck>,
         config: NodeConfig,
         batch_builder_config: BatchBuilderConfig,
+        pre_seal_hook: Option<Arc<dyn PreSealHook>>,
     ) -> Result<Self, Error> {
         let operator = Operator::new(
             &ethereum_l1,
@@ -70,14 +115,46 @@ impl Node {
             cancel_token.clone(),
         )
         .map_err(|e| anyhow::anyhow!("Failed to create Operator: {}", e))?;
+        let batch_size_controller = BatchSizeController::new(
+            config.adaptive_min_blocks_per_batch,
+            batch_builder_config.max_blocks_per_batch,
+            config.congested_l1_base_fee_wei,
+            Duration::from_secs(config.congested_l1_inclusion_delay_sec),
+            metrics.clone(),
+        );
         let batch_manager = BatchManager::new(
             config.l1_height_lag,
+            config.l1_anchor_block_tag,
             batch_builder_config,
             ethereum_l1.clone(),
             taiko.clone(),
             metrics.clone(),
+            pre_seal_hook,
         );
         let head_verifier = L2HeadVerifier::new();
+        let chain_halt_detector = ChainHaltDetector::new(
+            Duration::from_secs(config.l1_halt_timeout_sec),
+            Duration::from_secs(config.l2_halt_timeout_sec),
+            metrics.clone(),
+        );
+        let reorg_reporter = ReorgReporter::new(metrics.clone());
+        let reorg_rate_limiter = ReorgRateLimiter::new(
+            config.max_reorgs_per_window,
+            Duration::from_secs(config.reorg_rate_window_sec),
+            metrics.clone(),
+        );
+        let signer_circuit_breaker = SignerCircuitBreaker::new(
+            signer_health,
+            config.signer_failure_threshold,
+            Duration::from_secs(config.signer_failure_backoff_sec),
+            Duration::from_secs(config.signer_failure_max_backoff_sec),
+            metrics.clone(),
+        );
+        let slot_reporter = config
+            .slot_report_file
+            .as_deref()
+            .map(SlotReporter::new)
+            .transpose()?;
         Ok(Self {
             cancel_token,
             batch_manager,
@@ -89,7 +166,15 @@ impl Node {
             transaction_error_channel,
             metrics,
             watchdog: 0,
+            loop_stall_watchdog: LoopStallWatchdog::new(),
             head_verifier,
+            chain_halt_detector,
+            reorg_reporter,
+            reorg_rate_limiter,
+            batch_size_controller,
+            proposing_balance_guard,
+            signer_circuit_breaker,
+            slot_reporter,
             config,
         })
     }
@@ -146,37 +231,70 @@ impl Node {
         }
 
         // Wait for the last sent transaction to be executed
-        self.wait_for_sent_transactions().await?;
+        self.ethereum_l1
+            .execution_layer
+            .wait_for_sent_transactions(Duration::from_secs(6))
+            .await?;
+
+        if self.config.discard_unsafe_blocks_on_startup {
+            self.discard_unsafe_blocks().await?;
+        }
 
         Ok(())
     }
 
-    async fn wait_for_sent_transactions(&self) -> Result<(), Error> {
-        loop {
-            let nonce_latest: u64 = self
-                .ethereum_l1
-                .execution_layer
-                .get_preconfer_nonce_latest()
-                .await?;
-            let nonce_pending: u64 = self
-                .ethereum_l1
-                .execution_layer
-                .get_preconfer_nonce_pending()
+    /// After an unclean shutdown geth can contain preconfirmed blocks which were never proposed.
+    /// There are no pending transactions at this point, so such blocks will not be confirmed on L1.
+    /// Only the blocks preconfirmed by this node are discarded.
+    async fn discard_unsafe_blocks(&self) -> Result<(), Error> {
+        let (taiko_inbox_height, taiko_geth_height) = self.get_current_protocol_height().await?;
+
+        let Some((first_block, last_block)) =
+            get_unsafe_blocks_range(taiko_inbox_height, taiko_geth_height)
+        else {
+            return Ok(());
+        };
+        let mut coinbases = Vec::new();
+        for block_number in first_block..=last_block {
+            let block = self
+                .taiko
+                .get_l2_block_by_number(block_number, false)
                 .await?;
-            if nonce_pending == nonce_latest {
-                break;
-            }
-            debug!(
-                "Waiting for sent transactions to be executed. Nonce Latest: {nonce_latest}, Nonce Pending: {nonce_pending}"
-            );
-            sleep(Duration::from_secs(6)).await;
+            coinbases.push(block.header.beneficiary);
+        }
+
+        let Some(new_last_block_id) = discard_own_unsafe_blocks(
+            self.taiko.as_ref(),
+            &self.chain_monitor,
+            first_block,
+            &coinbases,
+            &self.taiko.get_own_fee_recipients(),
+        )
+        .await
+        .map_err(|e| anyhow::anyhow!("Failed to discard unsafe L2 blocks: {}", e))?
+        else {
+            return Ok(());
+        };
+
+        let taiko_geth_height = self.taiko.get_latest_l2_block_id().await?;
+        if taiko_geth_height > new_last_block_id {
+            return Err(anyhow::anyhow!(
+                "Unsafe L2 blocks still present after discarding, Taiko Geth Height: {}",
+                taiko_geth_height
+            ));
         }
+        info!("Unsafe L2 blocks discarded, Taiko Geth Height: {taiko_geth_height}");
 
         Ok(())
     }
 
     async fn preconfirmation_loop(&mut self) {
         debug!("Main perconfirmation loop started");
+        self.loop_stall_watchdog.spawn(
+            Duration::from_secs(self.config.preconf_loop_stall_timeout_sec),
+            self.cancel_token.clone(),
+            self.metrics.clone(),
+        );
         // Synchronize with L1 Slot Start Time
         match self.ethereum_l1.slot_clock.duration_to_next_slot() {
             Ok(duration) => {
@@ -198,6 +316,7 @@ impl Node {
         interval.set_missed_tick_behavior(tokio::time::MissedTickBehavior::Skip);
         loop {
             interval.tick().await;
+            self.loop_stall_watchdog.tick();
             if self.cancel_token.is_cancelled() {
                 info!("Shutdown signal received, exiting main loop...");
                 return;
@@ -241,7 +360,10 @@ impl Node {
                 .get_preconfer_nonce_pending()
                 .await?;
             debug!("Nonce Latest: {nonce_latest}, Nonce Pending: {nonce_pending}");
-            if nonce_latest == nonce_pending {
+            // the sent txs are replaced by the first submission with the latest nonce
+            if nonce_latest == nonce_pending
+                || self.ethereum_l1.execution_layer.replaces_pending_txs()
+            {
                 // Just create a new verifier, we will check it in preconfirmation loop
                 self.verifier = Some(
                     verifier::Verifier::new_with_taiko_height(
@@ -268,8 +390,31 @@ impl Node {
     }
 
     async fn main_block_preconfirmation_step(&mut self) -> Result<(), Error> {
+        let mut report = SlotReport::default();
+        let result = self.do_main_block_preconfirmation_step(&mut report).await;
+        if let Some(slot_reporter) = self.slot_reporter.as_mut() {
+            report.batches = self.batch_manager.get_number_of_batches();
+            report.batches_ready_to_send = self.batch_manager.get_number_of_batches_ready_to_send();
+            report.record_result(&result);
+            if let Err(err) = slot_reporter.write(&report).await {
+                warn!("Failed to write slot report: {}", err);
+            }
+        }
+        result
+    }
+
+    async fn do_main_block_preconfirmation_step(
+        &mut self,
+        report: &mut SlotReport,
+    ) -> Result<(), Error> {
+        let mut cycle = CycleDeadline::new(
+            Duration::from_millis(self.config.preconf_cycle_deadline_ms),
+            self.metrics.clone(),
+        );
+
         let (l2_slot_info, current_status, pending_tx_list) =
             self.get_slot_info_and_status().await?;
+        report.record_status(&l2_slot_info, &current_status);
 
         // Get the transaction status before checking the error channel
         // to avoid race condition
@@ -278,10 +423,16 @@ impl Node {
             .execution_layer
             .is_transaction_in_progress()
             .await?;
+        self.adjust_max_blocks_per_batch(transaction_in_progress)
+            .await;
 
         self.check_transaction_error_channel(&current_status)
             .await?;
 
+        if self.config.follow_on_duty_loss && self.operator.is_duty_lost() {
+            self.switch_to_following(&current_status).await?;
+        }
+
         if current_status.is_preconfirmation_start_slot() {
             self.head_verifier
                 .set(l2_slot_info.parent_id(), *l2_slot_info.parent_hash())
@@ -331,7 +482,27 @@ impl Node {
             }
         }
 
-        if current_status.is_preconfer() && current_status.is_driver_synced() {
+        self.update_chain_halt_detector(&l2_slot_info).await;
+        // own reanchors are expected by the chain monitor and not counted
+        for _ in 0..self.chain_monitor.take_unexpected_reorgs().await {
+            self.reorg_rate_limiter.record_reorg();
+        }
+        // Only the batch proposing is paused on a chain halt, preconfirmation continues
+        let chain_halted = self.chain_halt_detector.is_halted(Chain::L1)
+            || self.chain_halt_detector.is_halted(Chain::L2);
+
+        cycle.end_phase(CyclePhase::Status);
+
+        report.no_block_reason = if !current_status.is_preconfer() {
+            Some(slot_report::NO_BLOCK_NOT_PRECONFER)
+        } else if !current_status.is_driver_synced() {
+            Some(slot_report::NO_BLOCK_DRIVER_NOT_SYNCED)
+        } else if cycle.skip_phase(CyclePhase::Preconfirm) {
+            Some(slot_report::NO_BLOCK_CYCLE_DEADLINE)
+        } else {
+            None
+        };
+        if report.no_block_reason.is_none() {
             // do not trigger fast reanchor on submitter window to prevent from double reanchor
             if !current_status.is_submitter()
                 && self
@@ -339,6 +510,7 @@ impl Node {
                     .await?
             {
                 // reanchored, no need to preconf
+                report.no_block_reason = Some(slot_report::NO_BLOCK_REANCHORED);
                 return Ok(());
             }
 
@@ -347,6 +519,10 @@ impl Node {
                 .verify(l2_slot_info.parent_id(), l2_slot_info.parent_hash())
                 .await
             {
+                if self.reconcile_driver_head(&l2_slot_info).await {
+                    // the head is back at the last preconfirmed block, build on the next heartbeat
+                    return Ok(());
+                }
                 self.head_verifier.log_error().await;
                 self.cancel_token.cancel();
                 return Err(anyhow::anyhow!(
@@ -363,14 +539,35 @@ impl Node {
                         && self.verifier.is_none(),
                 )
                 .await?;
+            report.record_blocks(forced_inclusion_block.as_ref(), block.as_ref());
 
             self.verify_preconfed_block(forced_inclusion_block).await?;
             self.verify_preconfed_block(block).await?;
+            cycle.end_phase(CyclePhase::Preconfirm);
         }
 
-        if current_status.is_submitter() && !transaction_in_progress {
+        report.submission = if !current_status.is_submitter() {
+            Some(slot_report::SUBMISSION_NOT_SUBMITTER)
+        } else if transaction_in_progress {
+            Some(slot_report::SUBMISSION_TX_IN_PROGRESS)
+        } else if chain_halted {
+            Some(slot_report::SUBMISSION_CHAIN_HALTED)
+        } else if self.proposing_balance_guard.is_proposing_paused() {
+            Some(slot_report::SUBMISSION_PAUSED_BY_LOW_BALANCE)
+        } else if !self.reorg_rate_limiter.allows_proposing() {
+            Some(slot_report::SUBMISSION_PAUSED_BY_REORG_RATE)
+        } else if cycle.skip_phase(CyclePhase::Submit) {
+            Some(slot_report::SUBMISSION_CYCLE_DEADLINE)
+        } else {
+            None
+        };
+        if report.submission.is_none() {
             // first check verifier
-            if self.has_verified_unproposed_batches().await? {
+            if !self.has_verified_unproposed_batches().await? {
+                report.submission = Some(slot_report::SUBMISSION_NOT_VERIFIED);
+            } else if !self.signer_circuit_breaker.allows_proposing().await {
+                report.submission = Some(slot_report::SUBMISSION_SIGNER_UNAVAILABLE);
+            } else {
                 if let Err(err) = self
                     .batch_manager
                     .try_submit_oldest_batch(current_status.is_preconfer())
@@ -382,7 +579,21 @@ impl Node {
                     }
                     return Err(err);
                 }
+                report.submission = Some(
+                    if matches!(
+                        self.ethereum_l1
+                            .execution_layer
+                            .is_transaction_in_progress()
+                            .await,
+                        Ok(true)
+                    ) {
+                        slot_report::SUBMISSION_SUBMITTED
+                    } else {
+                        slot_report::SUBMISSION_NOTHING_TO_SUBMIT
+                    },
+                );
             }
+            cycle.end_phase(CyclePhase::Submit);
         }
 
         if !current_status.is_submitter() && !current_status.is_preconfer() {
@@ -404,6 +615,42 @@ impl Node {
         Ok(())
     }
 
+    /// The preconfer duty was lost before the end of sequencing, e.g. a beacon chain reorg
+    /// has changed the proposer. Stops building and follows the chain from now on,
+    /// the blocks already built are still proposed while the node is the submitter.
+    async fn switch_to_following(&mut self, current_status: &OperatorStatus) -> Result<(), Error> {
+        warn!(
+            "🔀 Preconfer duty lost before the end of sequencing, switching to follow mode. Status: {}",
+            current_status
+        );
+        if current_status.is_submitter() {
+            self.batch_manager.try_finalize_current_batch()?;
+        } else {
+            self.batch_manager.reset_builder().await?;
+            self.verifier = None;
+        }
+        Ok(())
+    }
+
+    /// The driver head is behind the last preconfirmed block, e.g. the driver has lost
+    /// unsafe blocks on restart. Returns true when the missing blocks are submitted again.
+    async fn reconcile_driver_head(&self, l2_slot_info: &L2SlotInfo) -> bool {
+        if !self.taiko.is_driver_head_reconciliation_enabled() {
+            return false;
+        }
+        match self
+            .taiko
+            .reconcile_driver_head(l2_slot_info.parent_id(), l2_slot_info.parent_hash())
+            .await
+        {
+            Ok(blocks) => blocks > 0,
+            Err(err) => {
+                error!("Failed to reconcile the driver head: {}", err);
+                false
+            }
+        }
+    }
+
     async fn verify_preconfed_block(
         &self,
         l2_block: Option<BuildPreconfBlockResponse>,
@@ -472,6 +719,52 @@ impl Node {
         Ok(false)
     }
 
+    async fn adjust_max_blocks_per_batch(&mut self, transaction_in_progress: bool) {
+        if !self.batch_size_controller.is_enabled() {
+            return;
+        }
+        let Some(inclusion_delay) = self
+            .batch_size_controller
+            .on_transaction_status(transaction_in_progress)
+        else {
+            return;
+        };
+        let base_fee = match self.ethereum_l1.execution_layer.get_l1_base_fee().await {
+            Ok(base_fee) => Some(base_fee),
+            Err(err) => {
+                warn!("Batch size adjustment: failed to get L1 base fee: {}", err);
+                None
+            }
+        };
+        let max_blocks_per_batch = self.batch_size_controller.adjust(base_fee, inclusion_delay);
+        self.batch_manager
+            .set_max_blocks_per_batch(max_blocks_per_batch);
+    }
+
+    async fn update_chain_halt_detector(&mut self, l2_slot_info: &L2SlotInfo) {
+        let l1_height = match self.ethereum_l1.slot_clock.get_current_slot() {
+            Ok(l1_slot) if self.chain_halt_detector.should_sample_l1_height(l1_slot) => {
+                match self.ethereum_l1.execution_layer.get_l1_height().await {
+                    Ok(height) => Some(height),
+                    Err(err) => {
+                        warn!("Chain halt detection: failed to get L1 height: {}", err);
+                        None
+                    }
+                }
+            }
+            Ok(_) => None,
+            Err(err) => {
+                warn!(
+                    "Chain halt detection: failed to get current L1 slot: {}",
+                    err
+                );
+                None
+            }
+        };
+        self.chain_halt_detector
+            .update(l1_height, l2_slot_info.parent_id());
+    }
+
     async fn get_slot_info_and_status(
         &mut self,
     ) -> Result<(L2SlotInfo, OperatorStatus, Option<PreBuiltTxList>), Error> {
@@ -565,7 +858,7 @@ impl Node {
         current_status: &OperatorStatus,
     ) -> Result<(), Error> {
         match error {
-            TransactionError::ReanchorRequired => {
+            TransactionError::ReanchorRequired | TransactionError::RejectedByPreSealHook => {
                 if current_status.is_preconfer() && current_status.is_submitter() {
                     let taiko_inbox_height = match self
                         .ethereum_l1
@@ -576,30 +869,37 @@ impl Node {
                         Ok(height) => height,
                         Err(err) => {
                             error!(
-                                "ReanchorRequired: Failed to get L2 height from Taiko inbox: {}",
-                                err
+                                "{}: Failed to get L2 height from Taiko inbox: {}",
+                                error, err
                             );
                             self.cancel_token.cancel();
                             return Err(anyhow::anyhow!(
-                                "ReanchorRequired: Failed to get L2 height from Taiko inbox: {}",
+                                "{}: Failed to get L2 height from Taiko inbox: {}",
+                                error,
                                 err
                             ));
                         }
                     };
+                    let reason = if matches!(error, TransactionError::ReanchorRequired) {
+                        "Transaction reverted"
+                    } else {
+                        "Batch rejected by pre-seal hook"
+                    };
                     if let Err(err) = self
-                        .reanchor_blocks(taiko_inbox_height, "Transaction reverted", false)
+                        .reanchor_blocks(taiko_inbox_height, reason, false)
                         .await
                     {
-                        error!("ReanchorRequired: Failed to reanchor blocks: {}", err);
+                        error!("{}: Failed to reanchor blocks: {}", error, err);
                         self.cancel_token.cancel();
                         return Err(anyhow::anyhow!(
-                            "ReanchorRequired: Failed to reanchor blocks: {}",
+                            "{}: Failed to reanchor blocks: {}",
+                            error,
                             err
                         ));
                     }
                     return Err(anyhow::anyhow!("Reanchoring done"));
                 } else {
-                    warn!("Transaction reverted, not our epoch, skipping reorg");
+                    warn!("{}, not our epoch, skipping reorg", error);
                 }
             }
             TransactionError::NotConfirmed => {
@@ -644,6 +944,18 @@ impl Node {
                 self.cancel_token.cancel();
                 return Err(anyhow::anyhow!("Transaction reverted, exiting"));
             }
+            TransactionError::ExceedsBlockGasLimit => {
+                self.cancel_token.cancel();
+                return Err(anyhow::anyhow!(
+                    "Batch proposal exceeds the L1 block gas limit, exiting"
+                ));
+            }
+            TransactionError::BatchTooLarge => {
+                self.cancel_token.cancel();
+                return Err(anyhow::anyhow!(
+                    "Batch proposal exceeds the on-chain batch size limit, exiting"
+                ));
+            }
             TransactionError::OldestForcedInclusionDue => {
                 let taiko_inbox_height = match self
                     .ethereum_l1
@@ -682,6 +994,11 @@ impl Node {
                     "Need to include forced inclusion, reanchoring done, skipping slot"
                 ));
             }
+            TransactionError::SenderNotAuthorized => {
+                return Err(anyhow::anyhow!(
+                    "Sender is not authorized to propose batches, skipping submission"
+                ));
+            }
             TransactionError::NotTheOperatorInCurrentEpoch => {
                 warn!("Propose batch transaction executed too late.");
                 return Ok(());
@@ -773,7 +1090,21 @@ impl Node {
         );
 
         let start_time = std::time::Instant::now();
+        let mut event = ReorgEvent::new(parent_block_id, reason);
+        let result = self
+            .do_reanchor_blocks(parent_block_id, allow_forced_inclusion, &mut event)
+            .await;
+        self.reorg_reporter
+            .report(&event, result.is_ok(), start_time.elapsed());
+        result
+    }
 
+    async fn do_reanchor_blocks(
+        &mut self,
+        parent_block_id: u64,
+        allow_forced_inclusion: bool,
+        event: &mut ReorgEvent,
+    ) -> Result<(), Error> {
         let mut l2_slot_info = self
             .taiko
             .get_l2_slot_info_by_parent_block(alloy::eips::BlockNumberOrTag::Number(
@@ -794,6 +1125,13 @@ impl Node {
             .await?;
 
         let blocks_reanchored = blocks.len() as u64;
+        event.depth = blocks_reanchored;
+        event.from_head = Some(
+            blocks
+                .last()
+                .map(|block| (block.header.number, block.header.hash))
+                .unwrap_or((l2_slot_info.parent_id(), *l2_slot_info.parent_hash())),
+        );
 
         let mut forced_inclusion_flags: Vec<bool> = Vec::with_capacity(blocks.len());
         for block in &blocks {
@@ -884,13 +1222,181 @@ impl Node {
             .set(l2_slot_info.parent_id(), *l2_slot_info.parent_hash())
             .await;
 
+        event.to_head = Some((l2_slot_info.parent_id(), *l2_slot_info.parent_hash()));
         self.metrics.inc_by_blocks_reanchored(blocks_reanchored);
+        Ok(())
+    }
+}
 
-        debug!(
-            "Finished reanchoring blocks for parent block {} in {} ms",
-            parent_block_id,
-            start_time.elapsed().as_millis()
+/// Returns the range of L2 blocks which exist in geth but are not proposed to L1.
+fn get_unsafe_blocks_range(taiko_inbox_height: u64, taiko_geth_height: u64) -> Option<(u64, u64)> {
+    if taiko_geth_height > taiko_inbox_height {
+        Some((taiko_inbox_height + 1, taiko_geth_height))
+    } else {
+        None
+    }
+}
+
+/// Removes the unsafe blocks preconfirmed with any of the own coinbases from the top of the
+/// chain, blocks of other preconfers and the blocks below them are kept.
+/// Returns the new last block when blocks were removed.
+async fn discard_own_unsafe_blocks(
+    driver: &impl PreconfBlocksDriver,
+    chain_monitor: &ChainMonitor,
+    first_unsafe_block: u64,
+    unsafe_block_coinbases: &[Address],
+    own_coinbases: &[Address],
+) -> Result<Option<u64>, Error> {
+    let own_blocks = unsafe_block_coinbases
+        .iter()
+        .rev()
+        .take_while(|coinbase| own_coinbases.contains(coinbase))
+        .count();
+    let kept_blocks = unsafe_block_coinbases.len() - own_blocks;
+    if kept_blocks > 0 {
+        warn!(
+            "Keeping {} unsafe L2 blocks from {} with blocks preconfirmed by another preconfer",
+            kept_blocks, first_unsafe_block
         );
-        Ok(())
+    }
+    if own_blocks == 0 {
+        return Ok(None);
+    }
+
+    let new_last_block_id = first_unsafe_block + kept_blocks as u64 - 1;
+    warn!(
+        "⛓️‍💥 Discarding unsafe L2 blocks {}..={} not confirmed on L1",
+        new_last_block_id + 1,
+        new_last_block_id + own_blocks as u64
+    );
+    chain_monitor
+        .set_expected_reorg(new_last_block_id + 1)
+        .await;
+    driver.remove_preconf_blocks(new_last_block_id).await?;
+    Ok(Some(new_last_block_id))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::taiko::{
+        mock_driver::MockDriver,
+        operation_type::OperationType,
+        preconf_blocks::{BuildPreconfBlockRequestBody, ExecutableData},
+    };
+    use alloy::primitives::B256;
+
+    const OWN_COINBASE: Address = Address::repeat_byte(1);
+    const OTHER_COINBASE: Address = Address::repeat_byte(2);
+    const FALLBACK_COINBASE: Address = Address::repeat_byte(3);
+
+    // driver with the last proposed block 10 and the unsafe blocks on top of it
+    async fn driver_with_unsafe_blocks(blocks: u64) -> MockDriver {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+        for timestamp in 0..blocks {
+            let head = driver.head().unwrap();
+            let request = BuildPreconfBlockRequestBody {
+                executable_data: ExecutableData {
+                    base_fee_per_gas: 0,
+                    block_number: head.number + 1,
+                    extra_data: String::new(),
+                    fee_recipient: String::new(),
+                    gas_limit: 0,
+                    parent_hash: format!("0x{}", hex::encode(head.hash)),
+                    timestamp,
+                    transactions: String::new(),
+                },
+                end_of_sequencing: false,
+                is_forced_inclusion: false,
+            };
+            driver
+                .submit_preconf_block(&request, OperationType::Preconfirm)
+                .await
+                .unwrap();
+        }
+        driver
+    }
+
+    fn chain_monitor() -> ChainMonitor {
+        ChainMonitor::new(
+            "ws://127.0.0.1:1".to_string(),
+            "ws://127.0.0.1:2".to_string(),
+            Address::ZERO.to_string(),
+            CancellationToken::new(),
+        )
+        .unwrap()
+    }
+
+    #[test]
+    fn test_unsafe_blocks_ahead_of_l1_are_discarded() {
+        assert_eq!(get_unsafe_blocks_range(100, 105), Some((101, 105)));
+        assert_eq!(get_unsafe_blocks_range(100, 101), Some((101, 101)));
+    }
+
+    #[test]
+    fn test_no_unsafe_blocks_when_head_is_confirmed() {
+        assert_eq!(get_unsafe_blocks_range(100, 100), None);
+        assert_eq!(get_unsafe_blocks_range(100, 99), None);
+    }
+
+    #[tokio::test]
+    async fn test_own_unsafe_blocks_are_discarded() {
+        let driver = driver_with_unsafe_blocks(3).await;
+        let discarded = discard_own_unsafe_blocks(
+            &driver,
+            &chain_monitor(),
+            11,
+            &[OWN_COINBASE; 3],
+            &[OWN_COINBASE],
+        )
+        .await
+        .unwrap();
+        assert_eq!(discarded, Some(10));
+        assert_eq!(driver.head().unwrap().number, 10);
+    }
+
+    #[tokio::test]
+    async fn test_unsafe_blocks_of_other_preconfers_are_kept() {
+        // blocks 11 and 12 from the previous preconfer, 13 and 14 our own
+        let driver = driver_with_unsafe_blocks(4).await;
+        let coinbases = [OTHER_COINBASE, OTHER_COINBASE, OWN_COINBASE, OWN_COINBASE];
+        let discarded =
+            discard_own_unsafe_blocks(&driver, &chain_monitor(), 11, &coinbases, &[OWN_COINBASE])
+                .await
+                .unwrap();
+        assert_eq!(discarded, Some(12));
+        assert_eq!(driver.head().unwrap().number, 12);
+
+        // our block below the blocks of another preconfer cannot be removed without them
+        let driver = driver_with_unsafe_blocks(2).await;
+        let discarded = discard_own_unsafe_blocks(
+            &driver,
+            &chain_monitor(),
+            11,
+            &[OWN_COINBASE, OTHER_COINBASE],
+            &[OWN_COINBASE],
+        )
+        .await
+        .unwrap();
+        assert_eq!(discarded, None);
+        assert_eq!(driver.head().unwrap().number, 12);
+    }
+
+    #[tokio::test]
+    async fn test_unsafe_blocks_with_fallback_fee_recipient_are_discarded() {
+        // block 12 was built under the fallback fee recipient before the restart
+        let driver = driver_with_unsafe_blocks(3).await;
+        let coinbases = [OTHER_COINBASE, FALLBACK_COINBASE, OWN_COINBASE];
+        let discarded = discard_own_unsafe_blocks(
+            &driver,
+            &chain_monitor(),
+            11,
+            &coinbases,
+            &[OWN_COINBASE, FALLBACK_COINBASE],
+        )
+        .await
+        .unwrap();
+        assert_eq!(discarded, Some(11));
+        assert_eq!(driver.head().unwrap().number, 11);
     }
 }
@@ -1,7 +1,7 @@
 use crate::{
     ethereum_l1::{
         EthereumL1,
-        execution_layer::{ExecutionLayer, PreconfOperator},
+        execution_layer::{EpochDuty, ExecutionLayer, PreconfOperator},
         slot_clock::{Clock, RealClock, SlotClock},
     },
     shared::l2_slot_info::L2SlotInfo,
@@ -11,7 +11,7 @@ use crate::{
 use anyhow::Error;
 use std::sync::Arc;
 use tokio_util::sync::CancellationToken;
-use tracing::warn;
+use tracing::{debug, warn};
 
 pub struct Operator<
     T: PreconfOperator = ExecutionLayer,
@@ -27,6 +27,10 @@ pub struct Operator<
     continuing_role: bool,
     simulate_not_submitting_at_the_end_of_epoch: bool,
     was_synced_preconfer: bool,
+    end_of_sequencing_reached: bool,
+    duty_lost: bool,
+    // last seen duty of the current epoch, an epoch without an operator is logged once
+    current_epoch_duty: Option<EpochDuty>,
     cancel_token: CancellationToken,
     cancel_counter: u64,
     operator_transition_slots: u64,
@@ -112,6 +116,9 @@ impl Operator {
             continuing_role: false,
             simulate_not_submitting_at_the_end_of_epoch,
             was_synced_preconfer: false,
+            end_of_sequencing_reached: false,
+            duty_lost: false,
+            current_epoch_duty: None,
             cancel_token,
             cancel_counter: 0,
             operator_transition_slots: OPERATOR_TRANSITION_SLOTS,
@@ -128,7 +135,9 @@ impl<T: PreconfOperator, U: Clock, V: PreconfDriver> Operator<T, U, V> {
             .await?
         {
             warn!("PreconfRouter is not specified in TaikoWrapper");
+            let duty_lost = self.was_synced_preconfer && !self.end_of_sequencing_reached;
             self.reset();
+            self.duty_lost = duty_lost;
             return Ok(Status {
                 preconfer: false,
                 submitter: false,
@@ -143,14 +152,14 @@ impl<T: PreconfOperator, U: Clock, V: PreconfDriver> Operator<T, U, V> {
         // For the first N slots of the new epoch, use the next operator from the previous epoch
         // it's because of the delay that L1 updates the current operator after the epoch has changed.
         let current_operator = if l1_slot < self.operator_transition_slots {
-            let curr = match self.execution_layer.is_operator_for_current_epoch().await {
-                Ok(val) => format!("{val}"),
+            let curr = match self.execution_layer.get_duty_for_current_epoch().await {
+                Ok(val) => format!("{val:?}"),
                 Err(e) => {
                     format!("Failed to check current epoch operator: {e}")
                 }
             };
-            let next = match self.execution_layer.is_operator_for_next_epoch().await {
-                Ok(val) => format!("{val}"),
+            let next = match self.execution_layer.get_duty_for_next_epoch().await {
+                Ok(val) => format!("{val:?}"),
                 Err(e) => {
                     format!("Failed to check next epoch operator: {e}")
                 }
@@ -163,14 +172,21 @@ impl<T: PreconfOperator, U: Clock, V: PreconfDriver> Operator<T, U, V> {
             );
             self.next_operator
         } else {
-            self.next_operator = match self.execution_layer.is_operator_for_next_epoch().await {
-                Ok(val) => val,
+            self.next_operator = match self.execution_layer.get_duty_for_next_epoch().await {
+                Ok(duty) => {
+                    if duty == EpochDuty::NoOperator {
+                        debug!("No operator is designated for the next epoch");
+                    }
+                    duty == EpochDuty::Operator
+                }
                 Err(e) => {
                     warn!("Failed to check next epoch operator: {:?}", e);
                     false
                 }
             };
-            let current_operator = self.execution_layer.is_operator_for_current_epoch().await?;
+            let current_duty = self.execution_layer.get_duty_for_current_epoch().await?;
+            self.log_current_epoch_duty(current_duty);
+            let current_operator = current_duty == EpochDuty::Operator;
             self.continuing_role = current_operator && self.next_operator;
             current_operator
         };
@@ -189,8 +205,12 @@ impl<T: PreconfOperator, U: Clock, V: PreconfDriver> Operator<T, U, V> {
             .await?;
         let preconfirmation_started =
             self.is_preconfirmation_start_l2_slot(preconfer, is_driver_synced);
+        // The preconfer duty ends after the end of sequencing block, losing it earlier
+        // means the operator has changed in the middle of our sequencing
+        let duty_lost = self.was_synced_preconfer && !preconfer && !self.end_of_sequencing_reached;
         if preconfirmation_started {
             self.was_synced_preconfer = true;
+            self.end_of_sequencing_reached = false;
         }
         if !preconfer {
             self.was_synced_preconfer = false;
@@ -198,6 +218,10 @@ impl<T: PreconfOperator, U: Clock, V: PreconfDriver> Operator<T, U, V> {
 
         let submitter = self.is_submitter(current_operator, handover_window);
         let end_of_sequencing = self.is_end_of_sequencing(preconfer, submitter, l1_slot)?;
+        if end_of_sequencing {
+            self.end_of_sequencing_reached = true;
+        }
+        self.duty_lost = duty_lost;
 
         Ok(Status {
             preconfer,
@@ -212,9 +236,27 @@ impl<T: PreconfOperator, U: Clock, V: PreconfDriver> Operator<T, U, V> {
         self.next_operator = false;
         self.continuing_role = false;
         self.was_synced_preconfer = false;
+        self.end_of_sequencing_reached = false;
+        self.duty_lost = false;
         self.cancel_counter = 0;
     }
 
+    /// The slots of an epoch without an operator are empty, the node does not preconfirm
+    /// in them and logs it once for the epoch instead of on every poll.
+    fn log_current_epoch_duty(&mut self, duty: EpochDuty) {
+        if duty == EpochDuty::NoOperator && self.current_epoch_duty != Some(EpochDuty::NoOperator) {
+            warn!(
+                "No operator is designated for the current epoch, not assuming the preconfer duty"
+            );
+        }
+        self.current_epoch_duty = Some(duty);
+    }
+
+    /// True when the last status lost the preconfer duty before the end of sequencing.
+    pub fn is_duty_lost(&self) -> bool {
+        self.duty_lost
+    }
+
     fn is_end_of_sequencing(
         &self,
         preconfer: bool,
@@ -380,19 +422,19 @@ mod tests {
     const HANDOVER_WINDOW_SLOTS: i64 = 6;
     use alloy::primitives::B256;
     struct ExecutionLayerMock {
-        current_operator: bool,
-        next_operator: bool,
+        current_duty: EpochDuty,
+        next_duty: EpochDuty,
         is_preconf_router_specified: bool,
         taiko_inbox_height: u64,
     }
 
     impl PreconfOperator for ExecutionLayerMock {
-        async fn is_operator_for_current_epoch(&self) -> Result<bool, Error> {
-            Ok(self.current_operator)
+        async fn get_duty_for_current_epoch(&self) -> Result<EpochDuty, Error> {
+            Ok(self.current_duty)
         }
 
-        async fn is_operator_for_next_epoch(&self) -> Result<bool, Error> {
-            Ok(self.next_operator)
+        async fn get_duty_for_next_epoch(&self) -> Result<EpochDuty, Error> {
+            Ok(self.next_duty)
         }
 
         async fn is_preconf_router_specified_in_taiko_wrapper(&self) -> Result<bool, Error> {
@@ -465,6 +507,51 @@ mod tests {
         );
     }
 
+    #[tokio::test]
+    async fn test_epoch_without_operator() {
+        let no_operator = || {
+            Arc::new(ExecutionLayerMock {
+                current_duty: EpochDuty::NoOperator,
+                next_duty: EpochDuty::NoOperator,
+                is_preconf_router_specified: true,
+                taiko_inbox_height: 0,
+            })
+        };
+        let not_preconfer = Status {
+            preconfer: false,
+            submitter: false,
+            preconfirmation_started: false,
+            end_of_sequencing: false,
+            is_driver_synced: true,
+        };
+
+        // middle of the epoch
+        let mut operator = create_operator(10 * 12, false, false, true);
+        operator.execution_layer = no_operator();
+        assert_eq!(
+            operator.get_status(&get_l2_slot_info()).await.unwrap(),
+            not_preconfer
+        );
+        assert_eq!(operator.current_epoch_duty, Some(EpochDuty::NoOperator));
+        assert!(!operator.is_duty_lost());
+        // the following polls of the epoch keep standing down
+        assert_eq!(
+            operator.get_status(&get_l2_slot_info()).await.unwrap(),
+            not_preconfer
+        );
+        assert_eq!(operator.current_epoch_duty, Some(EpochDuty::NoOperator));
+
+        // handover window of an epoch followed by an epoch without operator
+        let mut operator = create_operator((32 - HANDOVER_WINDOW_SLOTS) * 12, false, false, true);
+        operator.execution_layer = no_operator();
+        assert_eq!(
+            operator.get_status(&get_l2_slot_info()).await.unwrap(),
+            not_preconfer
+        );
+        assert!(!operator.next_operator);
+        assert!(!operator.continuing_role);
+    }
+
     #[tokio::test]
     async fn test_end_of_sequencing() {
         // End of sequencing
@@ -549,6 +636,68 @@ mod tests {
         );
     }
 
+    #[tokio::test]
+    async fn test_duty_lost_mid_sequencing() {
+        // the operator has changed in the middle of the epoch
+        let mut operator = create_operator(
+            10 * 12 + 2, // 10th l1 slot, second l2 slot
+            false,
+            false,
+            true,
+        );
+        operator.was_synced_preconfer = true;
+        let status = operator.get_status(&get_l2_slot_info()).await.unwrap();
+        assert!(!status.is_preconfer());
+        assert!(!status.is_submitter());
+        assert!(operator.is_duty_lost());
+
+        // the loss is reported once, the node follows from now on
+        let status = operator.get_status(&get_l2_slot_info()).await.unwrap();
+        assert!(!status.is_preconfer());
+        assert!(!operator.is_duty_lost());
+
+        // handover without the end of sequencing block, still the submitter
+        let mut operator = create_operator(
+            (32 - HANDOVER_WINDOW_SLOTS) * 12 + 2, // first l1 slot of the handover window
+            true,
+            false,
+            true,
+        );
+        operator.was_synced_preconfer = true;
+        let status = operator.get_status(&get_l2_slot_info()).await.unwrap();
+        assert!(!status.is_preconfer());
+        assert!(status.is_submitter());
+        assert!(operator.is_duty_lost());
+    }
+
+    #[tokio::test]
+    async fn test_duty_not_lost_after_end_of_sequencing() {
+        let mut operator = create_operator(
+            (31 - HANDOVER_WINDOW_SLOTS) * 12 + 5 * 2, // l1 slot before handover window, 5th l2 slot
+            true,
+            false,
+            true,
+        );
+        operator.was_synced_preconfer = true;
+        let status = operator.get_status(&get_l2_slot_info()).await.unwrap();
+        assert!(status.is_end_of_sequencing());
+        assert!(operator.end_of_sequencing_reached);
+        assert!(!operator.is_duty_lost());
+
+        let mut operator = create_operator(
+            (32 - HANDOVER_WINDOW_SLOTS) * 12 + 2, // first l1 slot of the handover window
+            true,
+            false,
+            true,
+        );
+        operator.was_synced_preconfer = true;
+        operator.end_of_sequencing_reached = true;
+        let status = operator.get_status(&get_l2_slot_info()).await.unwrap();
+        assert!(!status.is_preconfer());
+        assert!(status.is_submitter());
+        assert!(!operator.is_duty_lost());
+    }
+
     #[tokio::test]
     async fn test_get_preconfer_and_verifier_status() {
         let mut operator = create_operator(
@@ -997,6 +1146,14 @@ mod tests {
         );
     }
 
+    fn duty(is_operator: bool) -> EpochDuty {
+        if is_operator {
+            EpochDuty::Operator
+        } else {
+            EpochDuty::OtherOperator
+        }
+    }
+
     fn create_operator(
         timestamp: i64,
         current_operator: bool,
@@ -1012,8 +1169,8 @@ mod tests {
                 end_of_sequencing_block_hash: B256::ZERO,
             }),
             execution_layer: Arc::new(ExecutionLayerMock {
-                current_operator,
-                next_operator,
+                current_duty: duty(current_operator),
+                next_duty: duty(next_operator),
                 is_preconf_router_specified,
                 taiko_inbox_height: 0,
             }),
@@ -1024,6 +1181,9 @@ mod tests {
             continuing_role: false,
             simulate_not_submitting_at_the_end_of_epoch: false,
             was_synced_preconfer: false,
+            end_of_sequencing_reached: false,
+            duty_lost: false,
+            current_epoch_duty: None,
             operator_transition_slots: 1,
         }
     }
@@ -1042,8 +1202,8 @@ mod tests {
                 end_of_sequencing_block_hash: get_test_hash(),
             }),
             execution_layer: Arc::new(ExecutionLayerMock {
-                current_operator,
-                next_operator,
+                current_duty: duty(current_operator),
+                next_duty: duty(next_operator),
                 is_preconf_router_specified,
                 taiko_inbox_height: 0,
             }),
@@ -1054,6 +1214,9 @@ mod tests {
             continuing_role: false,
             simulate_not_submitting_at_the_end_of_epoch: false,
             was_synced_preconfer: false,
+            end_of_sequencing_reached: false,
+            duty_lost: false,
+            current_epoch_duty: None,
             cancel_counter: 0,
             operator_transition_slots: 1,
         }
@@ -1073,8 +1236,8 @@ mod tests {
                 end_of_sequencing_block_hash: get_test_hash(),
             }),
             execution_layer: Arc::new(ExecutionLayerMock {
-                current_operator,
-                next_operator,
+                current_duty: duty(current_operator),
+                next_duty: duty(next_operator),
                 is_preconf_router_specified,
                 taiko_inbox_height: 0,
             }),
@@ -1085,6 +1248,9 @@ mod tests {
             continuing_role: false,
             simulate_not_submitting_at_the_end_of_epoch: false,
             was_synced_preconfer: false,
+            end_of_sequencing_reached: false,
+            duty_lost: false,
+            current_epoch_duty: None,
             cancel_counter: 0,
             operator_transition_slots: 1,
         }
@@ -1100,8 +1266,8 @@ mod tests {
                 end_of_sequencing_block_hash: B256::ZERO,
             }),
             execution_layer: Arc::new(ExecutionLayerMock {
-                current_operator: true,
-                next_operator: true,
+                current_duty: EpochDuty::Operator,
+                next_duty: EpochDuty::Operator,
                 is_preconf_router_specified: true,
                 taiko_inbox_height: 1000,
             }),
@@ -1112,6 +1278,9 @@ mod tests {
             continuing_role: false,
             simulate_not_submitting_at_the_end_of_epoch: false,
             was_synced_preconfer: false,
+            end_of_sequencing_reached: false,
+            duty_lost: false,
+            current_epoch_duty: None,
             operator_transition_slots: 1,
         }
     }
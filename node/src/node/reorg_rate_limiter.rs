@@ -0,0 +1,153 @@
+use crate::metrics::Metrics;
+use std::{collections::VecDeque, sync::Arc};
+use tokio::time::{Duration, Instant};
+use tracing::{error, info};
+
+/// Pauses batch proposing while the L2 chain keeps reorging, proposing on an unstable chain
+/// would only churn batches. The reorgs are the ones observed by the chain monitor and not
+/// caused by the node's own reanchoring. Proposing resumes once the reorgs within the window are back
+/// under the limit.
+pub struct ReorgRateLimiter {
+    // zero disables the limit
+    max_reorgs: u64,
+    window: Duration,
+    reorgs: VecDeque<Instant>,
+    paused: bool,
+    metrics: Arc<Metrics>,
+}
+
+impl ReorgRateLimiter {
+    pub fn new(max_reorgs: u64, window: Duration, metrics: Arc<Metrics>) -> Self {
+        Self {
+            max_reorgs,
+            window,
+            reorgs: VecDeque::new(),
+            paused: false,
+            metrics,
+        }
+    }
+
+    pub fn record_reorg(&mut self) {
+        self.record_reorg_at(Instant::now());
+    }
+
+    /// Returns false while batch proposing is paused because of too frequent reorgs.
+    pub fn allows_proposing(&mut self) -> bool {
+        self.allows_proposing_at(Instant::now())
+    }
+
+    fn record_reorg_at(&mut self, now: Instant) {
+        if self.max_reorgs == 0 {
+            return;
+        }
+        self.reorgs.push_back(now);
+        self.forget_reorgs_before(now);
+        if !self.paused && self.reorgs.len() as u64 > self.max_reorgs {
+            error!(
+                "⛔ {} L2 reorgs within {}s, pausing batch proposing until the chain is stable",
+                self.reorgs.len(),
+                self.window.as_secs()
+            );
+            self.paused = true;
+            self.metrics.set_proposing_paused_by_reorg_rate(true);
+        }
+    }
+
+    fn allows_proposing_at(&mut self, now: Instant) -> bool {
+        if !self.paused {
+            return true;
+        }
+        self.forget_reorgs_before(now);
+        if self.reorgs.len() as u64 > self.max_reorgs {
+            return false;
+        }
+        info!(
+            "✅ {} L2 reorgs within {}s, resuming batch proposing",
+            self.reorgs.len(),
+            self.window.as_secs()
+        );
+        self.paused = false;
+        self.metrics.set_proposing_paused_by_reorg_rate(false);
+        true
+    }
+
+    fn forget_reorgs_before(&mut self, now: Instant) {
+        while let Some(reorg) = self.reorgs.front()
+            && now.duration_since(*reorg) >= self.window
+        {
+            self.reorgs.pop_front();
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const WINDOW: Duration = Duration::from_secs(60);
+
+    fn limiter(max_reorgs: u64) -> ReorgRateLimiter {
+        ReorgRateLimiter::new(max_reorgs, WINDOW, Arc::new(Metrics::new()))
+    }
+
+    #[test]
+    fn test_rapid_reorgs_pause_proposing() {
+        let mut limiter = limiter(3);
+        let start = Instant::now();
+
+        for second in 0..3 {
+            limiter.record_reorg_at(start + Duration::from_secs(second));
+            assert!(limiter.allows_proposing_at(start + Duration::from_secs(second)));
+        }
+
+        // the fourth reorg within the window exceeds the limit
+        let now = start + Duration::from_secs(3);
+        limiter.record_reorg_at(now);
+        assert!(!limiter.allows_proposing_at(now));
+        assert!(
+            limiter
+                .metrics
+                .gather()
+                .contains("proposing_paused_by_reorg_rate 1")
+        );
+
+        // still too many reorgs in the window
+        assert!(!limiter.allows_proposing_at(start + WINDOW - Duration::from_secs(1)));
+    }
+
+    #[test]
+    fn test_proposing_resumes_when_reorg_rate_subsides() {
+        let mut limiter = limiter(2);
+        let start = Instant::now();
+        for second in 0..4 {
+            limiter.record_reorg_at(start + Duration::from_secs(second));
+        }
+        assert!(!limiter.allows_proposing_at(start + Duration::from_secs(4)));
+
+        // proposing resumes once the first two reorgs have left the window
+        assert!(!limiter.allows_proposing_at(start + WINDOW));
+        assert!(limiter.allows_proposing_at(start + WINDOW + Duration::from_secs(1)));
+        assert!(
+            limiter
+                .metrics
+                .gather()
+                .contains("proposing_paused_by_reorg_rate 0")
+        );
+
+        // a new burst pauses proposing again
+        let now = start + WINDOW + Duration::from_secs(2);
+        limiter.record_reorg_at(now);
+        limiter.record_reorg_at(now);
+        assert!(!limiter.allows_proposing_at(now));
+    }
+
+    #[test]
+    fn test_reorg_rate_limit_disabled() {
+        let mut limiter = limiter(0);
+        let start = Instant::now();
+        for _ in 0..10 {
+            limiter.record_reorg_at(start);
+        }
+        assert!(limiter.allows_proposing_at(start));
+    }
+}
@@ -0,0 +1,190 @@
+use crate::metrics::Metrics;
+use alloy::primitives::B256;
+use std::{fmt, sync::Arc, time::Duration};
+use tracing::{error, info};
+
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum ReorgResult {
+    Success,
+    Failed,
+    // succeeded after a failed reorg to the same parent block
+    Retried,
+}
+
+impl ReorgResult {
+    fn as_str(&self) -> &'static str {
+        match self {
+            ReorgResult::Success => "success",
+            ReorgResult::Failed => "failed",
+            ReorgResult::Retried => "retried",
+        }
+    }
+}
+
+/// L2 reorg done by the node, the heads are filled in as the reorg progresses
+#[derive(Clone, Debug)]
+pub struct ReorgEvent {
+    pub parent_block_id: u64,
+    pub reason: String,
+    pub from_head: Option<(u64, B256)>,
+    pub to_head: Option<(u64, B256)>,
+    pub depth: u64,
+}
+
+impl ReorgEvent {
+    pub fn new(parent_block_id: u64, reason: &str) -> Self {
+        Self {
+            parent_block_id,
+            reason: reason.to_string(),
+            from_head: None,
+            to_head: None,
+            depth: 0,
+        }
+    }
+}
+
+fn format_head(head: &Option<(u64, B256)>) -> String {
+    match head {
+        Some((number, hash)) => format!("{number} {hash}"),
+        None => "unknown".to_string(),
+    }
+}
+
+impl fmt::Display for ReorgEvent {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "parent_block_id={} from_head={} to_head={} depth={} reason=\"{}\"",
+            self.parent_block_id,
+            format_head(&self.from_head),
+            format_head(&self.to_head),
+            self.depth,
+            self.reason
+        )
+    }
+}
+
+/// Records the result of every reorg in the metrics and logs
+pub struct ReorgReporter {
+    last_failed_parent_block_id: Option<u64>,
+    metrics: Arc<Metrics>,
+}
+
+impl ReorgReporter {
+    pub fn new(metrics: Arc<Metrics>) -> Self {
+        Self {
+            last_failed_parent_block_id: None,
+            metrics,
+        }
+    }
+
+    pub fn report(
+        &mut self,
+        event: &ReorgEvent,
+        succeeded: bool,
+        duration: Duration,
+    ) -> ReorgResult {
+        let result = if !succeeded {
+            ReorgResult::Failed
+        } else if self.last_failed_parent_block_id == Some(event.parent_block_id) {
+            ReorgResult::Retried
+        } else {
+            ReorgResult::Success
+        };
+        self.last_failed_parent_block_id = if succeeded {
+            None
+        } else {
+            Some(event.parent_block_id)
+        };
+
+        self.metrics.inc_reorgs(result.as_str());
+        if result == ReorgResult::Failed {
+            error!(
+                "⛓️‍💥 Reorg failed: {} duration_ms={}",
+                event,
+                duration.as_millis()
+            );
+        } else {
+            self.metrics.observe_reorg_depth(event.depth);
+            info!(
+                "⛓️‍💥 Reorg done: result={} {} duration_ms={}",
+                result.as_str(),
+                event,
+                duration.as_millis()
+            );
+        }
+        result
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn event(parent_block_id: u64, depth: u64) -> ReorgEvent {
+        let mut event = ReorgEvent::new(parent_block_id, "Transaction reverted");
+        event.from_head = Some((parent_block_id + depth, B256::repeat_byte(1)));
+        event.to_head = Some((parent_block_id + depth, B256::repeat_byte(2)));
+        event.depth = depth;
+        event
+    }
+
+    #[test]
+    fn test_reorgs_are_reported() {
+        let mut reporter = ReorgReporter::new(Arc::new(Metrics::new()));
+
+        assert_eq!(
+            reporter.report(&event(100, 3), true, Duration::ZERO),
+            ReorgResult::Success
+        );
+        assert_eq!(
+            reporter.report(
+                &ReorgEvent::new(200, "OldestForcedInclusionDue"),
+                false,
+                Duration::ZERO
+            ),
+            ReorgResult::Failed
+        );
+        assert_eq!(
+            reporter.report(&event(200, 40), true, Duration::ZERO),
+            ReorgResult::Retried
+        );
+        // a reorg to another parent after a failure is not a retry
+        reporter.report(
+            &ReorgEvent::new(300, "Verification failed"),
+            false,
+            Duration::ZERO,
+        );
+        assert_eq!(
+            reporter.report(&event(301, 1), true, Duration::ZERO),
+            ReorgResult::Success
+        );
+
+        let output = reporter.metrics.gather();
+        assert!(output.contains("reorgs_total{result=\"success\"} 2"));
+        assert!(output.contains("reorgs_total{result=\"failed\"} 2"));
+        assert!(output.contains("reorgs_total{result=\"retried\"} 1"));
+        // failed reorgs have no depth
+        assert!(output.contains("reorg_depth_count 3"));
+        assert!(output.contains("reorg_depth_sum 44"));
+        assert!(output.contains("reorg_depth_bucket{le=\"2\"} 1"));
+        assert!(output.contains("reorg_depth_bucket{le=\"4\"} 2"));
+        assert!(output.contains("reorg_depth_bucket{le=\"64\"} 3"));
+    }
+
+    #[test]
+    fn test_reorg_event_log_fields() {
+        assert_eq!(
+            event(100, 3).to_string(),
+            format!(
+                "parent_block_id=100 from_head=103 {} to_head=103 {} depth=3 reason=\"Transaction reverted\"",
+                B256::repeat_byte(1),
+                B256::repeat_byte(2)
+            )
+        );
+        assert_eq!(
+            ReorgEvent::new(100, "Verification failed").to_string(),
+            "parent_block_id=100 from_head=unknown to_head=unknown depth=0 reason=\"Verification failed\""
+        );
+    }
+}
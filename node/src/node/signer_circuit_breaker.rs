@@ -0,0 +1,302 @@
+use crate::{metrics::Metrics, shared::signer::SignerHealthCheck};
+use std::sync::Arc;
+use tokio::time::{Duration, Instant};
+use tracing::{error, info, warn};
+
+/// Stops batch proposing while the signers keep failing. The signers are checked before
+/// every submission, after `failure_threshold` consecutive failed checks the checks are backed
+/// off with the backoff doubled on every further failure. A cached check result is counted once.
+pub struct SignerCircuitBreaker {
+    signer_health: Arc<SignerHealthCheck>,
+    // zero disables the breaker
+    failure_threshold: u64,
+    backoff: Duration,
+    max_backoff: Duration,
+    consecutive_failures: u64,
+    open_until: Option<Instant>,
+    // time of the last counted signer check
+    last_checked_at: Option<std::time::Instant>,
+    metrics: Arc<Metrics>,
+}
+
+impl SignerCircuitBreaker {
+    pub fn new(
+        signer_health: Arc<SignerHealthCheck>,
+        failure_threshold: u64,
+        backoff: Duration,
+        max_backoff: Duration,
+        metrics: Arc<Metrics>,
+    ) -> Self {
+        Self {
+            signer_health,
+            failure_threshold,
+            backoff,
+            max_backoff: max_backoff.max(backoff),
+            consecutive_failures: 0,
+            open_until: None,
+            last_checked_at: None,
+            metrics,
+        }
+    }
+
+    /// Returns false when the batch must not be submitted because of failing signers.
+    pub async fn allows_proposing(&mut self) -> bool {
+        self.allows_proposing_at(Instant::now()).await
+    }
+
+    async fn allows_proposing_at(&mut self, now: Instant) -> bool {
+        if self.failure_threshold == 0 {
+            return true;
+        }
+        if self.is_open_at(now) {
+            return false;
+        }
+        let (checked_at, result) = self.signer_health.check_with_time().await;
+        if self.last_checked_at == Some(checked_at) {
+            return result.is_ok();
+        }
+        self.last_checked_at = Some(checked_at);
+        match result {
+            Ok(()) => {
+                self.record_success();
+                true
+            }
+            Err(err) => {
+                self.record_failure_at(now, &err);
+                false
+            }
+        }
+    }
+
+    fn is_open_at(&self, now: Instant) -> bool {
+        self.open_until.is_some_and(|open_until| now < open_until)
+    }
+
+    fn record_failure_at(&mut self, now: Instant, err: &str) {
+        self.consecutive_failures += 1;
+        self.metrics.inc_signer_failures();
+        if self.consecutive_failures < self.failure_threshold {
+            warn!(
+                "Signer failure {} of {} before backing off proposing: {}",
+                self.consecutive_failures, self.failure_threshold, err
+            );
+            return;
+        }
+
+        let backoff = self.current_backoff();
+        error!(
+            "⛔ Signer failed {} times in a row, backing off batch proposing for {}s: {}",
+            self.consecutive_failures,
+            backoff.as_secs(),
+            err
+        );
+        self.open_until = Some(now + backoff);
+        self.metrics.set_proposing_paused_by_signer_failures(true);
+    }
+
+    fn record_success(&mut self) {
+        if self.consecutive_failures >= self.failure_threshold {
+            info!("✅ Signer is available again, resuming batch proposing");
+        }
+        self.consecutive_failures = 0;
+        self.open_until = None;
+        self.metrics.set_proposing_paused_by_signer_failures(false);
+    }
+
+    fn current_backoff(&self) -> Duration {
+        let exponent = u32::try_from(self.consecutive_failures - self.failure_threshold)
+            .unwrap_or(u32::MAX)
+            .min(16);
+        self.backoff
+            .saturating_mul(2u32.pow(exponent))
+            .min(self.max_backoff)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::shared::{
+        signer::Signer, signer_fallback::SignerFallback, test_utils::rpc_result,
+        web3signer::Web3Signer,
+    };
+    use alloy::signers::local::PrivateKeySigner;
+    use std::str::FromStr;
+
+    const TEST_PRIVATE_KEY: &str =
+        "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80";
+    const BACKOFF: Duration = Duration::from_secs(12);
+    const MAX_BACKOFF: Duration = Duration::from_secs(40);
+
+    fn breaker(private_key: &str, failure_threshold: u64) -> SignerCircuitBreaker {
+        breaker_with_cache(private_key, failure_threshold, Duration::ZERO)
+    }
+
+    fn breaker_with_cache(
+        private_key: &str,
+        failure_threshold: u64,
+        cache_duration: Duration,
+    ) -> SignerCircuitBreaker {
+        SignerCircuitBreaker::new(
+            Arc::new(SignerHealthCheck::new(
+                vec![("L1", Arc::new(Signer::PrivateKey(private_key.to_string())))],
+                cache_duration,
+            )),
+            failure_threshold,
+            BACKOFF,
+            MAX_BACKOFF,
+            Arc::new(Metrics::new()),
+        )
+    }
+
+    #[tokio::test]
+    async fn test_breaker_trips_on_repeated_signer_failures() {
+        let mut breaker = breaker("invalid", 3);
+        let start = Instant::now();
+
+        for _ in 0..3 {
+            assert!(!breaker.allows_proposing_at(start).await);
+        }
+        assert!(breaker.is_open_at(start + BACKOFF - Duration::from_secs(1)));
+        assert!(
+            breaker
+                .metrics
+                .gather()
+                .contains("proposing_paused_by_signer_failures 1")
+        );
+
+        // the signer is not checked while backing off
+        assert!(!breaker.allows_proposing_at(start).await);
+        assert_eq!(breaker.consecutive_failures, 3);
+
+        // every further failure doubles the backoff up to the max
+        let now = start + BACKOFF;
+        assert!(!breaker.allows_proposing_at(now).await);
+        assert_eq!(breaker.open_until, Some(now + BACKOFF * 2));
+        let now = now + BACKOFF * 2;
+        assert!(!breaker.allows_proposing_at(now).await);
+        assert_eq!(breaker.open_until, Some(now + MAX_BACKOFF));
+        assert!(breaker.metrics.gather().contains("signer_failures 5"));
+    }
+
+    #[tokio::test]
+    async fn test_breaker_is_reset_on_recovery() {
+        let mut breaker = breaker(TEST_PRIVATE_KEY, 2);
+        let start = Instant::now();
+        breaker.record_failure_at(start, "web3signer unavailable");
+        breaker.record_failure_at(start, "web3signer unavailable");
+        assert!(breaker.is_open_at(start));
+        assert!(!breaker.allows_proposing_at(start).await);
+
+        assert!(breaker.allows_proposing_at(start + BACKOFF).await);
+        assert_eq!(breaker.consecutive_failures, 0);
+        assert!(!breaker.is_open_at(start + BACKOFF));
+        assert!(
+            breaker
+                .metrics
+                .gather()
+                .contains("proposing_paused_by_signer_failures 0")
+        );
+
+        // the backoff starts from the beginning after the recovery
+        breaker.record_failure_at(start + BACKOFF, "web3signer unavailable");
+        assert!(!breaker.is_open_at(start + BACKOFF));
+        breaker.record_failure_at(start + BACKOFF, "web3signer unavailable");
+        assert_eq!(breaker.open_until, Some(start + BACKOFF * 2));
+    }
+
+    #[tokio::test]
+    async fn test_cached_signer_failure_is_counted_once() {
+        let mut breaker = breaker_with_cache("invalid", 3, Duration::from_secs(10));
+        let start = Instant::now();
+
+        // the submissions within the cache duration read the same failed check
+        for _ in 0..5 {
+            assert!(!breaker.allows_proposing_at(start).await);
+        }
+        assert_eq!(breaker.consecutive_failures, 1);
+        assert!(!breaker.is_open_at(start));
+        assert!(breaker.metrics.gather().contains("signer_failures 1"));
+    }
+
+    // web3signer holding the key of TEST_PRIVATE_KEY which becomes unreachable
+    async fn unreachable_web3signer(
+        server: &mut mockito::ServerGuard,
+        with_fallback: bool,
+    ) -> Signer {
+        let address = PrivateKeySigner::from_str(TEST_PRIVATE_KEY)
+            .unwrap()
+            .address();
+        let accounts = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_accounts".to_string()))
+            .with_body_from_request(rpc_result(serde_json::json!([address.to_string()])))
+            .create_async()
+            .await;
+        let fallback = with_fallback.then(|| {
+            SignerFallback::new(
+                "L1",
+                TEST_PRIVATE_KEY,
+                address,
+                Duration::from_secs(60),
+                Arc::new(Metrics::new()),
+            )
+            .unwrap()
+        });
+        let web3signer = Web3Signer::new(
+            &server.url(),
+            Duration::from_millis(100),
+            &address.to_string(),
+            fallback,
+        )
+        .await
+        .unwrap();
+        accounts.remove_async().await;
+        server
+            .mock("POST", "/")
+            .with_status(503)
+            .create_async()
+            .await;
+        Signer::Web3signer(Arc::new(web3signer))
+    }
+
+    fn breaker_for(signer: Signer) -> SignerCircuitBreaker {
+        SignerCircuitBreaker::new(
+            Arc::new(SignerHealthCheck::new(
+                vec![("L1", Arc::new(signer))],
+                Duration::ZERO,
+            )),
+            3,
+            BACKOFF,
+            MAX_BACKOFF,
+            Arc::new(Metrics::new()),
+        )
+    }
+
+    #[tokio::test]
+    async fn test_breaker_stays_closed_while_fallback_signs() {
+        let mut server = mockito::Server::new_async().await;
+        let mut breaker = breaker_for(unreachable_web3signer(&mut server, true).await);
+        let start = Instant::now();
+        for _ in 0..5 {
+            assert!(breaker.allows_proposing_at(start).await);
+        }
+        assert_eq!(breaker.consecutive_failures, 0);
+
+        // without the fallback the unreachable web3signer pauses proposing
+        let mut server = mockito::Server::new_async().await;
+        let mut breaker = breaker_for(unreachable_web3signer(&mut server, false).await);
+        for _ in 0..3 {
+            assert!(!breaker.allows_proposing_at(start).await);
+        }
+        assert!(breaker.is_open_at(start));
+    }
+
+    #[tokio::test]
+    async fn test_breaker_disabled() {
+        let mut breaker = breaker("invalid", 0);
+        for _ in 0..5 {
+            assert!(breaker.allows_proposing().await);
+        }
+    }
+}
@@ -0,0 +1,238 @@
+use super::operator::Status;
+use crate::{shared::l2_slot_info::L2SlotInfo, taiko::preconf_blocks::BuildPreconfBlockResponse};
+use anyhow::Error;
+use serde::Serialize;
+use std::path::PathBuf;
+use tokio::{fs::File, io::AsyncWriteExt};
+
+pub const NO_BLOCK_NOT_PRECONFER: &str = "not preconfer";
+pub const NO_BLOCK_DRIVER_NOT_SYNCED: &str = "driver not synced";
+pub const NO_BLOCK_CYCLE_DEADLINE: &str = "cycle deadline exceeded";
+pub const NO_BLOCK_REANCHORED: &str = "reanchored";
+pub const NO_BLOCK_NOTHING_TO_PRECONFIRM: &str = "nothing to preconfirm";
+
+pub const SUBMISSION_NOT_SUBMITTER: &str = "not submitter";
+pub const SUBMISSION_TX_IN_PROGRESS: &str = "tx in progress";
+pub const SUBMISSION_CHAIN_HALTED: &str = "chain halted";
+pub const SUBMISSION_PAUSED_BY_LOW_BALANCE: &str = "paused by low balance";
+pub const SUBMISSION_PAUSED_BY_REORG_RATE: &str = "paused by reorg rate";
+pub const SUBMISSION_CYCLE_DEADLINE: &str = "cycle deadline exceeded";
+pub const SUBMISSION_NOT_VERIFIED: &str = "batches not verified";
+pub const SUBMISSION_SIGNER_UNAVAILABLE: &str = "signer unavailable";
+pub const SUBMISSION_SUBMITTED: &str = "batch submitted";
+pub const SUBMISSION_NOTHING_TO_SUBMIT: &str = "nothing to submit";
+
+/// Outcome of a single preconfirmation loop step
+#[derive(Serialize, Debug, Default)]
+pub struct SlotReport {
+    pub slot_timestamp: Option<u64>,
+    pub parent_block_id: Option<u64>,
+    pub status: Option<String>,
+    pub is_preconfer: bool,
+    pub is_submitter: bool,
+    pub block_number: Option<u64>,
+    pub forced_inclusion_block_number: Option<u64>,
+    pub no_block_reason: Option<&'static str>,
+    // txs of the preconfirmed block, the forced inclusion block is not counted
+    pub txs_included: usize,
+    pub batches: u64,
+    pub batches_ready_to_send: u64,
+    pub submission: Option<&'static str>,
+    pub error: Option<String>,
+}
+
+impl SlotReport {
+    pub fn record_status(&mut self, l2_slot_info: &L2SlotInfo, status: &Status) {
+        self.slot_timestamp = Some(l2_slot_info.slot_timestamp());
+        self.parent_block_id = Some(l2_slot_info.parent_id());
+        self.status = Some(status.to_string());
+        self.is_preconfer = status.is_preconfer();
+        self.is_submitter = status.is_submitter();
+    }
+
+    pub fn record_blocks(
+        &mut self,
+        forced_inclusion_block: Option<&BuildPreconfBlockResponse>,
+        block: Option<&BuildPreconfBlockResponse>,
+    ) {
+        self.forced_inclusion_block_number = forced_inclusion_block.map(|block| block.number);
+        self.block_number = block.map(|block| block.number);
+        if let Some(block) = block {
+            self.txs_included = block.tx_count;
+        } else if forced_inclusion_block.is_none() {
+            self.no_block_reason = Some(NO_BLOCK_NOTHING_TO_PRECONFIRM);
+        }
+    }
+
+    pub fn record_result(&mut self, result: &Result<(), Error>) {
+        self.error = result.as_ref().err().map(|err| err.to_string());
+    }
+}
+
+/// Appends a JSON line per preconfirmation loop step with its full outcome,
+/// a machine readable counterpart of the per-slot log.
+pub struct SlotReporter {
+    path: PathBuf,
+    file: File,
+}
+
+impl SlotReporter {
+    pub fn new(path: &str) -> Result<Self, Error> {
+        let file = std::fs::OpenOptions::new()
+            .create(true)
+            .append(true)
+            .open(path)
+            .map_err(|e| anyhow::anyhow!("Failed to open slot report file {}: {}", path, e))?;
+        Ok(Self {
+            path: PathBuf::from(path),
+            file: File::from_std(file),
+        })
+    }
+
+    pub async fn write(&mut self, report: &SlotReport) -> Result<(), Error> {
+        let mut line = serde_json::to_vec(report)?;
+        line.push(b'\n');
+        // tokio completes the write of a file in the background until it is flushed
+        let result = match self.file.write_all(&line).await {
+            Ok(()) => self.file.flush().await,
+            Err(e) => Err(e),
+        };
+        result.map_err(|e| {
+            anyhow::anyhow!("Failed to write slot report {}: {}", self.path.display(), e)
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use alloy::primitives::B256;
+    use serde_json::Value;
+    use tempfile::TempDir;
+
+    fn block(number: u64, tx_count: usize) -> BuildPreconfBlockResponse {
+        BuildPreconfBlockResponse {
+            number,
+            hash: B256::repeat_byte(2),
+            parent_hash: B256::repeat_byte(1),
+            tx_count,
+        }
+    }
+
+    fn slot_report(is_preconfer: bool, is_submitter: bool) -> SlotReport {
+        SlotReport {
+            slot_timestamp: Some(1000),
+            parent_block_id: Some(10),
+            status: Some("Preconf, Submit, Synced".to_string()),
+            is_preconfer,
+            is_submitter,
+            ..Default::default()
+        }
+    }
+
+    async fn write_and_read(reports: &[SlotReport]) -> Vec<Value> {
+        let dir = TempDir::new().unwrap();
+        let path = dir
+            .path()
+            .join("slot_report.jsonl")
+            .to_string_lossy()
+            .to_string();
+        let mut reporter = SlotReporter::new(&path).unwrap();
+        for report in reports {
+            reporter.write(report).await.unwrap();
+        }
+        let content = std::fs::read_to_string(&path).unwrap();
+        content
+            .lines()
+            .map(|line| serde_json::from_str(line).unwrap())
+            .collect()
+    }
+
+    #[tokio::test]
+    async fn test_slot_without_duty() {
+        let mut report = slot_report(false, false);
+        report.no_block_reason = Some(NO_BLOCK_NOT_PRECONFER);
+        report.submission = Some(SUBMISSION_NOT_SUBMITTER);
+        report.record_result(&Ok(()));
+
+        let lines = write_and_read(&[report]).await;
+        assert_eq!(lines.len(), 1);
+        assert_eq!(lines[0]["slot_timestamp"], 1000);
+        assert_eq!(lines[0]["is_preconfer"], false);
+        assert_eq!(lines[0]["block_number"], Value::Null);
+        assert_eq!(lines[0]["no_block_reason"], NO_BLOCK_NOT_PRECONFER);
+        assert_eq!(lines[0]["submission"], SUBMISSION_NOT_SUBMITTER);
+        assert_eq!(lines[0]["error"], Value::Null);
+    }
+
+    #[tokio::test]
+    async fn test_slots_with_empty_and_full_blocks() {
+        let mut empty = slot_report(true, true);
+        empty.record_blocks(None, None);
+        empty.submission = Some(SUBMISSION_NOTHING_TO_SUBMIT);
+
+        let mut full = slot_report(true, true);
+        full.record_blocks(Some(&block(11, 2)), Some(&block(12, 150)));
+        full.batches = 2;
+        full.batches_ready_to_send = 1;
+        full.submission = Some(SUBMISSION_SUBMITTED);
+
+        // reports of the following slots are appended
+        let lines = write_and_read(&[empty, full]).await;
+        assert_eq!(lines.len(), 2);
+        assert_eq!(lines[0]["block_number"], Value::Null);
+        assert_eq!(lines[0]["no_block_reason"], NO_BLOCK_NOTHING_TO_PRECONFIRM);
+        assert_eq!(lines[0]["txs_included"], 0);
+        assert_eq!(lines[0]["submission"], SUBMISSION_NOTHING_TO_SUBMIT);
+
+        assert_eq!(lines[1]["forced_inclusion_block_number"], 11);
+        assert_eq!(lines[1]["block_number"], 12);
+        assert_eq!(lines[1]["no_block_reason"], Value::Null);
+        assert_eq!(lines[1]["txs_included"], 150);
+        assert_eq!(lines[1]["batches"], 2);
+        assert_eq!(lines[1]["batches_ready_to_send"], 1);
+        assert_eq!(lines[1]["submission"], SUBMISSION_SUBMITTED);
+    }
+
+    #[tokio::test]
+    async fn test_txs_included_after_forced_inclusion() {
+        // the block after the forced inclusion is built from a new tx pool poll
+        let mut report = slot_report(true, true);
+        report.record_blocks(Some(&block(11, 3)), Some(&block(12, 40)));
+        let mut forced_inclusion_only = slot_report(true, true);
+        forced_inclusion_only.record_blocks(Some(&block(11, 3)), None);
+
+        let lines = write_and_read(&[report, forced_inclusion_only]).await;
+        assert_eq!(lines[0]["forced_inclusion_block_number"], 11);
+        assert_eq!(lines[0]["block_number"], 12);
+        assert_eq!(lines[0]["txs_included"], 40);
+
+        assert_eq!(lines[1]["forced_inclusion_block_number"], 11);
+        assert_eq!(lines[1]["block_number"], Value::Null);
+        assert_eq!(lines[1]["no_block_reason"], Value::Null);
+        assert_eq!(lines[1]["txs_included"], 0);
+    }
+
+    #[tokio::test]
+    async fn test_slot_with_error() {
+        let mut report = slot_report(true, false);
+        report.record_result(&Err(anyhow::anyhow!(
+            "Unexpected L2 head detected. Restarting node..."
+        )));
+
+        let lines = write_and_read(&[report]).await;
+        assert_eq!(lines[0]["block_number"], Value::Null);
+        assert_eq!(lines[0]["submission"], Value::Null);
+        assert_eq!(
+            lines[0]["error"],
+            "Unexpected L2 head detected. Restarting node..."
+        );
+
+        // a failure before the status is known leaves the slot fields empty
+        let mut report = SlotReport::default();
+        report.record_result(&Err(anyhow::anyhow!("Failed to get L2 slot info")));
+        let lines = write_and_read(&[report]).await;
+        assert_eq!(lines[0]["slot_timestamp"], Value::Null);
+        assert_eq!(lines[0]["error"], "Failed to get L2 slot info");
+    }
+}
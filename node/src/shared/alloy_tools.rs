@@ -163,3 +163,30 @@ pub async fn create_alloy_provider_without_wallet(url: &str) -> Result<DynProvid
         ))
     }
 }
+
+/// JSON of a block without transactions, as returned by eth_getBlockByNumber.
+#[cfg(test)]
+pub fn test_block_json(number: u64, base_fee_per_gas: u64) -> serde_json::Value {
+    let hash = |byte: &str| format!("0x{}", byte.repeat(32));
+    serde_json::json!({
+        "hash": hash("11"),
+        "parentHash": hash("22"),
+        "sha3Uncles": hash("33"),
+        "miner": format!("0x{}", "00".repeat(20)),
+        "stateRoot": hash("44"),
+        "transactionsRoot": hash("55"),
+        "receiptsRoot": hash("66"),
+        "logsBloom": format!("0x{}", "00".repeat(256)),
+        "difficulty": "0x0",
+        "number": format!("{number:#x}"),
+        "gasLimit": "0x1c9c380",
+        "gasUsed": "0x0",
+        "timestamp": "0x3e8",
+        "extraData": "0x",
+        "mixHash": hash("77"),
+        "nonce": "0x0000000000000000",
+        "baseFeePerGas": format!("{base_fee_per_gas:#x}"),
+        "uncles": [],
+        "transactions": [],
+    })
+}
@@ -179,4 +179,41 @@ mod tests {
         assert_eq!(pending_tx_lists[0].estimated_gas_used, 42000);
         assert_eq!(pending_tx_lists[0].bytes_length, 203);
     }
+
+    fn get_test_tx_list() -> Vec<Transaction> {
+        serde_json::from_str::<Vec<PreBuiltTxList>>(include_str!(
+            "../utils/tx_lists_test_response_from_geth.json"
+        ))
+        .unwrap()
+        .remove(0)
+        .tx_list
+    }
+
+    #[test]
+    fn test_encode_and_compress_is_deterministic() {
+        let tx_list = get_test_tx_list();
+        let encoded = encode_and_compress(&tx_list).unwrap();
+        assert_eq!(encoded, encode_and_compress(&tx_list.clone()).unwrap());
+
+        let decoded = uncompress_and_decode(&encoded).unwrap();
+        assert_eq!(
+            decoded
+                .iter()
+                .map(|tx| tx.inner.tx_hash())
+                .collect::<Vec<_>>(),
+            tx_list
+                .iter()
+                .map(|tx| tx.inner.tx_hash())
+                .collect::<Vec<_>>()
+        );
+    }
+
+    #[test]
+    fn test_encode_and_compress_matches_golden_file() {
+        // regenerate the golden file only on an intended change of the batch encoding
+        let golden =
+            hex::decode(include_str!("../utils/tx_lists_test_encoded_from_geth.hex").trim())
+                .unwrap();
+        assert_eq!(encode_and_compress(&get_test_tx_list()).unwrap(), golden);
+    }
 }
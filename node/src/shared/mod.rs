@@ -3,4 +3,7 @@ pub mod l2_block;
 pub mod l2_slot_info;
 pub mod l2_tx_lists;
 pub mod signer;
+pub mod signer_fallback;
+#[cfg(test)]
+pub mod test_utils;
 pub mod web3signer;
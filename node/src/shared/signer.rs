@@ -1,8 +1,158 @@
 use super::web3signer::Web3Signer;
-use std::sync::Arc;
+use alloy::{
+    primitives::B256,
+    signers::{SignerSync, local::PrivateKeySigner},
+};
+use anyhow::Error;
+use std::{
+    str::FromStr,
+    sync::Arc,
+    time::{Duration, Instant},
+};
+use tokio::sync::Mutex;
+use tracing::warn;
 
 #[derive(Debug)]
 pub enum Signer {
     Web3signer(Arc<Web3Signer>),
     PrivateKey(String),
 }
+
+impl Signer {
+    /// Checks that the signer is able to sign. The web3signer is pinged, or its fallback
+    /// signer checked while it is unreachable, the private key signer performs a test sign.
+    pub async fn check_health(&self) -> Result<(), Error> {
+        match self {
+            Signer::Web3signer(web3signer) => web3signer.check_health().await,
+            Signer::PrivateKey(private_key) => {
+                let signer = PrivateKeySigner::from_str(private_key.as_str())
+                    .map_err(|e| anyhow::anyhow!("Invalid private key: {}", e))?;
+                signer
+                    .sign_hash_sync(&B256::ZERO)
+                    .map_err(|e| anyhow::anyhow!("Failed to sign test hash: {}", e))?;
+                Ok(())
+            }
+        }
+    }
+}
+
+struct CachedSignerHealth {
+    checked_at: Instant,
+    result: Result<(), String>,
+}
+
+/// Signer health used by the readiness probe, cached to avoid signing on every probe.
+pub struct SignerHealthCheck {
+    signers: Vec<(&'static str, Arc<Signer>)>,
+    cache_duration: Duration,
+    cache: Mutex<Option<CachedSignerHealth>>,
+}
+
+impl SignerHealthCheck {
+    pub fn new(signers: Vec<(&'static str, Arc<Signer>)>, cache_duration: Duration) -> Self {
+        Self {
+            signers,
+            cache_duration,
+            cache: Mutex::new(None),
+        }
+    }
+
+    pub async fn check(&self) -> Result<(), String> {
+        self.check_with_time().await.1
+    }
+
+    /// Returns the result together with the time of the check, a cached result has the time
+    /// of the check it comes from.
+    pub async fn check_with_time(&self) -> (Instant, Result<(), String>) {
+        let mut cache = self.cache.lock().await;
+        if let Some(cached) = cache.as_ref()
+            && cached.checked_at.elapsed() < self.cache_duration
+        {
+            return (cached.checked_at, cached.result.clone());
+        }
+
+        let mut result = Ok(());
+        for (name, signer) in &self.signers {
+            if let Err(e) = signer.check_health().await {
+                warn!("Signer health check failed for {} signer: {}", name, e);
+                result = Err(format!("{name} signer unavailable: {e}"));
+                break;
+            }
+        }
+
+        let checked_at = Instant::now();
+        *cache = Some(CachedSignerHealth {
+            checked_at,
+            result: result.clone(),
+        });
+        (checked_at, result)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const TEST_PRIVATE_KEY: &str =
+        "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80";
+
+    #[tokio::test]
+    async fn test_private_key_signer_health() {
+        let signer = Signer::PrivateKey(TEST_PRIVATE_KEY.to_string());
+        assert!(signer.check_health().await.is_ok());
+
+        let signer = Signer::PrivateKey("not a private key".to_string());
+        assert!(signer.check_health().await.is_err());
+    }
+
+    #[tokio::test]
+    async fn test_signer_health_check_reports_failing_signer() {
+        let health_check = SignerHealthCheck::new(
+            vec![
+                (
+                    "L1",
+                    Arc::new(Signer::PrivateKey(TEST_PRIVATE_KEY.to_string())),
+                ),
+                ("L2", Arc::new(Signer::PrivateKey("invalid".to_string()))),
+            ],
+            Duration::from_secs(10),
+        );
+
+        let result = health_check.check().await;
+        assert!(result.unwrap_err().starts_with("L2 signer unavailable"));
+    }
+
+    #[tokio::test]
+    async fn test_signer_health_check_is_cached() {
+        let health_check = SignerHealthCheck::new(
+            vec![(
+                "L1",
+                Arc::new(Signer::PrivateKey(TEST_PRIVATE_KEY.to_string())),
+            )],
+            Duration::from_secs(10),
+        );
+
+        assert!(health_check.check().await.is_ok());
+        let checked_at = health_check.cache.lock().await.as_ref().unwrap().checked_at;
+
+        assert!(health_check.check().await.is_ok());
+        assert_eq!(
+            health_check.cache.lock().await.as_ref().unwrap().checked_at,
+            checked_at
+        );
+    }
+
+    #[tokio::test]
+    async fn test_signer_health_check_refreshes_after_cache_duration() {
+        let health_check = SignerHealthCheck::new(
+            vec![("L1", Arc::new(Signer::PrivateKey("invalid".to_string())))],
+            Duration::ZERO,
+        );
+
+        assert!(health_check.check().await.is_err());
+        let checked_at = health_check.cache.lock().await.as_ref().unwrap().checked_at;
+
+        assert!(health_check.check().await.is_err());
+        assert!(health_check.cache.lock().await.as_ref().unwrap().checked_at > checked_at);
+    }
+}
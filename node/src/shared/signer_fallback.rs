@@ -0,0 +1,130 @@
+use crate::metrics::Metrics;
+use alloy::{
+    consensus::transaction::SignableTransaction,
+    network::TxSignerSync,
+    primitives::{Address, B256, Signature as EcdsaSignature},
+    signers::{Result as SignerResult, SignerSync, local::PrivateKeySigner},
+};
+use anyhow::Error;
+use std::{
+    fmt,
+    str::FromStr,
+    sync::Arc,
+    time::{Duration, Instant},
+};
+use tokio::sync::Mutex;
+use tracing::{info, warn};
+
+struct FallbackState {
+    active: bool,
+    last_remote_attempt: Instant,
+}
+
+/// Local signer taking over from the web3signer while it is unavailable. The web3signer
+/// is tried again every `retry_remote_interval`, and used again as soon as it signs.
+pub struct SignerFallback {
+    // signer label of the metrics, L1 or L2
+    name: &'static str,
+    local_signer: PrivateKeySigner,
+    retry_remote_interval: Duration,
+    state: Mutex<FallbackState>,
+    metrics: Arc<Metrics>,
+}
+
+impl fmt::Debug for SignerFallback {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_struct("SignerFallback")
+            .field("name", &self.name)
+            .field("address", &self.local_signer.address())
+            .field("retry_remote_interval", &self.retry_remote_interval)
+            .finish()
+    }
+}
+
+impl SignerFallback {
+    pub fn new(
+        name: &'static str,
+        private_key: &str,
+        signer_address: Address,
+        retry_remote_interval: Duration,
+        metrics: Arc<Metrics>,
+    ) -> Result<Self, Error> {
+        let local_signer = PrivateKeySigner::from_str(private_key)
+            .map_err(|e| anyhow::anyhow!("Invalid fallback signer private key: {}", e))?;
+        if local_signer.address() != signer_address {
+            return Err(anyhow::anyhow!(
+                "Fallback signer address {} does not match the signer address {}",
+                local_signer.address(),
+                signer_address
+            ));
+        }
+        metrics.set_signer_fallback_active(name, false);
+        Ok(Self {
+            name,
+            local_signer,
+            retry_remote_interval,
+            state: Mutex::new(FallbackState {
+                active: false,
+                last_remote_attempt: Instant::now(),
+            }),
+            metrics,
+        })
+    }
+
+    /// Whether the next tx is sent to the web3signer, while the fallback is active
+    /// only once per retry interval.
+    pub async fn use_remote(&self) -> bool {
+        let mut state = self.state.lock().await;
+        if !state.active {
+            return true;
+        }
+        if state.last_remote_attempt.elapsed() < self.retry_remote_interval {
+            return false;
+        }
+        state.last_remote_attempt = Instant::now();
+        true
+    }
+
+    pub async fn on_remote_success(&self) {
+        let mut state = self.state.lock().await;
+        if state.active {
+            info!(
+                "✅ {} web3signer recovered, switching back from the fallback signer",
+                self.name
+            );
+            state.active = false;
+            self.metrics.set_signer_fallback_active(self.name, false);
+        }
+    }
+
+    pub async fn on_remote_failure(&self, err: &Error) {
+        let mut state = self.state.lock().await;
+        if !state.active {
+            warn!(
+                "⚠️ {} web3signer unavailable, signing with the local fallback signer {}: {}",
+                self.name,
+                self.local_signer.address(),
+                err
+            );
+            state.active = true;
+            self.metrics.set_signer_fallback_active(self.name, true);
+        }
+        state.last_remote_attempt = Instant::now();
+    }
+
+    /// Checks that the local signer is able to sign with a test sign.
+    pub fn check_health(&self) -> Result<(), Error> {
+        self.local_signer
+            .sign_hash_sync(&B256::ZERO)
+            .map_err(|e| anyhow::anyhow!("Fallback signer failed to sign test hash: {}", e))?;
+        Ok(())
+    }
+
+    pub fn sign_transaction(
+        &self,
+        tx: &mut dyn SignableTransaction<EcdsaSignature>,
+    ) -> SignerResult<EcdsaSignature> {
+        self.metrics.inc_signer_fallback_signatures(self.name);
+        self.local_signer.sign_transaction_sync(tx)
+    }
+}
@@ -0,0 +1,22 @@
+use serde_json::Value;
+
+/// Mockito body of a JSON-RPC response, `response` holds the result or the error.
+/// The response echoes the id of the request.
+pub fn rpc_response(response: Value) -> impl Fn(&mockito::Request) -> Vec<u8> + Send + Sync {
+    move |request| {
+        let id = request
+            .body()
+            .ok()
+            .and_then(|body| serde_json::from_slice::<Value>(body).ok())
+            .and_then(|body| body.get("id").cloned())
+            .unwrap_or(Value::Null);
+        let mut response = response.clone();
+        response["jsonrpc"] = "2.0".into();
+        response["id"] = id;
+        response.to_string().into_bytes()
+    }
+}
+
+pub fn rpc_result(result: Value) -> impl Fn(&mockito::Request) -> Vec<u8> + Send + Sync {
+    rpc_response(serde_json::json!({ "result": result }))
+}
@@ -1,3 +1,4 @@
+use super::signer_fallback::SignerFallback;
 use crate::utils::rpc_client::JSONRPCClient;
 use alloy::{
     consensus::{
@@ -11,6 +12,9 @@ use alloy::{
 use anyhow::Error;
 use async_trait::async_trait;
 use hex;
+use jsonrpsee::{
+    core::client::Error as JsonRpcError, http_client::transport::Error as TransportError,
+};
 use serde_json::{Map, Value};
 use std::sync::Arc;
 use std::time::Duration;
@@ -19,6 +23,8 @@ use tracing::{debug, error, info};
 #[derive(Debug)]
 pub struct Web3Signer {
     client: JSONRPCClient,
+    signer_address: String,
+    fallback: Option<SignerFallback>,
 }
 
 impl Web3Signer {
@@ -26,6 +32,7 @@ impl Web3Signer {
         rpc_url: &str,
         timeout: Duration,
         signer_address: &str,
+        fallback: Option<SignerFallback>,
     ) -> Result<Self, Error> {
         info!("Web3Signer: Creating new Web3Signer with URL: {}", rpc_url);
         let client = JSONRPCClient::new_with_timeout(rpc_url, timeout)?;
@@ -35,7 +42,51 @@ impl Web3Signer {
                 signer_address
             ));
         }
-        Ok(Self { client })
+        Ok(Self {
+            client,
+            signer_address: signer_address.to_string(),
+            fallback,
+        })
+    }
+
+    /// Pings the remote signer and checks that the signer key is still available.
+    /// Called by the health checks, so a single request is made without retries.
+    async fn check_signer_key_available(&self) -> Result<(), Error> {
+        let response = self
+            .client
+            .call_method("eth_accounts", vec![])
+            .await
+            .map_err(|e| {
+                let error_msg = format!("Web3Signer: Failed to get available accounts: {e}");
+                e.context(error_msg)
+            })?;
+        if !Self::contains_signer_key(&response, &self.signer_address)? {
+            return Err(anyhow::anyhow!(
+                "Web3Signer: Signer key is not available for address {}",
+                self.signer_address
+            ));
+        }
+        Ok(())
+    }
+
+    /// Checks that the signer is able to sign. While the web3signer cannot be reached the
+    /// fallback signer signs, so it is healthy as long as the fallback signer can sign.
+    pub async fn check_health(&self) -> Result<(), Error> {
+        let err = match self.check_signer_key_available().await {
+            Ok(()) => return Ok(()),
+            Err(err) => err,
+        };
+        match &self.fallback {
+            Some(fallback) if is_web3signer_unreachable(&err) => {
+                fallback.check_health()?;
+                debug!(
+                    "Web3Signer unavailable, the fallback signer is signing: {}",
+                    err
+                );
+                Ok(())
+            }
+            _ => Err(err),
+        }
     }
 
     async fn is_signer_key_available(
@@ -45,11 +96,18 @@ impl Web3Signer {
         let response = client
             .call_method_with_retry("eth_accounts", vec![])
             .await
-            .map_err(|e| anyhow::anyhow!("Web3Signer: Failed to get available accounts: {}", e))?;
+            .map_err(|e| {
+                let error_msg = format!("Web3Signer: Failed to get available accounts: {e}");
+                e.context(error_msg)
+            })?;
+        Self::contains_signer_key(&response, signer_address)
+    }
+
+    fn contains_signer_key(response: &Value, signer_address: &str) -> Result<bool, Error> {
         let accounts = response.as_array().ok_or(anyhow::anyhow!(
             "Web3Signer: Failed to decode available accounts"
         ))?;
-        info!("Web3Signer: Available accounts: {:?}", accounts);
+        debug!("Web3Signer: Available accounts: {:?}", accounts);
         Ok(accounts
             .iter()
             .map(|account| account.as_str().unwrap_or("").to_lowercase())
@@ -123,7 +181,10 @@ impl Web3Signer {
             .client
             .call_method_with_retry("eth_signTransaction", vec![Value::Object(tx_obj)])
             .await
-            .map_err(|e| anyhow::anyhow!("Web3Signer: Failed to sign transaction: {}", e))?;
+            .map_err(|e| {
+                let error_msg = format!("Web3Signer: Failed to sign transaction: {e}");
+                e.context(error_msg)
+            })?;
 
         if let Some(signature) = response.as_str().map(|s| s.strip_prefix("0x").unwrap_or(s)) {
             return hex::decode(signature)
@@ -162,9 +223,22 @@ impl TxSigner<EcdsaSignature> for Web3TxSigner {
         &self,
         tx: &mut dyn SignableTransaction<EcdsaSignature>,
     ) -> SignerResult<EcdsaSignature> {
+        let fallback = self.inner.fallback.as_ref();
+        if let Some(fallback) = fallback
+            && !fallback.use_remote().await
+        {
+            return fallback.sign_transaction(tx);
+        }
+
         let web3signer_signed_tx = match self.inner.sign_transaction(tx, self.address).await {
             Ok(web3signer_signed_tx) => web3signer_signed_tx,
             Err(err) => {
+                if let Some(fallback) = fallback
+                    && is_web3signer_unreachable(&err)
+                {
+                    fallback.on_remote_failure(&err).await;
+                    return fallback.sign_transaction(tx);
+                }
                 return Err(SignerError::Other(err.into()));
             }
         };
@@ -183,10 +257,29 @@ impl TxSigner<EcdsaSignature> for Web3TxSigner {
             ));
         }
 
+        if let Some(fallback) = fallback {
+            fallback.on_remote_success().await;
+        }
         Ok(*tx_envelope.signature())
     }
 }
 
+/// Only a web3signer which cannot be reached or fails with a server error is replaced by the
+/// fallback signer. A refusal to sign, e.g. by a signing policy or the slashing protection,
+/// comes as an error response or a client error status and is returned to the caller.
+fn is_web3signer_unreachable(err: &Error) -> bool {
+    match err.downcast_ref::<JsonRpcError>() {
+        Some(JsonRpcError::RequestTimeout) => true,
+        Some(JsonRpcError::Transport(err)) => match err.downcast_ref::<TransportError>() {
+            Some(TransportError::Rejected { status_code }) => *status_code >= 500,
+            // the request did not get through, e.g. the connection was refused
+            Some(TransportError::Http(_)) => true,
+            _ => false,
+        },
+        _ => false,
+    }
+}
+
 async fn check_signer_correctness(tx_envelope: &TxEnvelope, from: Address) -> bool {
     let signer = match tx_envelope.recover_signer() {
         Ok(signer) => signer,
@@ -208,6 +301,249 @@ async fn check_signer_correctness(tx_envelope: &TxEnvelope, from: Address) -> bo
 #[cfg(test)]
 mod tests {
     use super::*;
+    use crate::metrics::Metrics;
+    use crate::shared::test_utils::{rpc_response, rpc_result};
+    use alloy::{
+        consensus::TxEip1559,
+        network::TxSignerSync,
+        primitives::{TxKind, U256},
+        signers::local::PrivateKeySigner,
+    };
+    use std::str::FromStr;
+
+    const TEST_PRIVATE_KEY: &str =
+        "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318";
+
+    fn test_tx(nonce: u64) -> TxEip1559 {
+        TxEip1559 {
+            chain_id: 1,
+            nonce,
+            gas_limit: 21_000,
+            max_fee_per_gas: 2_000_000_000,
+            max_priority_fee_per_gas: 1_000_000_000,
+            to: TxKind::Call(Address::repeat_byte(2)),
+            value: U256::from(1),
+            ..Default::default()
+        }
+    }
+
+    async fn web3signer_with_fallback(
+        server: &mut mockito::ServerGuard,
+        retry_remote_interval: Duration,
+        metrics: Arc<Metrics>,
+    ) -> Web3TxSigner {
+        let address = PrivateKeySigner::from_str(TEST_PRIVATE_KEY)
+            .unwrap()
+            .address();
+        server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_accounts".to_string()))
+            .with_body_from_request(rpc_result(serde_json::json!([address.to_string()])))
+            .create_async()
+            .await;
+        let fallback = SignerFallback::new(
+            "L1",
+            TEST_PRIVATE_KEY,
+            address,
+            retry_remote_interval,
+            metrics,
+        )
+        .unwrap();
+        let web3signer = Web3Signer::new(
+            &server.url(),
+            Duration::from_millis(100),
+            &address.to_string(),
+            Some(fallback),
+        )
+        .await
+        .unwrap();
+        Web3TxSigner::new(Arc::new(web3signer), address).unwrap()
+    }
+
+    async fn mock_unavailable_signing(server: &mut mockito::ServerGuard) -> mockito::Mock {
+        server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_signTransaction".to_string()))
+            .with_status(503)
+            .create_async()
+            .await
+    }
+
+    // the remote signer holding the same key
+    async fn mock_signing(
+        server: &mut mockito::ServerGuard,
+        tx: &TxEip1559,
+        hits: usize,
+    ) -> mockito::Mock {
+        let mut tx = tx.clone();
+        let signature = PrivateKeySigner::from_str(TEST_PRIVATE_KEY)
+            .unwrap()
+            .sign_transaction_sync(&mut tx)
+            .unwrap();
+        let tx_envelope = TxEnvelope::from(tx.into_signed(signature));
+        let signed_tx = format!("0x{}", hex::encode(alloy_rlp::encode(&tx_envelope)));
+        server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_signTransaction".to_string()))
+            .with_body_from_request(rpc_result(Value::String(signed_tx)))
+            .expect(hits)
+            .create_async()
+            .await
+    }
+
+    fn assert_signed_by_key(tx: &TxEip1559, signature: &EcdsaSignature) {
+        let expected = PrivateKeySigner::from_str(TEST_PRIVATE_KEY)
+            .unwrap()
+            .address();
+        assert_eq!(
+            signature
+                .recover_address_from_prehash(&tx.signature_hash())
+                .unwrap(),
+            expected
+        );
+    }
+
+    #[tokio::test]
+    async fn test_fallback_to_local_signer_and_recovery() {
+        let mut server = mockito::Server::new_async().await;
+        let metrics = Arc::new(Metrics::new());
+        let signer = web3signer_with_fallback(&mut server, Duration::ZERO, metrics.clone()).await;
+
+        // the web3signer is down, the local key signs
+        let unavailable = mock_unavailable_signing(&mut server).await;
+        let mut tx = test_tx(0);
+        let signature = signer.sign_transaction(&mut tx).await.unwrap();
+        assert_signed_by_key(&tx, &signature);
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_active{signer=\"L1\"} 1")
+        );
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_signatures{signer=\"L1\"} 1")
+        );
+
+        // the web3signer is back and signs again
+        unavailable.remove_async().await;
+        let mut tx = test_tx(1);
+        let signing = mock_signing(&mut server, &tx, 1).await;
+        let signature = signer.sign_transaction(&mut tx).await.unwrap();
+        assert_signed_by_key(&tx, &signature);
+        signing.assert_async().await;
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_active{signer=\"L1\"} 0")
+        );
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_signatures{signer=\"L1\"} 1")
+        );
+    }
+
+    #[tokio::test]
+    async fn test_unavailable_web3signer_is_retried_after_interval() {
+        let mut server = mockito::Server::new_async().await;
+        let metrics = Arc::new(Metrics::new());
+        let signer =
+            web3signer_with_fallback(&mut server, Duration::from_secs(3600), metrics.clone()).await;
+
+        let unavailable = mock_unavailable_signing(&mut server).await;
+        signer.sign_transaction(&mut test_tx(0)).await.unwrap();
+        unavailable.remove_async().await;
+
+        // within the retry interval the web3signer is not asked at all
+        let mut tx = test_tx(1);
+        let signing = mock_signing(&mut server, &tx, 0).await;
+        let signature = signer.sign_transaction(&mut tx).await.unwrap();
+        assert_signed_by_key(&tx, &signature);
+        signing.assert_async().await;
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_active{signer=\"L1\"} 1")
+        );
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_signatures{signer=\"L1\"} 2")
+        );
+    }
+
+    #[tokio::test]
+    async fn test_refused_signing_is_not_replaced_by_fallback() {
+        let mut server = mockito::Server::new_async().await;
+        let metrics = Arc::new(Metrics::new());
+        let signer = web3signer_with_fallback(&mut server, Duration::ZERO, metrics.clone()).await;
+
+        // the web3signer is reachable and refuses to sign
+        server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_signTransaction".to_string()))
+            .with_body_from_request(rpc_response(serde_json::json!({
+                "error": {"code": -32000, "message": "signing refused by the slashing protection"},
+            })))
+            .create_async()
+            .await;
+        assert!(signer.sign_transaction(&mut test_tx(0)).await.is_err());
+        assert!(
+            metrics
+                .gather()
+                .contains("signer_fallback_active{signer=\"L1\"} 0")
+        );
+        assert!(!metrics.gather().contains("signer_fallback_signatures{"));
+    }
+
+    #[test]
+    fn test_is_web3signer_unreachable() {
+        let transport_error = |err: TransportError| {
+            Error::from(JsonRpcError::Transport(err.into())).context("Http transport error")
+        };
+        assert!(is_web3signer_unreachable(&transport_error(
+            TransportError::Rejected { status_code: 503 }
+        )));
+        assert!(is_web3signer_unreachable(
+            &Error::from(JsonRpcError::RequestTimeout).context("Operation timed out after 1s")
+        ));
+        assert!(!is_web3signer_unreachable(&transport_error(
+            TransportError::Rejected { status_code: 403 }
+        )));
+        // only the error kind counts, not the message
+        assert!(!is_web3signer_unreachable(&anyhow::anyhow!(
+            "Http transport error: connection refused."
+        )));
+    }
+
+    #[tokio::test]
+    async fn test_refused_connection_is_unreachable() {
+        let listener = std::net::TcpListener::bind("127.0.0.1:0").unwrap();
+        let url = format!("http://{}", listener.local_addr().unwrap());
+        drop(listener);
+
+        let client = JSONRPCClient::new_with_timeout(&url, Duration::from_millis(100)).unwrap();
+        let err = client
+            .call_method_with_retry("eth_signTransaction", vec![])
+            .await
+            .unwrap_err();
+        assert!(is_web3signer_unreachable(&err), "{err}");
+    }
+
+    #[tokio::test]
+    async fn test_fallback_key_must_match_signer_address() {
+        assert!(
+            SignerFallback::new(
+                "L1",
+                TEST_PRIVATE_KEY,
+                Address::repeat_byte(1),
+                Duration::ZERO,
+                Arc::new(Metrics::new())
+            )
+            .is_err()
+        );
+    }
 
     #[tokio::test]
     async fn test_is_signer_key_available() {
@@ -250,4 +586,26 @@ mod tests {
                 .unwrap()
         );
     }
+
+    #[tokio::test]
+    async fn test_health_check_makes_a_single_request() {
+        let mut server = mockito::Server::new_async().await;
+        let client =
+            JSONRPCClient::new_with_timeout(&server.url(), Duration::from_secs(1)).unwrap();
+        let signer = Web3Signer {
+            client,
+            signer_address: "0x614561d2d143621e126e87831aef287678b442b8".to_string(),
+            fallback: None,
+        };
+        let unavailable = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::Regex("eth_accounts".to_string()))
+            .with_status(503)
+            .expect(1)
+            .create_async()
+            .await;
+
+        assert!(signer.check_health().await.is_err());
+        unavailable.assert_async().await;
+    }
 }
@@ -0,0 +1,189 @@
+use super::{config::GOLDEN_TOUCH_ADDRESS, decode_anchor_id_from_tx_data};
+use alloy::{consensus::Transaction as _, primitives::Address, rpc::types::Transaction};
+use std::fmt;
+
+#[derive(Debug, PartialEq)]
+pub enum AnchorTxError {
+    Missing,
+    NotFirst { index: usize },
+    GoldenTouchTx { index: usize },
+}
+
+impl fmt::Display for AnchorTxError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            AnchorTxError::Missing => write!(f, "block has no anchor tx"),
+            AnchorTxError::NotFirst { index } => {
+                write!(f, "anchor tx is at index {index} instead of 0")
+            }
+            AnchorTxError::GoldenTouchTx { index } => {
+                write!(f, "tx at index {index} is sent by the golden touch address")
+            }
+        }
+    }
+}
+
+impl std::error::Error for AnchorTxError {}
+
+fn is_anchor_tx(tx: &Transaction, taiko_anchor_address: Address) -> bool {
+    tx.inner.signer() == GOLDEN_TOUCH_ADDRESS
+        && tx.to() == Some(taiko_anchor_address)
+        && decode_anchor_id_from_tx_data(tx.input()).is_ok()
+}
+
+/// A block is valid for the protocol only with the anchor tx as its first tx and as the
+/// only tx sent by the golden touch address, it guards against regressions in building
+/// the tx list of a block and against pool txs sent by the golden touch address.
+pub fn check_anchor_tx(
+    tx_list: &[Transaction],
+    taiko_anchor_address: Address,
+) -> Result<(), AnchorTxError> {
+    if !tx_list
+        .first()
+        .is_some_and(|tx| is_anchor_tx(tx, taiko_anchor_address))
+    {
+        return match tx_list
+            .iter()
+            .position(|tx| is_anchor_tx(tx, taiko_anchor_address))
+        {
+            None => Err(AnchorTxError::Missing),
+            Some(index) => Err(AnchorTxError::NotFirst { index }),
+        };
+    }
+
+    match tx_list
+        .iter()
+        .skip(1)
+        .position(|tx| tx.inner.signer() == GOLDEN_TOUCH_ADDRESS)
+    {
+        None => Ok(()),
+        Some(index) => Err(AnchorTxError::GoldenTouchTx { index: index + 1 }),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::taiko::{
+        intrinsic_gas::tests::build_test_tx,
+        l2_contracts_bindings::{LibSharedData, TaikoAnchor},
+    };
+    use alloy::{
+        consensus::{SignableTransaction, TxEnvelope, TxLegacy, transaction::Recovered},
+        primitives::{B256, Bytes, Signature, TxKind, U256},
+        sol_types::SolCall,
+    };
+
+    const TAIKO_ANCHOR_ADDRESS: Address = Address::new([0x10; 20]);
+
+    fn build_anchor_tx() -> Transaction {
+        build_golden_touch_tx(TAIKO_ANCHOR_ADDRESS, build_anchor_input())
+    }
+
+    fn build_anchor_input() -> Bytes {
+        TaikoAnchor::anchorV3Call {
+            _anchorBlockId: 5,
+            _anchorStateRoot: B256::repeat_byte(1),
+            _parentGasUsed: 0,
+            _baseFeeConfig: LibSharedData::BaseFeeConfig {
+                adjustmentQuotient: 8,
+                sharingPctg: 75,
+                gasIssuancePerSecond: 5_000_000,
+                minGasExcess: 1_340_000_000,
+                maxGasIssuancePerBlock: 600_000_000,
+            },
+            _signalSlots: vec![],
+        }
+        .abi_encode()
+        .into()
+    }
+
+    fn build_golden_touch_tx(to: Address, input: Bytes) -> Transaction {
+        let tx = TxLegacy {
+            chain_id: Some(167000),
+            nonce: 0,
+            gas_price: 1,
+            gas_limit: 1_000_000,
+            to: TxKind::Call(to),
+            value: U256::ZERO,
+            input,
+        };
+        Transaction {
+            inner: Recovered::new_unchecked(
+                TxEnvelope::Legacy(tx.into_signed(Signature::test_signature())),
+                GOLDEN_TOUCH_ADDRESS,
+            ),
+            block_hash: None,
+            block_number: None,
+            transaction_index: None,
+            effective_gas_price: None,
+        }
+    }
+
+    fn build_transfer_tx() -> Transaction {
+        build_test_tx(21_000, TxKind::Call(Address::ZERO), Bytes::new())
+    }
+
+    #[test]
+    fn test_anchor_tx_first() {
+        assert!(
+            check_anchor_tx(
+                &[build_anchor_tx(), build_transfer_tx()],
+                TAIKO_ANCHOR_ADDRESS
+            )
+            .is_ok()
+        );
+        assert!(check_anchor_tx(&[build_anchor_tx()], TAIKO_ANCHOR_ADDRESS).is_ok());
+    }
+
+    #[test]
+    fn test_missing_anchor_tx_is_rejected() {
+        assert_eq!(
+            check_anchor_tx(&[build_transfer_tx()], TAIKO_ANCHOR_ADDRESS),
+            Err(AnchorTxError::Missing)
+        );
+        assert_eq!(
+            check_anchor_tx(&[], TAIKO_ANCHOR_ADDRESS),
+            Err(AnchorTxError::Missing)
+        );
+        // an anchor call to another contract is not the anchor tx
+        assert_eq!(
+            check_anchor_tx(&[build_anchor_tx()], Address::repeat_byte(0x11)),
+            Err(AnchorTxError::Missing)
+        );
+    }
+
+    #[test]
+    fn test_misplaced_anchor_tx_is_rejected() {
+        assert_eq!(
+            check_anchor_tx(
+                &[build_transfer_tx(), build_anchor_tx()],
+                TAIKO_ANCHOR_ADDRESS
+            ),
+            Err(AnchorTxError::NotFirst { index: 1 })
+        );
+    }
+
+    #[test]
+    fn test_golden_touch_pool_tx_is_rejected() {
+        // a second anchor tx
+        assert_eq!(
+            check_anchor_tx(
+                &[build_anchor_tx(), build_transfer_tx(), build_anchor_tx()],
+                TAIKO_ANCHOR_ADDRESS
+            ),
+            Err(AnchorTxError::GoldenTouchTx { index: 2 })
+        );
+        // any other tx sent by the golden touch address
+        assert_eq!(
+            check_anchor_tx(
+                &[
+                    build_anchor_tx(),
+                    build_golden_touch_tx(Address::ZERO, Bytes::new())
+                ],
+                TAIKO_ANCHOR_ADDRESS
+            ),
+            Err(AnchorTxError::GoldenTouchTx { index: 1 })
+        );
+    }
+}
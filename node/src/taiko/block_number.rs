@@ -0,0 +1,144 @@
+use super::operation_type::OperationType;
+use std::{fmt, sync::Mutex};
+
+#[derive(Debug, PartialEq)]
+pub enum BlockNumberError {
+    Gap { block_number: u64, head: u64 },
+    Regression { block_number: u64, head: u64 },
+}
+
+impl fmt::Display for BlockNumberError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            BlockNumberError::Gap { block_number, head } => write!(
+                f,
+                "block number {block_number} leaves a gap after the L2 head {head}"
+            ),
+            BlockNumberError::Regression { block_number, head } => write!(
+                f,
+                "block number {block_number} does not advance the L2 head {head}"
+            ),
+        }
+    }
+}
+
+impl std::error::Error for BlockNumberError {}
+
+/// The block is built on the L2 slot info fetched at the start of the heartbeat. A head
+/// which has moved since then means a reorg was missed, the block must not be built on it.
+pub fn check_block_number(block_number: u64, head: u64) -> Result<(), BlockNumberError> {
+    if block_number > head.saturating_add(1) {
+        return Err(BlockNumberError::Gap { block_number, head });
+    }
+    if block_number <= head {
+        return Err(BlockNumberError::Regression { block_number, head });
+    }
+    Ok(())
+}
+
+/// L2 head as last seen by the node, from the latest L2 slot info or the last block sealed
+/// since, so the block number is checked without querying the head again for every block.
+#[derive(Default)]
+pub struct KnownL2Head {
+    head: Mutex<Option<u64>>,
+}
+
+impl KnownL2Head {
+    pub fn set(&self, head: u64) {
+        if let Ok(mut known) = self.head.lock() {
+            *known = Some(head);
+        }
+    }
+
+    /// Reanchoring rebuilds on an older parent while the head is still higher,
+    /// so only preconfirmed blocks have to extend the head.
+    pub fn check(
+        &self,
+        block_number: u64,
+        operation_type: OperationType,
+    ) -> Result<(), BlockNumberError> {
+        if !matches!(operation_type, OperationType::Preconfirm) {
+            return Ok(());
+        }
+        match self.head.lock().ok().and_then(|known| *known) {
+            Some(head) => check_block_number(block_number, head),
+            None => Ok(()),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_next_block_number() {
+        assert!(check_block_number(11, 10).is_ok());
+        assert!(check_block_number(1, 0).is_ok());
+    }
+
+    #[test]
+    fn test_block_number_gap_is_rejected() {
+        // the head went back, e.g. the driver has removed preconfirmed blocks
+        assert_eq!(
+            check_block_number(11, 8),
+            Err(BlockNumberError::Gap {
+                block_number: 11,
+                head: 8
+            })
+        );
+    }
+
+    #[test]
+    fn test_block_number_regression_is_rejected() {
+        // blocks from another preconfer were inserted meanwhile
+        assert_eq!(
+            check_block_number(11, 11),
+            Err(BlockNumberError::Regression {
+                block_number: 11,
+                head: 11
+            })
+        );
+        assert_eq!(
+            check_block_number(11, 13),
+            Err(BlockNumberError::Regression {
+                block_number: 11,
+                head: 13
+            })
+        );
+    }
+
+    #[test]
+    fn test_preconfirmed_block_must_extend_known_head() {
+        let known_head = KnownL2Head::default();
+        // nothing known yet
+        assert!(known_head.check(11, OperationType::Preconfirm).is_ok());
+
+        known_head.set(10);
+        assert!(known_head.check(11, OperationType::Preconfirm).is_ok());
+        // the block sealed meanwhile moved the head
+        known_head.set(11);
+        assert_eq!(
+            known_head.check(11, OperationType::Preconfirm),
+            Err(BlockNumberError::Regression {
+                block_number: 11,
+                head: 11
+            })
+        );
+    }
+
+    #[test]
+    fn test_reanchored_block_below_head_is_accepted() {
+        let known_head = KnownL2Head::default();
+        known_head.set(15);
+        // blocks from 11 on are rebuilt on an older anchor while the head is still at 15
+        for block_number in 11..=15 {
+            assert!(
+                known_head
+                    .check(block_number, OperationType::Reanchor)
+                    .is_ok()
+            );
+        }
+        assert!(known_head.check(11, OperationType::Preconfirm).is_err());
+    }
+}
@@ -0,0 +1,83 @@
+use crate::ethereum_l1::slot_clock::{Clock, SlotClock};
+use anyhow::Error;
+use std::fmt;
+
+#[derive(Debug, PartialEq)]
+pub struct StaleSlotError {
+    slot_timestamp: u64,
+    current_slot_timestamp: u64,
+}
+
+impl fmt::Display for StaleSlotError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "L2 slot {} has already passed, current L2 slot is {}",
+            self.slot_timestamp, self.current_slot_timestamp
+        )
+    }
+}
+
+impl std::error::Error for StaleSlotError {}
+
+/// A block built for a slot the slot clock has already left, e.g. because of a slow
+/// heartbeat or clock skew, would be preconfirmed with a stale timestamp.
+pub fn check_slot_not_passed<T: Clock>(
+    slot_clock: &SlotClock<T>,
+    slot_timestamp: u64,
+) -> Result<(), Error> {
+    let current_slot_timestamp = slot_clock.get_l2_slot_begin_timestamp()?;
+    if current_slot_timestamp > slot_timestamp {
+        return Err(StaleSlotError {
+            slot_timestamp,
+            current_slot_timestamp,
+        }
+        .into());
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::ethereum_l1::slot_clock::mock::MockClock;
+
+    const GENESIS_TIMESTAMP: u64 = 1_000;
+    const PRECONF_HEARTBEAT_MS: u64 = 2_000;
+
+    fn slot_clock(seconds_from_genesis: i64) -> SlotClock<MockClock> {
+        let mut slot_clock =
+            SlotClock::<MockClock>::new(0, GENESIS_TIMESTAMP, 12, 32, PRECONF_HEARTBEAT_MS);
+        slot_clock.clock.timestamp =
+            i64::try_from(GENESIS_TIMESTAMP).unwrap() + seconds_from_genesis;
+        slot_clock
+    }
+
+    #[test]
+    fn test_block_within_its_slot() {
+        let mut slot_clock = slot_clock(24);
+        let slot_timestamp = slot_clock.get_l2_slot_begin_timestamp().unwrap();
+        assert!(check_slot_not_passed(&slot_clock, slot_timestamp).is_ok());
+
+        // still before the next heartbeat
+        slot_clock.clock.timestamp += 1;
+        assert!(check_slot_not_passed(&slot_clock, slot_timestamp).is_ok());
+    }
+
+    #[test]
+    fn test_block_overrunning_into_next_slot_is_discarded() {
+        let mut slot_clock = slot_clock(24);
+        let slot_timestamp = slot_clock.get_l2_slot_begin_timestamp().unwrap();
+
+        // building took longer than the heartbeat
+        slot_clock.clock.timestamp += 2;
+        let err = check_slot_not_passed(&slot_clock, slot_timestamp).unwrap_err();
+        assert_eq!(
+            err.downcast_ref::<StaleSlotError>(),
+            Some(&StaleSlotError {
+                slot_timestamp,
+                current_slot_timestamp: slot_timestamp + 2,
+            })
+        );
+    }
+}
@@ -1,6 +1,7 @@
 use crate::shared::signer::Signer;
 use alloy::primitives::{Address, B256};
 use anyhow::Error;
+use std::fmt;
 use std::str::FromStr;
 use std::sync::Arc;
 use std::time::Duration;
@@ -29,6 +30,16 @@ pub struct TaikoConfig {
     pub rpc_l2_execution_layer_timeout: Duration,
     pub rpc_driver_preconf_timeout: Duration,
     pub rpc_driver_status_timeout: Duration,
+    pub tx_selection: TxSelectionConfig,
+    pub block_validation: BlockValidationConfig,
+    pub fee_recipients: FeeRecipientConfig,
+    pub preconf_summary_push: PreconfSummaryPushConfig,
+    pub driver_head_reconcile_blocks: u64,
+    pub engine_api_export_url: Option<String>,
+    pub engine_api_export_jwt_secret_bytes: Option<[u8; 32]>,
+    pub engine_api_export_timeout: Duration,
+    pub equivocation_guard_file: Option<String>,
+    pub propagate_trace_context: bool,
     pub signer: Arc<Signer>,
 }
 
@@ -47,6 +58,16 @@ impl TaikoConfig {
         rpc_l2_execution_layer_timeout: Duration,
         rpc_driver_preconf_timeout: Duration,
         rpc_driver_status_timeout: Duration,
+        tx_selection: TxSelectionConfig,
+        block_validation: BlockValidationConfig,
+        fee_recipients: FeeRecipientConfig,
+        preconf_summary_push: PreconfSummaryPushConfig,
+        driver_head_reconcile_blocks: u64,
+        engine_api_export_url: Option<String>,
+        engine_api_export_jwt_secret_bytes: Option<[u8; 32]>,
+        engine_api_export_timeout: Duration,
+        equivocation_guard_file: Option<String>,
+        propagate_trace_context: bool,
         singer: Arc<Signer>,
     ) -> Result<Self, Error> {
         Ok(Self {
@@ -62,7 +83,90 @@ impl TaikoConfig {
             rpc_l2_execution_layer_timeout,
             rpc_driver_preconf_timeout,
             rpc_driver_status_timeout,
+            tx_selection,
+            block_validation,
+            fee_recipients,
+            preconf_summary_push,
+            driver_head_reconcile_blocks,
+            engine_api_export_url,
+            engine_api_export_jwt_secret_bytes,
+            engine_api_export_timeout,
+            equivocation_guard_file,
+            propagate_trace_context,
             signer: singer,
         })
     }
 }
+
+/// Filtering and ordering of the txs polled from the taiko geth tx pool
+#[derive(Clone, Debug)]
+pub struct TxSelectionConfig {
+    pub pre_simulate_txs: bool,
+    pub drop_txs_below_intrinsic_gas: bool,
+    pub drop_blob_txs: bool,
+    /// 0 means no limit
+    pub max_tx_data_size: u64,
+    /// 0 means no limit
+    pub max_txs_per_poll: u64,
+    /// 0 disables the adjustment of the txs fetched by a poll
+    pub adaptive_min_txs_per_poll: u64,
+    pub equal_tip_order: EqualTipOrder,
+    pub report_dir: Option<String>,
+}
+
+/// Checks of a preconfirmed block before it is submitted to the driver
+#[derive(Clone, Debug)]
+pub struct BlockValidationConfig {
+    pub validate_gas_used: bool,
+    pub validate_block_number: bool,
+    pub validate_anchor_tx: bool,
+    pub validate_block_slot: bool,
+}
+
+#[derive(Clone, Debug)]
+pub struct FeeRecipientConfig {
+    /// None means the preconfer address
+    pub fee_recipient: Option<String>,
+    pub fallback_fee_recipient: Option<Address>,
+    pub enforce_registered_fee_recipient: bool,
+}
+
+#[derive(Clone, Debug)]
+pub struct PreconfSummaryPushConfig {
+    /// None disables the push
+    pub url: Option<String>,
+    pub timeout: Duration,
+    pub max_attempts: u64,
+}
+
+/// Order of the txs with the same effective tip in a preconfirmed block
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum EqualTipOrder {
+    /// position in the tx list returned by taiko geth
+    List,
+    /// ascending tx hash, independent of the order of the tx pool. The txs
+    /// are ordered by tip, with the txs of a sender in nonce order
+    TxHash,
+}
+
+impl FromStr for EqualTipOrder {
+    type Err = anyhow::Error;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "list" => Ok(EqualTipOrder::List),
+            "tx_hash" => Ok(EqualTipOrder::TxHash),
+            _ => Err(anyhow::anyhow!("Unknown equal tip order: {}", s)),
+        }
+    }
+}
+
+impl fmt::Display for EqualTipOrder {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let s = match self {
+            EqualTipOrder::List => "list",
+            EqualTipOrder::TxHash => "tx_hash",
+        };
+        write!(f, "{s}")
+    }
+}
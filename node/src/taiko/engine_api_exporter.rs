@@ -0,0 +1,332 @@
+use super::l2_execution_layer::L2ExecutionLayer;
+use crate::utils::rpc_client::JSONRPCClient;
+use alloy::{eips::eip2718::Encodable2718, primitives::B256, rpc::types::Block};
+use anyhow::Error;
+use serde_json::{Value, json};
+use std::{sync::Arc, time::Duration};
+use tokio::sync::mpsc::{self, Receiver, Sender, error::TrySendError};
+use tracing::{debug, info, warn};
+
+// blocks waiting for the export, the newest ones are dropped when the engine falls behind
+const EXPORT_QUEUE_SIZE: usize = 64;
+
+#[derive(Debug, PartialEq)]
+pub enum PayloadStatus {
+    Valid,
+    Invalid { validation_error: Option<String> },
+    Syncing,
+    Accepted,
+}
+
+impl PayloadStatus {
+    fn from_value(value: &Value) -> Result<Self, Error> {
+        let status = value
+            .get("status")
+            .and_then(Value::as_str)
+            .ok_or_else(|| anyhow::anyhow!("Payload status missing in {}", value))?;
+        match status {
+            "VALID" => Ok(PayloadStatus::Valid),
+            "INVALID" | "INVALID_BLOCK_HASH" => Ok(PayloadStatus::Invalid {
+                validation_error: value
+                    .get("validationError")
+                    .and_then(Value::as_str)
+                    .map(str::to_string),
+            }),
+            "SYNCING" => Ok(PayloadStatus::Syncing),
+            "ACCEPTED" => Ok(PayloadStatus::Accepted),
+            _ => Err(anyhow::anyhow!("Unknown payload status {}", status)),
+        }
+    }
+}
+
+/// Feeds the preconfirmed L2 blocks to an external execution client over the Engine API,
+/// so it follows the preconfirmations without its own driver. Blocks are exported in order
+/// by a background task, which also fetches the full blocks, a slow or failing engine never
+/// delays block building.
+pub struct EngineApiExporter {
+    sender: Sender<u64>,
+}
+
+impl EngineApiExporter {
+    pub fn new(
+        url: &str,
+        jwt_secret: &[u8],
+        timeout: Duration,
+        l2_execution_layer: Arc<L2ExecutionLayer>,
+    ) -> Result<Self, Error> {
+        let client = JSONRPCClient::new_with_timeout_and_jwt(url, timeout, jwt_secret)?;
+        let (sender, receiver) = mpsc::channel(EXPORT_QUEUE_SIZE);
+        tokio::spawn(export_loop(client, l2_execution_layer, receiver));
+        Ok(Self { sender })
+    }
+
+    pub fn export(&self, block_number: u64) {
+        match self.sender.try_send(block_number) {
+            Ok(()) => {}
+            Err(TrySendError::Full(block_number)) => warn!(
+                "Engine API export queue is full, block {} not exported",
+                block_number
+            ),
+            Err(TrySendError::Closed(block_number)) => warn!(
+                "Engine API export stopped, block {} not exported",
+                block_number
+            ),
+        }
+    }
+}
+
+async fn export_loop(
+    client: JSONRPCClient,
+    l2_execution_layer: Arc<L2ExecutionLayer>,
+    mut receiver: Receiver<u64>,
+) {
+    while let Some(block_number) = receiver.recv().await {
+        let block = match l2_execution_layer
+            .get_l2_block_by_number(block_number, true)
+            .await
+        {
+            Ok(block) => block,
+            Err(err) => {
+                warn!(
+                    "Failed to get block {} for the Engine API export: {}",
+                    block_number, err
+                );
+                continue;
+            }
+        };
+        match export_block(&client, &block).await {
+            Ok(PayloadStatus::Valid) => {
+                debug!("Block {} exported to the engine", block.header.number)
+            }
+            Ok(PayloadStatus::Invalid { validation_error }) => warn!(
+                "Engine rejected block {} as invalid: {}",
+                block.header.number,
+                validation_error.as_deref().unwrap_or("no validation error")
+            ),
+            Ok(status) => info!(
+                "Engine has not validated block {} yet, status {:?}",
+                block.header.number, status
+            ),
+            Err(err) => warn!(
+                "Failed to export block {} to the engine: {}",
+                block.header.number, err
+            ),
+        }
+    }
+}
+
+fn execution_payload(block: &Block) -> Result<Value, Error> {
+    let transactions = block
+        .transactions
+        .as_transactions()
+        .ok_or_else(|| anyhow::anyhow!("Block {} has no full txs", block.header.number))?
+        .iter()
+        .map(|tx| format!("0x{}", hex::encode(tx.inner.encoded_2718())))
+        .collect::<Vec<_>>();
+    let header = &block.header;
+    Ok(json!({
+        "parentHash": header.parent_hash,
+        "feeRecipient": header.beneficiary,
+        "stateRoot": header.state_root,
+        "receiptsRoot": header.receipts_root,
+        "logsBloom": header.logs_bloom,
+        "prevRandao": header.mix_hash,
+        "blockNumber": format!("{:#x}", header.number),
+        "gasLimit": format!("{:#x}", header.gas_limit),
+        "gasUsed": format!("{:#x}", header.gas_used),
+        "timestamp": format!("{:#x}", header.timestamp),
+        "extraData": header.extra_data,
+        "baseFeePerGas": format!("{:#x}", header.base_fee_per_gas.unwrap_or_default()),
+        "blockHash": header.hash,
+        "transactions": transactions,
+        "withdrawals": [],
+    }))
+}
+
+/// Sends the block with engine_newPayload and makes it the head with engine_forkchoiceUpdated,
+/// unless the engine has found it invalid.
+async fn export_block(client: &JSONRPCClient, block: &Block) -> Result<PayloadStatus, Error> {
+    let result = client
+        .call_method("engine_newPayloadV2", vec![execution_payload(block)?])
+        .await
+        .map_err(|e| anyhow::anyhow!("engine_newPayloadV2 failed: {}", e))?;
+    let status = PayloadStatus::from_value(&result)?;
+    if matches!(status, PayloadStatus::Invalid { .. }) {
+        return Ok(status);
+    }
+
+    let forkchoice_state = json!({
+        "headBlockHash": block.header.hash,
+        "safeBlockHash": B256::ZERO,
+        "finalizedBlockHash": B256::ZERO,
+    });
+    let result = client
+        .call_method(
+            "engine_forkchoiceUpdatedV2",
+            vec![forkchoice_state, Value::Null],
+        )
+        .await
+        .map_err(|e| anyhow::anyhow!("engine_forkchoiceUpdatedV2 failed: {}", e))?;
+    let payload_status = result
+        .get("payloadStatus")
+        .ok_or_else(|| anyhow::anyhow!("Forkchoice updated response missing payloadStatus"))?;
+    PayloadStatus::from_value(payload_status)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::shared::test_utils::rpc_result;
+    use alloy::{
+        consensus::Header,
+        rpc::types::{BlockTransactions, Header as RpcHeader},
+    };
+
+    const JWT_SECRET: [u8; 32] = [7; 32];
+
+    fn test_block() -> Block {
+        let header = Header {
+            parent_hash: B256::repeat_byte(1),
+            number: 12,
+            gas_limit: 241_000_000,
+            timestamp: 1000,
+            base_fee_per_gas: Some(10_000_000),
+            ..Default::default()
+        };
+        Block {
+            header: RpcHeader {
+                hash: B256::repeat_byte(2),
+                inner: header,
+                total_difficulty: None,
+                size: None,
+            },
+            uncles: vec![],
+            transactions: BlockTransactions::Full(vec![]),
+            withdrawals: None,
+        }
+    }
+
+    async fn mock_method(
+        server: &mut mockito::ServerGuard,
+        method: &str,
+        result: Value,
+        hits: usize,
+    ) -> mockito::Mock {
+        server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::PartialJson(json!({ "method": method })))
+            .with_body_from_request(rpc_result(result))
+            .expect(hits)
+            .create_async()
+            .await
+    }
+
+    fn client(server: &mockito::ServerGuard) -> JSONRPCClient {
+        JSONRPCClient::new_with_timeout_and_jwt(&server.url(), Duration::from_secs(1), &JWT_SECRET)
+            .unwrap()
+    }
+
+    #[tokio::test]
+    async fn test_valid_payload_becomes_head() {
+        let mut server = mockito::Server::new_async().await;
+        let block_hash = format!("\"0x{}\"", hex::encode(B256::repeat_byte(2)));
+        let new_payload = server
+            .mock("POST", "/")
+            .match_header("authorization", mockito::Matcher::Regex("^Bearer ".into()))
+            .match_body(mockito::Matcher::AllOf(vec![
+                mockito::Matcher::PartialJson(json!({ "method": "engine_newPayloadV2" })),
+                mockito::Matcher::Regex(format!("\"blockHash\":{block_hash}")),
+                mockito::Matcher::Regex("\"blockNumber\":\"0xc\"".into()),
+            ]))
+            .with_body_from_request(rpc_result(json!({ "status": "VALID" })))
+            .create_async()
+            .await;
+        let forkchoice_updated = server
+            .mock("POST", "/")
+            .match_body(mockito::Matcher::AllOf(vec![
+                mockito::Matcher::PartialJson(json!({ "method": "engine_forkchoiceUpdatedV2" })),
+                mockito::Matcher::Regex(format!("\"headBlockHash\":{block_hash}")),
+            ]))
+            .with_body_from_request(rpc_result(
+                json!({ "payloadStatus": { "status": "VALID" } }),
+            ))
+            .create_async()
+            .await;
+
+        let status = export_block(&client(&server), &test_block()).await.unwrap();
+        assert_eq!(status, PayloadStatus::Valid);
+        new_payload.assert_async().await;
+        forkchoice_updated.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_invalid_payload_does_not_become_head() {
+        let mut server = mockito::Server::new_async().await;
+        let new_payload = mock_method(
+            &mut server,
+            "engine_newPayloadV2",
+            json!({ "status": "INVALID", "validationError": "invalid state root" }),
+            1,
+        )
+        .await;
+        let forkchoice_updated = mock_method(
+            &mut server,
+            "engine_forkchoiceUpdatedV2",
+            json!({ "payloadStatus": { "status": "VALID" } }),
+            0,
+        )
+        .await;
+
+        let status = export_block(&client(&server), &test_block()).await.unwrap();
+        assert_eq!(
+            status,
+            PayloadStatus::Invalid {
+                validation_error: Some("invalid state root".to_string())
+            }
+        );
+        new_payload.assert_async().await;
+        forkchoice_updated.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_syncing_engine_is_given_the_head() {
+        let mut server = mockito::Server::new_async().await;
+        let new_payload = mock_method(
+            &mut server,
+            "engine_newPayloadV2",
+            json!({ "status": "SYNCING" }),
+            1,
+        )
+        .await;
+        let forkchoice_updated = mock_method(
+            &mut server,
+            "engine_forkchoiceUpdatedV2",
+            json!({ "payloadStatus": { "status": "SYNCING" } }),
+            1,
+        )
+        .await;
+
+        let status = export_block(&client(&server), &test_block()).await.unwrap();
+        assert_eq!(status, PayloadStatus::Syncing);
+        new_payload.assert_async().await;
+        forkchoice_updated.assert_async().await;
+    }
+
+    #[test]
+    fn test_execution_payload() {
+        let payload = execution_payload(&test_block()).unwrap();
+        assert_eq!(payload["blockNumber"], "0xc");
+        assert_eq!(payload["blockHash"], B256::repeat_byte(2).to_string());
+        assert_eq!(payload["parentHash"], B256::repeat_byte(1).to_string());
+        assert_eq!(payload["timestamp"], "0x3e8");
+        assert_eq!(payload["gasLimit"], "0xe5d5e40");
+        assert_eq!(payload["baseFeePerGas"], "0x989680");
+        assert_eq!(payload["transactions"], json!([]));
+    }
+
+    #[test]
+    fn test_unknown_payload_status_is_rejected() {
+        assert!(PayloadStatus::from_value(&json!({ "status": "UNKNOWN" })).is_err());
+        assert!(PayloadStatus::from_value(&json!({})).is_err());
+    }
+}
@@ -0,0 +1,223 @@
+use super::preconf_blocks::ExecutableData;
+use crate::metrics::Metrics;
+use alloy::primitives::{B256, keccak256};
+use anyhow::Error;
+use serde::{Deserialize, Serialize};
+use std::{
+    collections::VecDeque,
+    fmt,
+    path::PathBuf,
+    sync::{Arc, Mutex},
+};
+use tracing::info;
+
+// number of the last preconfirmed blocks kept in the file
+const RECORDED_BLOCKS: usize = 64;
+
+#[derive(Serialize, Deserialize, Clone, Debug, PartialEq)]
+struct BlockRecord {
+    slot_timestamp: u64,
+    block_number: u64,
+    block_digest: B256,
+}
+
+#[derive(Debug, PartialEq)]
+pub struct EquivocationError {
+    slot_timestamp: u64,
+    block_number: u64,
+}
+
+impl fmt::Display for EquivocationError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "a different block {} was already preconfirmed for L2 slot {}",
+            self.block_number, self.slot_timestamp
+        )
+    }
+}
+
+impl std::error::Error for EquivocationError {}
+
+/// Keeps the last preconfirmed blocks in a file, so a node restarted with a stale state
+/// refuses to preconfirm a conflicting block for a slot it has already preconfirmed.
+pub struct EquivocationGuard {
+    path: PathBuf,
+    records: Mutex<VecDeque<BlockRecord>>,
+    metrics: Arc<Metrics>,
+}
+
+impl EquivocationGuard {
+    pub fn new(path: &str, metrics: Arc<Metrics>) -> Result<Self, Error> {
+        let path = PathBuf::from(path);
+        let records: VecDeque<BlockRecord> = if path.exists() {
+            let content = std::fs::read(&path).map_err(|e| {
+                anyhow::anyhow!(
+                    "Failed to read equivocation guard file {}: {}",
+                    path.display(),
+                    e
+                )
+            })?;
+            serde_json::from_slice(&content)?
+        } else {
+            VecDeque::new()
+        };
+        info!(
+            "Equivocation guard loaded {} preconfirmed blocks from {}",
+            records.len(),
+            path.display()
+        );
+        Ok(Self {
+            path,
+            records: Mutex::new(records),
+            metrics,
+        })
+    }
+
+    fn lock(&self) -> Result<std::sync::MutexGuard<'_, VecDeque<BlockRecord>>, Error> {
+        self.records
+            .lock()
+            .map_err(|e| anyhow::anyhow!("EquivocationGuard: failed to lock records: {}", e))
+    }
+
+    fn block_digest(executable_data: &ExecutableData) -> Result<B256, Error> {
+        Ok(keccak256(serde_json::to_vec(executable_data)?))
+    }
+
+    /// Fails when another block with the same number was preconfirmed for the same slot.
+    pub fn check(&self, executable_data: &ExecutableData) -> Result<(), Error> {
+        let block_digest = Self::block_digest(executable_data)?;
+        let is_conflicting = self.lock()?.iter().any(|record| {
+            record.slot_timestamp == executable_data.timestamp
+                && record.block_number == executable_data.block_number
+                && record.block_digest != block_digest
+        });
+        if is_conflicting {
+            self.metrics.inc_self_equivocations_prevented();
+            return Err(EquivocationError {
+                slot_timestamp: executable_data.timestamp,
+                block_number: executable_data.block_number,
+            }
+            .into());
+        }
+        Ok(())
+    }
+
+    /// Records a submitted block, a reanchored block replaces the recorded one.
+    pub async fn record(&self, executable_data: &ExecutableData) -> Result<(), Error> {
+        let record = BlockRecord {
+            slot_timestamp: executable_data.timestamp,
+            block_number: executable_data.block_number,
+            block_digest: Self::block_digest(executable_data)?,
+        };
+        let content = {
+            let mut records = self.lock()?;
+            records.retain(|r| {
+                r.slot_timestamp != record.slot_timestamp || r.block_number != record.block_number
+            });
+            records.push_back(record);
+            while records.len() > RECORDED_BLOCKS {
+                records.pop_front();
+            }
+            serde_json::to_vec(&*records)?
+        };
+        tokio::fs::write(&self.path, content).await.map_err(|e| {
+            anyhow::anyhow!(
+                "Failed to write equivocation guard file {}: {}",
+                self.path.display(),
+                e
+            )
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tempfile::TempDir;
+
+    fn test_file(dir: &TempDir) -> String {
+        dir.path()
+            .join("equivocation_guard.json")
+            .to_string_lossy()
+            .to_string()
+    }
+
+    fn block(block_number: u64, timestamp: u64, transactions: &str) -> ExecutableData {
+        ExecutableData {
+            base_fee_per_gas: 10_000_000,
+            block_number,
+            extra_data: String::new(),
+            fee_recipient: String::new(),
+            gas_limit: 241_000_000,
+            parent_hash: format!("0x{}", hex::encode(B256::repeat_byte(1))),
+            timestamp,
+            transactions: transactions.to_string(),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_conflicting_block_after_restart_is_refused() {
+        let dir = TempDir::new().unwrap();
+        let path = test_file(&dir);
+        let guard = EquivocationGuard::new(&path, Arc::new(Metrics::new())).unwrap();
+        let preconfirmed = block(12, 1000, "0x01");
+        guard.check(&preconfirmed).unwrap();
+        guard.record(&preconfirmed).await.unwrap();
+        drop(guard);
+
+        // restarted with a stale pending tx list
+        let guard = EquivocationGuard::new(&path, Arc::new(Metrics::new())).unwrap();
+        let err = guard.check(&block(12, 1000, "0x02")).unwrap_err();
+        assert_eq!(
+            err.downcast_ref::<EquivocationError>(),
+            Some(&EquivocationError {
+                slot_timestamp: 1000,
+                block_number: 12
+            })
+        );
+        assert!(
+            guard
+                .metrics
+                .gather()
+                .contains("self_equivocations_prevented 1")
+        );
+
+        // the same block can be submitted again and the next slots are free
+        assert!(guard.check(&preconfirmed).is_ok());
+        assert!(guard.check(&block(13, 1002, "0x02")).is_ok());
+    }
+
+    #[tokio::test]
+    async fn test_reanchored_block_replaces_record() {
+        let dir = TempDir::new().unwrap();
+        let path = test_file(&dir);
+        let guard = EquivocationGuard::new(&path, Arc::new(Metrics::new())).unwrap();
+        guard.record(&block(12, 1000, "0x01")).await.unwrap();
+
+        let reanchored = block(12, 1000, "0x03");
+        guard.record(&reanchored).await.unwrap();
+        assert!(guard.check(&reanchored).is_ok());
+        assert!(guard.check(&block(12, 1000, "0x01")).is_err());
+    }
+
+    #[tokio::test]
+    async fn test_recorded_blocks_are_bounded() {
+        let dir = TempDir::new().unwrap();
+        let path = test_file(&dir);
+        let guard = EquivocationGuard::new(&path, Arc::new(Metrics::new())).unwrap();
+        for number in 0..100 {
+            guard
+                .record(&block(number, 1000 + number, "0x01"))
+                .await
+                .unwrap();
+        }
+        drop(guard);
+
+        let guard = EquivocationGuard::new(&path, Arc::new(Metrics::new())).unwrap();
+        assert_eq!(guard.lock().unwrap().len(), RECORDED_BLOCKS);
+        // the oldest blocks are forgotten
+        assert!(guard.check(&block(0, 1000, "0x02")).is_ok());
+        assert!(guard.check(&block(99, 1099, "0x02")).is_err());
+    }
+}
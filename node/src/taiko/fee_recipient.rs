@@ -0,0 +1,119 @@
+use alloy::primitives::Address;
+use anyhow::Error;
+use tracing::{info, warn};
+
+/// Returns the fee recipient for preconfirmed blocks.
+/// `primary` is the checked primary fee recipient or the reason why it is problematic.
+pub fn select_fee_recipient(
+    primary: Result<Address, String>,
+    fallback: Option<Address>,
+) -> Result<Address, Error> {
+    match (primary, fallback) {
+        (Ok(primary), _) => {
+            info!("Using fee recipient {}", primary);
+            Ok(primary)
+        }
+        (Err(reason), Some(fallback)) => {
+            warn!(
+                "🔀 Primary fee recipient is problematic ({}), switching to fallback fee recipient {}",
+                reason, fallback
+            );
+            Ok(fallback)
+        }
+        (Err(reason), None) => Err(anyhow::anyhow!(
+            "Primary fee recipient is problematic ({}) and no fallback fee recipient is configured",
+            reason
+        )),
+    }
+}
+
+/// Tells a caller without funds apart from a recipient rejecting the value transfer,
+/// only the latter makes the fee recipient problematic.
+pub fn is_insufficient_funds_error(error_message: &str) -> bool {
+    error_message.contains("insufficient funds")
+}
+
+/// Fails when the fee recipient differs from the sequencer address registered for the preconfer.
+pub fn check_registered_fee_recipient(
+    fee_recipient: Address,
+    registered_sequencer: Address,
+) -> Result<(), Error> {
+    if registered_sequencer == Address::ZERO {
+        return Err(anyhow::anyhow!(
+            "Fee recipient enforcement: the preconfer is not registered in PreconfWhitelist"
+        ));
+    }
+    if fee_recipient != registered_sequencer {
+        return Err(anyhow::anyhow!(
+            "Fee recipient enforcement: fee recipient {} does not match the registered sequencer address {}",
+            fee_recipient,
+            registered_sequencer
+        ));
+    }
+    info!(
+        "Fee recipient {} matches the registered sequencer address",
+        fee_recipient
+    );
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const PRIMARY: Address = Address::new([0x11; 20]);
+    const FALLBACK: Address = Address::new([0x22; 20]);
+
+    #[test]
+    fn test_primary_fee_recipient_is_used_when_valid() {
+        assert_eq!(
+            select_fee_recipient(Ok(PRIMARY), Some(FALLBACK)).unwrap(),
+            PRIMARY
+        );
+        assert_eq!(select_fee_recipient(Ok(PRIMARY), None).unwrap(), PRIMARY);
+    }
+
+    #[test]
+    fn test_fallback_fee_recipient_is_used_when_primary_is_problematic() {
+        assert_eq!(
+            select_fee_recipient(
+                Err("contract rejects value transfer".to_string()),
+                Some(FALLBACK)
+            )
+            .unwrap(),
+            FALLBACK
+        );
+        assert_eq!(
+            select_fee_recipient(Err("zero address".to_string()), Some(FALLBACK)).unwrap(),
+            FALLBACK
+        );
+    }
+
+    #[test]
+    fn test_problematic_primary_fee_recipient_without_fallback() {
+        assert!(select_fee_recipient(Err("zero address".to_string()), None).is_err());
+    }
+
+    #[test]
+    fn test_insufficient_funds_is_not_a_rejected_transfer() {
+        assert!(is_insufficient_funds_error(
+            "insufficient funds for gas * price + value: address 0x11 have 0 want 1"
+        ));
+        assert!(is_insufficient_funds_error(
+            "insufficient funds for transfer"
+        ));
+        assert!(!is_insufficient_funds_error("execution reverted"));
+    }
+
+    #[test]
+    fn test_registered_fee_recipient_matches() {
+        assert!(check_registered_fee_recipient(PRIMARY, PRIMARY).is_ok());
+    }
+
+    #[test]
+    fn test_registered_fee_recipient_mismatch() {
+        let err = check_registered_fee_recipient(PRIMARY, FALLBACK).unwrap_err();
+        assert!(err.to_string().contains("does not match"));
+        assert!(check_registered_fee_recipient(PRIMARY, Address::ZERO).is_err());
+    }
+}
@@ -0,0 +1,133 @@
+use super::intrinsic_gas::intrinsic_gas;
+use crate::shared::l2_tx_lists::PreBuiltTxList;
+use alloy::consensus::Transaction as _;
+use std::fmt;
+
+#[derive(Debug, PartialEq)]
+pub enum GasConsistencyError {
+    ExceedsBlockGasLimit { gas_used: u64, block_gas_limit: u64 },
+    ExceedsTxsGasLimit { gas_used: u64, txs_gas_limit: u64 },
+    BelowIntrinsicGas { gas_used: u64, intrinsic_gas: u64 },
+}
+
+impl fmt::Display for GasConsistencyError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            GasConsistencyError::ExceedsBlockGasLimit {
+                gas_used,
+                block_gas_limit,
+            } => write!(
+                f,
+                "gas used {gas_used} exceeds the block gas limit {block_gas_limit}"
+            ),
+            GasConsistencyError::ExceedsTxsGasLimit {
+                gas_used,
+                txs_gas_limit,
+            } => write!(
+                f,
+                "gas used {gas_used} exceeds the sum of tx gas limits {txs_gas_limit}"
+            ),
+            GasConsistencyError::BelowIntrinsicGas {
+                gas_used,
+                intrinsic_gas,
+            } => write!(
+                f,
+                "gas used {gas_used} is below the sum of tx intrinsic gas {intrinsic_gas}"
+            ),
+        }
+    }
+}
+
+impl std::error::Error for GasConsistencyError {}
+
+/// Taiko geth reports only the total gas used by the tx list, so it is checked against
+/// the bounds given by the txs themselves: every tx uses at least its intrinsic gas
+/// and at most its gas limit, and the total must fit into the block gas limit.
+pub fn check_gas_used(
+    tx_list: &PreBuiltTxList,
+    block_gas_limit: u64,
+) -> Result<(), GasConsistencyError> {
+    let gas_used = tx_list.estimated_gas_used;
+    if gas_used > block_gas_limit {
+        return Err(GasConsistencyError::ExceedsBlockGasLimit {
+            gas_used,
+            block_gas_limit,
+        });
+    }
+
+    let txs_gas_limit = tx_list
+        .tx_list
+        .iter()
+        .map(|tx| tx.gas_limit())
+        .fold(0u64, u64::saturating_add);
+    if gas_used > txs_gas_limit {
+        return Err(GasConsistencyError::ExceedsTxsGasLimit {
+            gas_used,
+            txs_gas_limit,
+        });
+    }
+
+    let intrinsic_gas = tx_list
+        .tx_list
+        .iter()
+        .map(intrinsic_gas)
+        .fold(0u64, u64::saturating_add);
+    if gas_used < intrinsic_gas {
+        return Err(GasConsistencyError::BelowIntrinsicGas {
+            gas_used,
+            intrinsic_gas,
+        });
+    }
+
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const BLOCK_GAS_LIMIT: u64 = 240_000_000;
+
+    fn get_test_tx_list(estimated_gas_used: u64) -> PreBuiltTxList {
+        let mut tx_list = serde_json::from_str::<Vec<PreBuiltTxList>>(include_str!(
+            "../utils/tx_lists_test_response_from_geth.json"
+        ))
+        .unwrap()
+        .remove(0);
+        tx_list.estimated_gas_used = estimated_gas_used;
+        tx_list
+    }
+
+    #[test]
+    fn test_consistent_gas_used() {
+        // two plain transfers with 40000 gas limit each
+        assert!(check_gas_used(&get_test_tx_list(42_000), BLOCK_GAS_LIMIT).is_ok());
+        assert!(check_gas_used(&get_test_tx_list(80_000), BLOCK_GAS_LIMIT).is_ok());
+        assert!(check_gas_used(&PreBuiltTxList::empty(), BLOCK_GAS_LIMIT).is_ok());
+    }
+
+    #[test]
+    fn test_inconsistent_gas_used_is_rejected() {
+        assert_eq!(
+            check_gas_used(&get_test_tx_list(80_001), BLOCK_GAS_LIMIT),
+            Err(GasConsistencyError::ExceedsTxsGasLimit {
+                gas_used: 80_001,
+                txs_gas_limit: 80_000,
+            })
+        );
+        assert_eq!(
+            check_gas_used(&get_test_tx_list(41_999), BLOCK_GAS_LIMIT),
+            Err(GasConsistencyError::BelowIntrinsicGas {
+                gas_used: 41_999,
+                intrinsic_gas: 42_000,
+            })
+        );
+        assert_eq!(
+            check_gas_used(&get_test_tx_list(42_000), 41_000),
+            Err(GasConsistencyError::ExceedsBlockGasLimit {
+                gas_used: 42_000,
+                block_gas_limit: 41_000,
+            })
+        );
+    }
+}
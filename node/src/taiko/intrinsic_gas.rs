@@ -0,0 +1,100 @@
+use alloy::consensus::Transaction;
+
+const TX_BASE_GAS: u64 = 21_000;
+const TX_CREATE_GAS: u64 = 32_000;
+const TX_DATA_ZERO_GAS: u64 = 4;
+const TX_DATA_NON_ZERO_GAS: u64 = 16;
+const TX_ACCESS_LIST_ADDRESS_GAS: u64 = 2_400;
+const TX_ACCESS_LIST_STORAGE_KEY_GAS: u64 = 1_900;
+const INIT_CODE_WORD_GAS: u64 = 2;
+const PER_AUTHORIZATION_GAS: u64 = 25_000;
+
+/// Gas charged before the execution of the transaction starts (Shanghai rules, EIP-7702 authorizations included).
+pub fn intrinsic_gas(tx: &impl Transaction) -> u64 {
+    let input = tx.input();
+    let zero_bytes = input.iter().filter(|byte| **byte == 0).count() as u64;
+    let non_zero_bytes = input.len() as u64 - zero_bytes;
+
+    let mut gas =
+        TX_BASE_GAS + zero_bytes * TX_DATA_ZERO_GAS + non_zero_bytes * TX_DATA_NON_ZERO_GAS;
+
+    if tx.kind().is_create() {
+        let init_code_words = (input.len() as u64).div_ceil(32);
+        gas += TX_CREATE_GAS + init_code_words * INIT_CODE_WORD_GAS;
+    }
+
+    if let Some(access_list) = tx.access_list() {
+        for item in access_list.iter() {
+            gas += TX_ACCESS_LIST_ADDRESS_GAS
+                + item.storage_keys.len() as u64 * TX_ACCESS_LIST_STORAGE_KEY_GAS;
+        }
+    }
+
+    if let Some(authorization_list) = tx.authorization_list() {
+        gas += authorization_list.len() as u64 * PER_AUTHORIZATION_GAS;
+    }
+
+    gas
+}
+
+pub fn is_below_intrinsic_gas(tx: &impl Transaction) -> bool {
+    tx.gas_limit() < intrinsic_gas(tx)
+}
+
+#[cfg(test)]
+pub(crate) mod tests {
+    use super::*;
+    use alloy::{
+        consensus::{SignableTransaction, TxEnvelope, TxLegacy, transaction::Recovered},
+        primitives::{Address, Bytes, Signature, TxKind, U256},
+        rpc::types::Transaction as RpcTransaction,
+    };
+
+    pub fn build_test_tx(gas_limit: u64, to: TxKind, input: Bytes) -> RpcTransaction {
+        let tx = TxLegacy {
+            chain_id: Some(167000),
+            nonce: 0,
+            gas_price: 1,
+            gas_limit,
+            to,
+            value: U256::ZERO,
+            input,
+        };
+        RpcTransaction {
+            inner: Recovered::new_unchecked(
+                TxEnvelope::Legacy(tx.into_signed(Signature::test_signature())),
+                Address::ZERO,
+            ),
+            block_hash: None,
+            block_number: None,
+            transaction_index: None,
+            effective_gas_price: None,
+        }
+    }
+
+    #[test]
+    fn test_intrinsic_gas_of_transfer() {
+        let tx = build_test_tx(21_000, TxKind::Call(Address::ZERO), Bytes::new());
+        assert_eq!(intrinsic_gas(&tx), 21_000);
+        assert!(!is_below_intrinsic_gas(&tx));
+
+        let tx = build_test_tx(20_999, TxKind::Call(Address::ZERO), Bytes::new());
+        assert!(is_below_intrinsic_gas(&tx));
+    }
+
+    #[test]
+    fn test_intrinsic_gas_with_calldata() {
+        let input = Bytes::from(vec![0, 0, 1, 2]);
+        let tx = build_test_tx(21_000, TxKind::Call(Address::ZERO), input);
+        assert_eq!(intrinsic_gas(&tx), 21_000 + 2 * 4 + 2 * 16);
+        assert!(is_below_intrinsic_gas(&tx));
+    }
+
+    #[test]
+    fn test_intrinsic_gas_of_contract_creation() {
+        let input = Bytes::from(vec![1; 33]);
+        let tx = build_test_tx(60_000, TxKind::Create, input);
+        assert_eq!(intrinsic_gas(&tx), 21_000 + 33 * 16 + 32_000 + 2 * 2);
+        assert!(!is_below_intrinsic_gas(&tx));
+    }
+}
@@ -1,5 +1,6 @@
 use super::{
     config::{GOLDEN_TOUCH_ADDRESS, GOLDEN_TOUCH_PRIVATE_KEY, TaikoConfig},
+    fee_recipient::is_insufficient_funds_error,
     fixed_k_signer_chainbound,
     l2_contracts_bindings::{Bridge, LibSharedData, TaikoAnchor},
 };
@@ -13,7 +14,10 @@ use alloy::{
     network::ReceiptResponse,
     primitives::{Address, B256, Bytes, U256, Uint},
     providers::{DynProvider, Provider},
-    rpc::types::{Block as RpcBlock, Transaction},
+    rpc::types::{
+        Block as RpcBlock, Transaction, TransactionRequest,
+        state::{AccountOverride, StateOverride},
+    },
     signers::Signature,
     transports::TransportErrorKind,
 };
@@ -114,6 +118,88 @@ impl L2ExecutionLayer {
         Ok(is_forced_inclusion)
     }
 
+    /// Returns true if the transaction fails when executed on top of the latest L2 block.
+    pub async fn is_transaction_reverting(&self, tx: &Transaction) -> Result<bool, Error> {
+        let tx_request = TransactionRequest::from_transaction(tx.clone());
+        let result = self.provider.read().await.call(tx_request).await;
+        match result {
+            Ok(_) => Ok(false),
+            Err(RpcError::ErrorResp(err)) => {
+                debug!(
+                    "Transaction {} fails in simulation: {}",
+                    tx.inner.tx_hash(),
+                    err.message
+                );
+                Ok(true)
+            }
+            Err(e) => {
+                self.check_for_provider_failure(Err(e), "Failed to simulate L2 transaction")
+                    .await
+            }
+        }
+    }
+
+    /// Returns the reason why the address cannot safely receive block fees, if any.
+    pub async fn get_fee_recipient_problem(
+        &self,
+        fee_recipient: Address,
+        from: Address,
+    ) -> Result<Option<String>, Error> {
+        if fee_recipient == Address::ZERO {
+            return Ok(Some("zero address".to_string()));
+        }
+
+        let code = self.provider.read().await.get_code_at(fee_recipient).await;
+        let code = self
+            .check_for_provider_failure(code, "Failed to get fee recipient code")
+            .await?;
+        if code.is_empty() {
+            return Ok(None);
+        }
+
+        let tx_request = TransactionRequest::default()
+            .from(from)
+            .to(fee_recipient)
+            .value(U256::from(1));
+        // the caller is funded, so its own balance does not fail the simulation
+        let mut state_override = StateOverride::default();
+        state_override.insert(
+            from,
+            AccountOverride {
+                balance: Some(U256::from(u128::MAX)),
+                ..Default::default()
+            },
+        );
+        let result = self
+            .provider
+            .read()
+            .await
+            .call(tx_request)
+            .overrides(state_override)
+            .await;
+        match result {
+            Ok(_) => Ok(None),
+            Err(RpcError::ErrorResp(err)) if is_insufficient_funds_error(&err.message) => {
+                warn!(
+                    "Could not simulate value transfer to fee recipient {}: {}",
+                    fee_recipient, err.message
+                );
+                Ok(None)
+            }
+            Err(RpcError::ErrorResp(err)) => Ok(Some(format!(
+                "contract rejects value transfer: {}",
+                err.message
+            ))),
+            Err(e) => {
+                self.check_for_provider_failure(
+                    Err(e),
+                    "Failed to simulate value transfer to fee recipient",
+                )
+                .await
+            }
+        }
+    }
+
     pub async fn get_latest_l2_block_id(&self) -> Result<u64, Error> {
         let block_number = self.provider.read().await.get_block_number().await;
 
@@ -0,0 +1,281 @@
+use super::{
+    PreconfBlocksDriver, PreconfDriver,
+    operation_type::OperationType,
+    preconf_blocks::{BuildPreconfBlockRequestBody, BuildPreconfBlockResponse, TaikoStatus},
+};
+use alloy::primitives::{B256, keccak256};
+use anyhow::Error;
+use std::{collections::HashSet, str::FromStr, sync::Mutex};
+
+#[derive(Copy, Clone, Debug, PartialEq, Eq, Hash)]
+pub enum DriverOperation {
+    GetStatus,
+    SubmitPreconfBlock,
+    RemovePreconfBlocks,
+}
+
+#[derive(Clone, Debug, PartialEq)]
+pub struct MockBlock {
+    pub number: u64,
+    pub hash: B256,
+    pub parent_hash: B256,
+}
+
+struct MockDriverState {
+    // the first block is the last safe block, it cannot be removed
+    blocks: Vec<MockBlock>,
+    end_of_sequencing_block_hash: B256,
+    failing_operations: HashSet<DriverOperation>,
+}
+
+/// In-memory driver keeping a chain of unsafe L2 blocks on top of the given safe block.
+pub struct MockDriver {
+    state: Mutex<MockDriverState>,
+}
+
+impl MockDriver {
+    pub fn new(safe_block_number: u64, safe_block_hash: B256) -> Self {
+        Self {
+            state: Mutex::new(MockDriverState {
+                blocks: vec![MockBlock {
+                    number: safe_block_number,
+                    hash: safe_block_hash,
+                    parent_hash: B256::ZERO,
+                }],
+                end_of_sequencing_block_hash: B256::ZERO,
+                failing_operations: HashSet::new(),
+            }),
+        }
+    }
+
+    /// Makes every following call of the operation fail until `recover` is called.
+    pub fn fail(&self, operation: DriverOperation) -> Result<(), Error> {
+        self.lock()?.failing_operations.insert(operation);
+        Ok(())
+    }
+
+    pub fn recover(&self, operation: DriverOperation) -> Result<(), Error> {
+        self.lock()?.failing_operations.remove(&operation);
+        Ok(())
+    }
+
+    pub fn head(&self) -> Result<MockBlock, Error> {
+        self.lock()?
+            .blocks
+            .last()
+            .cloned()
+            .ok_or_else(|| anyhow::anyhow!("MockDriver: no blocks"))
+    }
+
+    fn lock(&self) -> Result<std::sync::MutexGuard<'_, MockDriverState>, Error> {
+        self.state
+            .lock()
+            .map_err(|e| anyhow::anyhow!("MockDriver: failed to lock state: {}", e))
+    }
+
+    fn check_operation(state: &MockDriverState, operation: DriverOperation) -> Result<(), Error> {
+        if state.failing_operations.contains(&operation) {
+            return Err(anyhow::anyhow!("MockDriver: {:?} failed", operation));
+        }
+        Ok(())
+    }
+}
+
+impl PreconfDriver for MockDriver {
+    async fn get_status(&self) -> Result<TaikoStatus, Error> {
+        let state = self.lock()?;
+        Self::check_operation(&state, DriverOperation::GetStatus)?;
+        let head = state
+            .blocks
+            .last()
+            .ok_or_else(|| anyhow::anyhow!("MockDriver: no blocks"))?;
+        Ok(TaikoStatus {
+            highest_unsafe_l2_payload_block_id: head.number,
+            end_of_sequencing_block_hash: state.end_of_sequencing_block_hash,
+        })
+    }
+}
+
+impl PreconfBlocksDriver for MockDriver {
+    async fn submit_preconf_block(
+        &self,
+        request_body: &BuildPreconfBlockRequestBody,
+        _operation_type: OperationType,
+    ) -> Result<Option<BuildPreconfBlockResponse>, Error> {
+        let mut state = self.lock()?;
+        Self::check_operation(&state, DriverOperation::SubmitPreconfBlock)?;
+
+        let executable_data = &request_body.executable_data;
+        let parent_hash = B256::from_str(&executable_data.parent_hash)?;
+        let head = state
+            .blocks
+            .last()
+            .ok_or_else(|| anyhow::anyhow!("MockDriver: no blocks"))?;
+        if executable_data.block_number != head.number + 1 || parent_hash != head.hash {
+            return Err(anyhow::anyhow!(
+                "MockDriver: block {} with parent {} does not extend the head {} {}",
+                executable_data.block_number,
+                parent_hash,
+                head.number,
+                head.hash
+            ));
+        }
+
+        let hash = keccak256(
+            [
+                parent_hash.as_slice(),
+                &executable_data.timestamp.to_be_bytes(),
+                executable_data.transactions.as_bytes(),
+            ]
+            .concat(),
+        );
+        state.blocks.push(MockBlock {
+            number: executable_data.block_number,
+            hash,
+            parent_hash,
+        });
+        if request_body.end_of_sequencing {
+            state.end_of_sequencing_block_hash = hash;
+        }
+
+        Ok(Some(BuildPreconfBlockResponse {
+            number: executable_data.block_number,
+            hash,
+            parent_hash,
+            tx_count: 0,
+        }))
+    }
+
+    async fn remove_preconf_blocks(&self, new_last_block_id: u64) -> Result<(), Error> {
+        let mut state = self.lock()?;
+        Self::check_operation(&state, DriverOperation::RemovePreconfBlocks)?;
+
+        let Some(position) = state
+            .blocks
+            .iter()
+            .position(|block| block.number == new_last_block_id)
+        else {
+            return Err(anyhow::anyhow!(
+                "MockDriver: cannot remove blocks above unknown block {}",
+                new_last_block_id
+            ));
+        };
+        state.blocks.truncate(position + 1);
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::taiko::preconf_blocks::ExecutableData;
+
+    fn request(
+        parent: &MockBlock,
+        timestamp: u64,
+        transactions: &str,
+    ) -> BuildPreconfBlockRequestBody {
+        BuildPreconfBlockRequestBody {
+            executable_data: ExecutableData {
+                base_fee_per_gas: 0,
+                block_number: parent.number + 1,
+                extra_data: String::new(),
+                fee_recipient: String::new(),
+                gas_limit: 0,
+                parent_hash: format!("0x{}", hex::encode(parent.hash)),
+                timestamp,
+                transactions: transactions.to_string(),
+            },
+            end_of_sequencing: false,
+            is_forced_inclusion: false,
+        }
+    }
+
+    async fn submit(
+        driver: &MockDriver,
+        timestamp: u64,
+        transactions: &str,
+    ) -> Result<MockBlock, Error> {
+        let head = driver.head()?;
+        driver
+            .submit_preconf_block(
+                &request(&head, timestamp, transactions),
+                OperationType::Preconfirm,
+            )
+            .await?;
+        driver.head()
+    }
+
+    #[tokio::test]
+    async fn test_submit_blocks() {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+
+        let block = submit(&driver, 1000, "0x01").await.unwrap();
+        assert_eq!(block.number, 11);
+        assert_eq!(block.parent_hash, B256::repeat_byte(1));
+        let block = submit(&driver, 1002, "0x02").await.unwrap();
+        assert_eq!(block.number, 12);
+        assert_eq!(
+            driver
+                .get_status()
+                .await
+                .unwrap()
+                .highest_unsafe_l2_payload_block_id,
+            12
+        );
+
+        // a block which does not extend the head is rejected
+        let stale_parent = MockBlock {
+            number: 10,
+            hash: B256::repeat_byte(1),
+            parent_hash: B256::ZERO,
+        };
+        assert!(
+            driver
+                .submit_preconf_block(
+                    &request(&stale_parent, 1004, "0x03"),
+                    OperationType::Preconfirm
+                )
+                .await
+                .is_err()
+        );
+        assert_eq!(driver.head().unwrap(), block);
+    }
+
+    #[tokio::test]
+    async fn test_reorg() {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+        let first = submit(&driver, 1000, "0x01").await.unwrap();
+        let replaced = submit(&driver, 1002, "0x02").await.unwrap();
+        submit(&driver, 1004, "0x03").await.unwrap();
+
+        driver.remove_preconf_blocks(first.number).await.unwrap();
+        assert_eq!(driver.head().unwrap(), first);
+
+        let rebuilt = submit(&driver, 1002, "0x04").await.unwrap();
+        assert_eq!(rebuilt.number, replaced.number);
+        assert_eq!(rebuilt.parent_hash, first.hash);
+        assert_ne!(rebuilt.hash, replaced.hash);
+    }
+
+    #[tokio::test]
+    async fn test_reorg_failure() {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+        submit(&driver, 1000, "0x01").await.unwrap();
+        let head = submit(&driver, 1002, "0x02").await.unwrap();
+
+        // the chain cannot be reorged below the safe block
+        assert!(driver.remove_preconf_blocks(9).await.is_err());
+        assert_eq!(driver.head().unwrap(), head);
+
+        driver.fail(DriverOperation::RemovePreconfBlocks).unwrap();
+        assert!(driver.remove_preconf_blocks(10).await.is_err());
+        assert_eq!(driver.head().unwrap(), head);
+
+        driver
+            .recover(DriverOperation::RemovePreconfBlocks)
+            .unwrap();
+        driver.remove_preconf_blocks(10).await.unwrap();
+        assert_eq!(driver.head().unwrap().number, 10);
+    }
+}
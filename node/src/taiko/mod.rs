@@ -1,9 +1,25 @@
+mod anchor_tx_check;
+mod block_number;
+mod block_slot;
 pub mod config;
+mod engine_api_exporter;
+mod equivocation_guard;
+mod fee_recipient;
 mod fixed_k_signer_chainbound;
+mod gas_consistency;
+mod intrinsic_gas;
 mod l2_contracts_bindings;
 mod l2_execution_layer;
+#[cfg(test)]
+pub mod mock_driver;
 pub mod operation_type;
+mod poll_cap;
 pub mod preconf_blocks;
+mod preconf_summary_publisher;
+mod submitted_blocks;
+mod tx_buffer_controller;
+mod tx_order;
+mod tx_selection_report;
 
 use crate::{
     ethereum_l1::EthereumL1,
@@ -13,33 +29,56 @@ use crate::{
         l2_slot_info::L2SlotInfo,
         l2_tx_lists::{self, PreBuiltTxList},
     },
-    utils::rpc_client::{HttpRPCClient, JSONRPCClient},
+    utils::{
+        rpc_client::{HttpRPCClient, JSONRPCClient},
+        trace_context::TraceContext,
+    },
 };
 use alloy::{
-    consensus::BlockHeader,
+    consensus::{BlockHeader, Transaction as _},
     eips::BlockNumberOrTag,
     primitives::{Address, B256},
 };
 use anyhow::Error;
-use config::TaikoConfig;
+use block_number::KnownL2Head;
+use config::{EqualTipOrder, TaikoConfig};
+use engine_api_exporter::EngineApiExporter;
+use equivocation_guard::EquivocationGuard;
+use http::HeaderMap;
 use l2_contracts_bindings::LibSharedData;
 use l2_execution_layer::L2ExecutionLayer;
 use operation_type::OperationType;
+use preconf_summary_publisher::{PreconfSummary, PreconfSummaryPublisher};
 use serde_json::Value;
 use std::{
     cmp::{max, min},
+    str::FromStr,
     sync::Arc,
     time::Duration,
 };
-use tracing::{debug, trace};
+use submitted_blocks::SubmittedBlocks;
+use tracing::{Instrument, debug, error, trace, warn};
+use tx_buffer_controller::TxBufferController;
+use tx_selection_report::{
+    REASON_BELOW_INTRINSIC_GAS, REASON_BLOB_TX, REASON_OVER_MAX_TX_DATA_SIZE,
+    REASON_REVERTS_IN_PRE_SIMULATION, TxSelection, TxSelectionReporter,
+};
 
 pub struct Taiko {
-    l2_execution_layer: L2ExecutionLayer,
+    l2_execution_layer: Arc<L2ExecutionLayer>,
     taiko_geth_auth_rpc: JSONRPCClient,
     driver_preconf_rpc: HttpRPCClient,
     driver_status_rpc: HttpRPCClient,
     ethereum_l1: Arc<EthereumL1>,
     metrics: Arc<Metrics>,
+    fee_recipient: Address,
+    tx_selection_reporter: Option<TxSelectionReporter>,
+    preconf_summary_publisher: Option<PreconfSummaryPublisher>,
+    engine_api_exporter: Option<EngineApiExporter>,
+    equivocation_guard: Option<EquivocationGuard>,
+    submitted_blocks: SubmittedBlocks,
+    known_l2_head: KnownL2Head,
+    tx_buffer_controller: TxBufferController,
     config: TaikoConfig,
 }
 
@@ -50,10 +89,26 @@ impl Taiko {
         metrics: Arc<Metrics>,
         taiko_config: TaikoConfig,
     ) -> Result<Self, Error> {
+        let l2_execution_layer = L2ExecutionLayer::new(taiko_config.clone())
+            .await
+            .map_err(|e| anyhow::anyhow!("Failed to create L2ExecutionLayer: {}", e))?;
+        let fee_recipient = Self::resolve_fee_recipient(
+            &l2_execution_layer,
+            &taiko_config,
+            ethereum_l1.execution_layer.get_preconfer_alloy_address(),
+        )
+        .await?;
+        if taiko_config.fee_recipients.enforce_registered_fee_recipient {
+            fee_recipient::check_registered_fee_recipient(
+                fee_recipient,
+                ethereum_l1
+                    .execution_layer
+                    .get_registered_sequencer_address()
+                    .await?,
+            )?;
+        }
+        let l2_execution_layer = Arc::new(l2_execution_layer);
         Ok(Self {
-            l2_execution_layer: L2ExecutionLayer::new(taiko_config.clone())
-                .await
-                .map_err(|e| anyhow::anyhow!("Failed to create L2ExecutionLayer: {}", e))?,
             taiko_geth_auth_rpc: JSONRPCClient::new_with_timeout_and_jwt(
                 &taiko_config.taiko_geth_auth_url,
                 taiko_config.rpc_l2_execution_layer_timeout,
@@ -79,11 +134,103 @@ impl Taiko {
                 anyhow::anyhow!("Failed to create HttpRPCClient for driver status: {}", e)
             })?,
             ethereum_l1,
-            metrics,
+            metrics: metrics.clone(),
+            fee_recipient,
+            tx_selection_reporter: taiko_config
+                .tx_selection
+                .report_dir
+                .as_deref()
+                .map(TxSelectionReporter::new)
+                .transpose()?,
+            preconf_summary_publisher: taiko_config
+                .preconf_summary_push
+                .url
+                .as_deref()
+                .map(|url| {
+                    PreconfSummaryPublisher::new(
+                        url,
+                        taiko_config.preconf_summary_push.timeout,
+                        taiko_config.preconf_summary_push.max_attempts,
+                    )
+                })
+                .transpose()?,
+            engine_api_exporter: match (
+                taiko_config.engine_api_export_url.as_deref(),
+                taiko_config.engine_api_export_jwt_secret_bytes,
+            ) {
+                (Some(url), Some(jwt_secret)) => Some(EngineApiExporter::new(
+                    url,
+                    &jwt_secret,
+                    taiko_config.engine_api_export_timeout,
+                    l2_execution_layer.clone(),
+                )?),
+                _ => None,
+            },
+            equivocation_guard: taiko_config
+                .equivocation_guard_file
+                .as_deref()
+                .map(|path| EquivocationGuard::new(path, metrics.clone()))
+                .transpose()?,
+            submitted_blocks: SubmittedBlocks::new(taiko_config.driver_head_reconcile_blocks),
+            known_l2_head: KnownL2Head::default(),
+            tx_buffer_controller: TxBufferController::new(
+                taiko_config.tx_selection.adaptive_min_txs_per_poll,
+                taiko_config.tx_selection.max_txs_per_poll,
+                metrics,
+            ),
+            l2_execution_layer,
             config: taiko_config,
         })
     }
 
+    async fn resolve_fee_recipient(
+        l2_execution_layer: &L2ExecutionLayer,
+        taiko_config: &TaikoConfig,
+        preconfer_address: Address,
+    ) -> Result<Address, Error> {
+        let primary = match &taiko_config.fee_recipients.fee_recipient {
+            Some(fee_recipient) => Address::from_str(fee_recipient)
+                .map_err(|e| format!("invalid address {fee_recipient}: {e}")),
+            None => Ok(preconfer_address),
+        };
+        let primary = match primary {
+            Ok(address) => match l2_execution_layer
+                .get_fee_recipient_problem(address, preconfer_address)
+                .await?
+            {
+                Some(problem) => Err(format!("{address}: {problem}")),
+                None => Ok(address),
+            },
+            Err(err) => Err(err),
+        };
+        fee_recipient::select_fee_recipient(
+            primary,
+            taiko_config.fee_recipients.fallback_fee_recipient,
+        )
+    }
+
+    pub fn get_fee_recipient(&self) -> Address {
+        self.fee_recipient
+    }
+
+    /// All fee recipients the blocks of this node can have. Blocks built before a restart can
+    /// carry another recipient than the resolved one, e.g. when the fallback was in effect.
+    pub fn get_own_fee_recipients(&self) -> Vec<Address> {
+        let mut fee_recipients = vec![
+            self.fee_recipient,
+            self.ethereum_l1.execution_layer.get_preconfer_address(),
+        ];
+        fee_recipients.extend(
+            self.config
+                .fee_recipients
+                .fee_recipient
+                .as_deref()
+                .and_then(|fee_recipient| Address::from_str(fee_recipient).ok()),
+        );
+        fee_recipients.extend(self.config.fee_recipients.fallback_fee_recipient);
+        fee_recipients
+    }
+
     pub async fn get_pending_l2_tx_list_from_taiko_geth(
         &self,
         base_fee: u64,
@@ -95,23 +242,14 @@ impl Taiko {
             batches_ready_to_send,
             self.config.min_bytes_per_tx_list,
         );
-        let params = vec![
-            Value::String(format!(
-                "0x{}",
-                hex::encode(self.ethereum_l1.execution_layer.get_preconfer_address())
-            )), // beneficiary address
-            Value::from(base_fee), // baseFee
-            Value::Number(
-                self.ethereum_l1
-                    .execution_layer
-                    .get_config_block_max_gas_limit()
-                    .into(),
-            ), // blockMaxGasLimit
-            Value::Number(max_bytes_per_tx_list.into()), // maxBytesPerTxList (128KB by default)
-            Value::Array(vec![]),  // locals (empty array)
-            Value::Number(1.into()), // maxTransactionsLists
-            Value::Number(0.into()), // minTip
-        ];
+        let params = tx_pool_content_params(
+            self.fee_recipient,
+            base_fee,
+            self.ethereum_l1
+                .execution_layer
+                .get_config_block_max_gas_limit(),
+            max_bytes_per_tx_list,
+        );
 
         let result = self
             .taiko_geth_auth_rpc
@@ -122,12 +260,96 @@ impl Taiko {
             let mut tx_lists = l2_tx_lists::decompose_pending_lists_json_from_geth(result)
                 .map_err(|e| anyhow::anyhow!("Failed to decompose L2 tx lists: {}", e))?;
             // ignoring rest of tx lists, only one list per L2 block is processed
-            Ok(Some(tx_lists.remove(0)))
+            let mut tx_list = tx_lists.remove(0);
+            if self.config.block_validation.validate_gas_used
+                && let Err(err) = gas_consistency::check_gas_used(
+                    &tx_list,
+                    self.ethereum_l1
+                        .execution_layer
+                        .get_config_block_max_gas_limit()
+                        .into(),
+                )
+            {
+                error!("⛔ Rejecting pending L2 tx list from taiko geth: {}", err);
+                return Err(err.into());
+            }
+            let mut dropped_txs = Vec::new();
+            if self.config.tx_selection.drop_blob_txs {
+                tx_list = drop_blob_txs(tx_list, &mut dropped_txs)?;
+            }
+            if self.config.tx_selection.max_tx_data_size != 0 {
+                tx_list = drop_txs_over_max_data_size(
+                    tx_list,
+                    self.config.tx_selection.max_tx_data_size,
+                    &mut dropped_txs,
+                )?;
+            }
+            let max_txs_per_poll = if self.tx_buffer_controller.is_enabled() {
+                self.tx_buffer_controller.size()
+            } else {
+                self.config.tx_selection.max_txs_per_poll
+            };
+            if max_txs_per_poll != 0 {
+                let over_cap = poll_cap::txs_over_poll_cap(
+                    &tx_list.tx_list,
+                    max_txs_per_poll,
+                    base_fee,
+                    self.config.tx_selection.equal_tip_order,
+                );
+                tx_list = remove_txs(tx_list, &over_cap)?;
+            }
+            let buffered_txs = tx_list.tx_list.len() as u64;
+            if self.config.tx_selection.drop_txs_below_intrinsic_gas {
+                tx_list = drop_txs_below_intrinsic_gas(tx_list, &mut dropped_txs)?;
+            }
+            if self.config.tx_selection.pre_simulate_txs {
+                tx_list = self.drop_reverting_txs(tx_list, &mut dropped_txs).await?;
+            }
+            let equal_tip_order = self.config.tx_selection.equal_tip_order;
+            if equal_tip_order != EqualTipOrder::List {
+                tx_list = order_txs(tx_list, base_fee, equal_tip_order)?;
+            }
+            self.record_dropped_txs(dropped_txs);
+            if self.tx_buffer_controller.is_enabled() {
+                self.tx_buffer_controller
+                    .observe(tx_list.tx_list.len() as u64, buffered_txs);
+            }
+            Ok(Some(tx_list))
         } else {
             Ok(None)
         }
     }
 
+    /// Each transaction is simulated separately against the latest L2 state,
+    /// so a transaction depending on a previous one from the same list can be dropped as well.
+    async fn drop_reverting_txs(
+        &self,
+        tx_list: PreBuiltTxList,
+        dropped_txs: &mut Vec<TxSelection>,
+    ) -> Result<PreBuiltTxList, Error> {
+        let mut reverting = Vec::with_capacity(tx_list.tx_list.len());
+        for tx in &tx_list.tx_list {
+            reverting.push(self.l2_execution_layer.is_transaction_reverting(tx).await?);
+        }
+        dropped_txs.extend(tx_selection_report::dropped_txs(
+            &tx_list.tx_list,
+            &reverting,
+            REASON_REVERTS_IN_PRE_SIMULATION,
+        ));
+        remove_txs(tx_list, &reverting)
+    }
+
+    fn record_dropped_txs(&self, dropped_txs: Vec<TxSelection>) {
+        if let Some(reporter) = &self.tx_selection_reporter
+            && let Err(err) = reporter.set_dropped_txs(dropped_txs)
+        {
+            warn!(
+                "Failed to record dropped txs for tx selection report: {}",
+                err
+            );
+        }
+    }
+
     pub async fn get_balance(&self, address: Address) -> Result<alloy::primitives::U256, Error> {
         self.l2_execution_layer.get_balance(address).await
     }
@@ -226,6 +448,10 @@ impl Taiko {
             )
             .await?;
 
+        if block == BlockNumberOrTag::Latest {
+            self.known_l2_head.set(parent_id);
+        }
+
         trace!(
             timestamp = %l2_slot_timestamp,
             parent_hash = %parent_hash,
@@ -251,12 +477,71 @@ impl Taiko {
         end_of_sequencing: bool,
         is_forced_inclusion: bool,
         operation_type: OperationType,
+    ) -> Result<Option<preconf_blocks::BuildPreconfBlockResponse>, Error> {
+        // every log line of the block, from the anchor to the reports, carries its trace id
+        let (trace_context, span) = self.block_trace_context(
+            l2_slot_info.parent_id() + 1,
+            &format!("0x{}", hex::encode(l2_slot_info.parent_hash())),
+        );
+
+        self.build_and_submit_l2_block(
+            l2_block,
+            anchor_origin_height,
+            l2_slot_info,
+            end_of_sequencing,
+            is_forced_inclusion,
+            operation_type,
+            trace_context.as_ref(),
+        )
+        .instrument(span)
+        .await
+    }
+
+    /// The trace context sent to the driver with the block and the span carrying its trace id,
+    /// both are derived once so the log lines and the driver headers cannot diverge.
+    fn block_trace_context(
+        &self,
+        block_number: u64,
+        parent_hash: &str,
+    ) -> (Option<TraceContext>, tracing::Span) {
+        if !self.config.propagate_trace_context {
+            return (None, tracing::Span::none());
+        }
+        let trace_context = TraceContext::for_block(block_number, parent_hash);
+        let span = tracing::info_span!(
+            "l2_block",
+            number = block_number,
+            trace_id = %trace_context.trace_id()
+        );
+        (Some(trace_context), span)
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    async fn build_and_submit_l2_block(
+        &self,
+        l2_block: L2Block,
+        anchor_origin_height: u64,
+        l2_slot_info: &L2SlotInfo,
+        end_of_sequencing: bool,
+        is_forced_inclusion: bool,
+        operation_type: OperationType,
+        trace_context: Option<&TraceContext>,
     ) -> Result<Option<preconf_blocks::BuildPreconfBlockResponse>, Error> {
         tracing::debug!(
             "Submitting new L2 block to the Taiko driver with {} txs",
             l2_block.prebuilt_tx_list.tx_list.len()
         );
 
+        // on error the block is dropped and the next heartbeat builds on the current head
+        if self.config.block_validation.validate_block_number
+            && let Err(err) = self
+                .known_l2_head
+                .check(l2_slot_info.parent_id() + 1, operation_type)
+        {
+            error!("⛔ Rejecting L2 block before sealing: {}", err);
+            return Err(err.into());
+        }
+
         let anchor_block_state_root = self
             .ethereum_l1
             .execution_layer
@@ -277,21 +562,35 @@ impl Taiko {
                 l2_slot_info.base_fee(),
             )
             .await?;
+        let selected_txs = self
+            .tx_selection_reporter
+            .as_ref()
+            .map(|_| l2_block.prebuilt_tx_list.tx_list.clone());
         let tx_list = std::iter::once(anchor_tx)
             .chain(l2_block.prebuilt_tx_list.tx_list.into_iter())
             .collect::<Vec<_>>();
+        if self.config.block_validation.validate_anchor_tx
+            && let Err(err) =
+                anchor_tx_check::check_anchor_tx(&tx_list, self.config.taiko_anchor_address)
+        {
+            error!("⛔ Rejecting L2 block before sealing: {}", err);
+            return Err(err.into());
+        }
 
         let tx_list_bytes = l2_tx_lists::encode_and_compress(&tx_list)?;
+        let tx_hashes = self.preconf_summary_publisher.as_ref().map(|_| {
+            tx_list
+                .iter()
+                .map(|tx| *tx.inner.tx_hash())
+                .collect::<Vec<_>>()
+        });
         let extra_data = vec![sharing_pctg];
 
         let executable_data = preconf_blocks::ExecutableData {
             base_fee_per_gas: l2_slot_info.base_fee(),
             block_number: l2_slot_info.parent_id() + 1,
             extra_data: format!("0x{:0>64}", hex::encode(extra_data)),
-            fee_recipient: format!(
-                "0x{}",
-                hex::encode(self.ethereum_l1.execution_layer.get_preconfer_address())
-            ),
+            fee_recipient: format!("0x{}", hex::encode(self.fee_recipient)),
             gas_limit: 241_000_000u64,
             parent_hash: format!("0x{}", hex::encode(l2_slot_info.parent_hash())),
             timestamp: l2_block.timestamp_sec,
@@ -304,14 +603,104 @@ impl Taiko {
             is_forced_inclusion,
         };
 
+        if self.config.block_validation.validate_block_slot
+            && matches!(operation_type, OperationType::Preconfirm)
+            && let Err(err) = block_slot::check_slot_not_passed(
+                self.ethereum_l1.slot_clock.as_ref(),
+                l2_slot_info.slot_timestamp(),
+            )
+        {
+            error!("⛔ Discarding L2 block before submission: {}", err);
+            return Err(err);
+        }
+
+        if let Some(guard) = &self.equivocation_guard
+            && matches!(operation_type, OperationType::Preconfirm)
+            && let Err(err) = guard.check(&request_body.executable_data)
+        {
+            error!("⛔ Refusing to equivocate: {}", err);
+            return Err(err);
+        }
+
+        let preconfirmed_block = self
+            .submit_preconf_block(&request_body, operation_type, trace_context)
+            .await?
+            .map(|block| preconf_blocks::BuildPreconfBlockResponse {
+                tx_count: tx_list.len() - 1,
+                ..block
+            });
+
+        self.metrics.inc_blocks_preconfirmed();
+        if let Some(block) = &preconfirmed_block {
+            self.known_l2_head.set(block.number);
+        }
+        if let Err(err) = self.submitted_blocks.record(&request_body) {
+            warn!("Failed to record submitted block: {}", err);
+        }
+        if let Some(guard) = &self.equivocation_guard
+            && let Err(err) = guard.record(&request_body.executable_data).await
+        {
+            warn!("Failed to record block in the equivocation guard: {}", err);
+        }
+
+        if let (Some(publisher), Some(tx_hashes), Some(block)) = (
+            &self.preconf_summary_publisher,
+            tx_hashes,
+            &preconfirmed_block,
+        ) {
+            publisher.publish(PreconfSummary {
+                block_number: block.number,
+                block_hash: block.hash,
+                parent_hash: block.parent_hash,
+                timestamp: l2_block.timestamp_sec,
+                is_forced_inclusion,
+                end_of_sequencing,
+                tx_hashes,
+            });
+        }
+
+        if let (Some(exporter), Some(block)) = (&self.engine_api_exporter, &preconfirmed_block) {
+            exporter.export(block.number);
+        }
+
+        if let (Some(reporter), Some(selected_txs)) = (&self.tx_selection_reporter, selected_txs) {
+            match reporter
+                .write_report(
+                    l2_slot_info.parent_id() + 1,
+                    l2_block.timestamp_sec,
+                    is_forced_inclusion,
+                    &selected_txs,
+                )
+                .await
+            {
+                Ok(path) => debug!("Tx selection report written to {}", path.display()),
+                Err(err) => warn!("Failed to write tx selection report: {}", err),
+            }
+        }
+
+        Ok(preconfirmed_block)
+    }
+
+    async fn submit_preconf_block(
+        &self,
+        request_body: &preconf_blocks::BuildPreconfBlockRequestBody,
+        operation_type: OperationType,
+        trace_context: Option<&TraceContext>,
+    ) -> Result<Option<preconf_blocks::BuildPreconfBlockResponse>, Error> {
         const API_ENDPOINT: &str = "preconfBlocks";
 
+        let headers = match trace_context {
+            Some(trace_context) => trace_context.to_headers()?,
+            None => HeaderMap::new(),
+        };
+
         let response = self
             .call_driver(
                 &self.driver_preconf_rpc,
                 http::Method::POST,
                 API_ENDPOINT,
-                &request_body,
+                request_body,
+                &headers,
                 operation_type,
             )
             .await?;
@@ -325,11 +714,52 @@ impl Taiko {
             tracing::error!("Block was preconfirmed, but failed to decode response from driver.");
         }
 
-        self.metrics.inc_blocks_preconfirmed();
-
         Ok(preconfirmed_block)
     }
 
+    /// Removes unsafe L2 blocks above `new_last_block_id` from the driver and geth.
+    pub async fn remove_preconf_blocks(&self, new_last_block_id: u64) -> Result<(), Error> {
+        debug!(
+            "Removing preconfirmed L2 blocks above {} from the Taiko driver",
+            new_last_block_id
+        );
+
+        const API_ENDPOINT: &str = "preconfBlocks";
+        let request_body = preconf_blocks::RemovePreconfBlockRequestBody { new_last_block_id };
+
+        let response = self
+            .call_driver(
+                &self.driver_preconf_rpc,
+                http::Method::DELETE,
+                API_ENDPOINT,
+                &request_body,
+                &HeaderMap::new(),
+                OperationType::RemovePreconfBlocks,
+            )
+            .await?;
+
+        trace!("Response from remove preconfBlocks: {:?}", response);
+        self.known_l2_head.set(new_last_block_id);
+
+        self.submitted_blocks.remove_above(new_last_block_id)
+    }
+
+    pub fn is_driver_head_reconciliation_enabled(&self) -> bool {
+        self.submitted_blocks.is_enabled()
+    }
+
+    /// Submits again the recorded blocks missing on top of the current driver head,
+    /// returns the number of the blocks submitted.
+    pub async fn reconcile_driver_head(
+        &self,
+        head_number: u64,
+        head_hash: &B256,
+    ) -> Result<u64, Error> {
+        self.submitted_blocks
+            .reconcile(self, head_number, head_hash)
+            .await
+    }
+
     pub async fn get_status(&self) -> Result<preconf_blocks::TaikoStatus, Error> {
         trace!("Get status form taiko driver");
 
@@ -342,6 +772,7 @@ impl Taiko {
                 http::Method::GET,
                 API_ENDPOINT,
                 &request_body,
+                &HeaderMap::new(),
                 OperationType::Status,
             )
             .await?;
@@ -359,6 +790,7 @@ impl Taiko {
         method: http::Method,
         endpoint: &str,
         payload: &T,
+        headers: &HeaderMap,
         operation_type: OperationType,
     ) -> Result<Value, Error>
     where
@@ -372,7 +804,7 @@ impl Taiko {
         let start_time = std::time::Instant::now();
 
         match client
-            .retry_request_with_timeout(method, endpoint, payload, max_duration)
+            .retry_request_with_timeout(method, endpoint, payload, headers, max_duration)
             .await
         {
             Ok(response) => {
@@ -456,16 +888,192 @@ pub trait PreconfDriver {
     async fn get_status(&self) -> Result<preconf_blocks::TaikoStatus, Error>;
 }
 
+/// Operations on the unsafe L2 blocks of the driver.
+pub trait PreconfBlocksDriver: PreconfDriver {
+    async fn submit_preconf_block(
+        &self,
+        request_body: &preconf_blocks::BuildPreconfBlockRequestBody,
+        operation_type: OperationType,
+    ) -> Result<Option<preconf_blocks::BuildPreconfBlockResponse>, Error>;
+
+    async fn remove_preconf_blocks(&self, new_last_block_id: u64) -> Result<(), Error>;
+}
+
 impl PreconfDriver for Taiko {
     async fn get_status(&self) -> Result<preconf_blocks::TaikoStatus, Error> {
         Taiko::get_status(self).await
     }
 }
 
+impl PreconfBlocksDriver for Taiko {
+    async fn submit_preconf_block(
+        &self,
+        request_body: &preconf_blocks::BuildPreconfBlockRequestBody,
+        operation_type: OperationType,
+    ) -> Result<Option<preconf_blocks::BuildPreconfBlockResponse>, Error> {
+        // blocks submitted again by the driver head reconciliation keep their trace id
+        let (trace_context, span) = self.block_trace_context(
+            request_body.executable_data.block_number,
+            &request_body.executable_data.parent_hash,
+        );
+        Taiko::submit_preconf_block(self, request_body, operation_type, trace_context.as_ref())
+            .instrument(span)
+            .await
+    }
+
+    async fn remove_preconf_blocks(&self, new_last_block_id: u64) -> Result<(), Error> {
+        Taiko::remove_preconf_blocks(self, new_last_block_id).await
+    }
+}
+
 pub fn decode_anchor_id_from_tx_data(data: &[u8]) -> Result<u64, Error> {
     L2ExecutionLayer::decode_anchor_id_from_tx_data(data)
 }
 
+fn drop_txs_below_intrinsic_gas(
+    tx_list: PreBuiltTxList,
+    dropped_txs: &mut Vec<TxSelection>,
+) -> Result<PreBuiltTxList, Error> {
+    let below_intrinsic_gas = tx_list
+        .tx_list
+        .iter()
+        .map(|tx| {
+            let below = intrinsic_gas::is_below_intrinsic_gas(tx);
+            if below {
+                warn!(
+                    "Dropping tx {}: gas limit {} is below intrinsic gas {}",
+                    tx.inner.tx_hash(),
+                    tx.gas_limit(),
+                    intrinsic_gas::intrinsic_gas(tx)
+                );
+            }
+            below
+        })
+        .collect::<Vec<_>>();
+    dropped_txs.extend(tx_selection_report::dropped_txs(
+        &tx_list.tx_list,
+        &below_intrinsic_gas,
+        REASON_BELOW_INTRINSIC_GAS,
+    ));
+    remove_txs(tx_list, &below_intrinsic_gas)
+}
+
+/// The tx list is proposed as the batch data, whether in blobs or calldata, without
+/// the sidecars of the L2 blob txs, so they are never included in a block.
+fn drop_blob_txs(
+    tx_list: PreBuiltTxList,
+    dropped_txs: &mut Vec<TxSelection>,
+) -> Result<PreBuiltTxList, Error> {
+    let blob_txs = tx_list
+        .tx_list
+        .iter()
+        .map(|tx| {
+            let is_blob_tx = tx.inner.is_eip4844();
+            if is_blob_tx {
+                warn!("Dropping blob tx {}", tx.inner.tx_hash());
+            }
+            is_blob_tx
+        })
+        .collect::<Vec<_>>();
+    dropped_txs.extend(tx_selection_report::dropped_txs(
+        &tx_list.tx_list,
+        &blob_txs,
+        REASON_BLOB_TX,
+    ));
+    remove_txs(tx_list, &blob_txs)
+}
+
+/// Txs with a large calldata would dominate the DA cost of the batch,
+/// they are left in the pool.
+fn drop_txs_over_max_data_size(
+    tx_list: PreBuiltTxList,
+    max_tx_data_size: u64,
+    dropped_txs: &mut Vec<TxSelection>,
+) -> Result<PreBuiltTxList, Error> {
+    let over_max_data_size = tx_list
+        .tx_list
+        .iter()
+        .map(|tx| {
+            let data_size = tx.input().len() as u64;
+            let over = data_size > max_tx_data_size;
+            if over {
+                debug!(
+                    "Skipping tx {}: calldata size {} bytes is over the limit of {} bytes",
+                    tx.inner.tx_hash(),
+                    data_size,
+                    max_tx_data_size
+                );
+            }
+            over
+        })
+        .collect::<Vec<_>>();
+    dropped_txs.extend(tx_selection_report::dropped_txs(
+        &tx_list.tx_list,
+        &over_max_data_size,
+        REASON_OVER_MAX_TX_DATA_SIZE,
+    ));
+    remove_txs(tx_list, &over_max_data_size)
+}
+
+/// Params of taikoAuth_txPoolContentWithMinTip.
+fn tx_pool_content_params(
+    beneficiary: Address,
+    base_fee: u64,
+    block_max_gas_limit: u32,
+    max_bytes_per_tx_list: u64,
+) -> Vec<Value> {
+    vec![
+        Value::String(format!("0x{}", hex::encode(beneficiary))), // beneficiary address
+        Value::from(base_fee),                                    // baseFee
+        Value::Number(block_max_gas_limit.into()),                // blockMaxGasLimit
+        Value::Number(max_bytes_per_tx_list.into()), // maxBytesPerTxList (128KB by default)
+        Value::Array(vec![]),                        // locals (empty array)
+        Value::Number(1.into()),                     // maxTransactionsLists
+        Value::Number(0.into()),                     // minTip
+    ]
+}
+
+/// Orders the txs by tip with the equal tips in the configured order, the pool order of
+/// equal tips is not deterministic.
+fn order_txs(
+    tx_list: PreBuiltTxList,
+    base_fee: u64,
+    equal_tip_order: EqualTipOrder,
+) -> Result<PreBuiltTxList, Error> {
+    let txs = tx_order::order_by_tip(tx_list.tx_list, base_fee, equal_tip_order);
+    let bytes_length = l2_tx_lists::encode_and_compress(&txs)?.len() as u64;
+    Ok(PreBuiltTxList {
+        tx_list: txs,
+        estimated_gas_used: tx_list.estimated_gas_used,
+        bytes_length,
+    })
+}
+
+fn remove_txs(tx_list: PreBuiltTxList, removed: &[bool]) -> Result<PreBuiltTxList, Error> {
+    if !removed.contains(&true) {
+        return Ok(tx_list);
+    }
+
+    let txs = tx_list
+        .tx_list
+        .into_iter()
+        .zip(removed)
+        .filter(|(_, removed)| !**removed)
+        .map(|(tx, _)| tx)
+        .collect::<Vec<_>>();
+    debug!(
+        "Dropped {} txs from the pending tx list",
+        removed.iter().filter(|removed| **removed).count()
+    );
+
+    let bytes_length = l2_tx_lists::encode_and_compress(&txs)?.len() as u64;
+    Ok(PreBuiltTxList {
+        tx_list: txs,
+        estimated_gas_used: tx_list.estimated_gas_used,
+        bytes_length,
+    })
+}
+
 /// Calculate the max bytes per tx list based on the number of batches ready to send.
 /// The max bytes per tx list is reduced exponentially by given factor.
 fn calculate_max_bytes_per_tx_list(
@@ -489,6 +1097,160 @@ fn calculate_max_bytes_per_tx_list(
 mod test {
     use super::*;
 
+    fn get_test_tx_list() -> PreBuiltTxList {
+        serde_json::from_str::<Vec<PreBuiltTxList>>(include_str!(
+            "../utils/tx_lists_test_response_from_geth.json"
+        ))
+        .unwrap()
+        .remove(0)
+    }
+
+    #[test]
+    fn test_remove_reverting_txs() {
+        let tx_list = get_test_tx_list();
+        let kept_tx_hash = *tx_list.tx_list[0].inner.tx_hash();
+
+        let filtered = remove_txs(tx_list, &[false, true]).unwrap();
+        assert_eq!(filtered.tx_list.len(), 1);
+        assert_eq!(*filtered.tx_list[0].inner.tx_hash(), kept_tx_hash);
+        assert_eq!(
+            filtered.bytes_length,
+            l2_tx_lists::encode_and_compress(&filtered.tx_list)
+                .unwrap()
+                .len() as u64
+        );
+    }
+
+    #[test]
+    fn test_remove_reverting_txs_keeps_list_without_reverts() {
+        let tx_list = get_test_tx_list();
+        let bytes_length = tx_list.bytes_length;
+
+        let filtered = remove_txs(tx_list, &[false, false]).unwrap();
+        assert_eq!(filtered.tx_list.len(), 2);
+        assert_eq!(filtered.bytes_length, bytes_length);
+    }
+
+    #[test]
+    fn test_drop_txs_below_intrinsic_gas() {
+        let valid_tx = intrinsic_gas::tests::build_test_tx(
+            21_000,
+            alloy::primitives::TxKind::Call(Address::ZERO),
+            alloy::primitives::Bytes::new(),
+        );
+        let invalid_tx = intrinsic_gas::tests::build_test_tx(
+            21_000,
+            alloy::primitives::TxKind::Call(Address::ZERO),
+            alloy::primitives::Bytes::from(vec![1; 4]),
+        );
+        let invalid_tx_hash = invalid_tx.inner.tx_hash().to_string();
+        let tx_list = PreBuiltTxList {
+            tx_list: vec![valid_tx.clone(), invalid_tx],
+            estimated_gas_used: 0,
+            bytes_length: 0,
+        };
+
+        let mut dropped_txs = Vec::new();
+        let filtered = drop_txs_below_intrinsic_gas(tx_list, &mut dropped_txs).unwrap();
+        assert_eq!(filtered.tx_list.len(), 1);
+        assert_eq!(
+            filtered.tx_list[0].inner.tx_hash(),
+            valid_tx.inner.tx_hash()
+        );
+        assert_eq!(dropped_txs.len(), 1);
+        assert_eq!(dropped_txs[0].hash, invalid_tx_hash);
+        assert_eq!(dropped_txs[0].reason, REASON_BELOW_INTRINSIC_GAS);
+    }
+
+    #[test]
+    fn test_drop_blob_txs() {
+        use alloy::consensus::{
+            SignableTransaction, TxEip4844, TxEnvelope, transaction::Recovered,
+        };
+
+        let tx = intrinsic_gas::tests::build_test_tx(
+            21_000,
+            alloy::primitives::TxKind::Call(Address::ZERO),
+            alloy::primitives::Bytes::new(),
+        );
+        let blob_tx = alloy::rpc::types::Transaction {
+            inner: Recovered::new_unchecked(
+                TxEnvelope::from(
+                    TxEip4844 {
+                        chain_id: 167000,
+                        gas_limit: 21_000,
+                        blob_versioned_hashes: vec![B256::repeat_byte(1)],
+                        ..Default::default()
+                    }
+                    .into_signed(alloy::primitives::Signature::test_signature()),
+                ),
+                Address::ZERO,
+            ),
+            block_hash: None,
+            block_number: None,
+            transaction_index: None,
+            effective_gas_price: None,
+        };
+        let blob_tx_hash = blob_tx.inner.tx_hash().to_string();
+        let tx_list = PreBuiltTxList {
+            tx_list: vec![blob_tx, tx.clone()],
+            estimated_gas_used: 0,
+            bytes_length: 0,
+        };
+
+        let mut dropped_txs = Vec::new();
+        let filtered = drop_blob_txs(tx_list, &mut dropped_txs).unwrap();
+        assert_eq!(filtered.tx_list.len(), 1);
+        assert_eq!(filtered.tx_list[0].inner.tx_hash(), tx.inner.tx_hash());
+        assert_eq!(dropped_txs.len(), 1);
+        assert_eq!(dropped_txs[0].hash, blob_tx_hash);
+        assert_eq!(dropped_txs[0].reason, REASON_BLOB_TX);
+    }
+
+    #[test]
+    fn test_drop_txs_over_max_data_size() {
+        let small_tx = intrinsic_gas::tests::build_test_tx(
+            100_000,
+            alloy::primitives::TxKind::Call(Address::ZERO),
+            alloy::primitives::Bytes::from(vec![1; 100]),
+        );
+        let large_tx = intrinsic_gas::tests::build_test_tx(
+            100_000,
+            alloy::primitives::TxKind::Call(Address::ZERO),
+            alloy::primitives::Bytes::from(vec![1; 101]),
+        );
+        let large_tx_hash = large_tx.inner.tx_hash().to_string();
+        let tx_list = PreBuiltTxList {
+            tx_list: vec![large_tx, small_tx.clone()],
+            estimated_gas_used: 0,
+            bytes_length: 0,
+        };
+
+        let mut dropped_txs = Vec::new();
+        let filtered = drop_txs_over_max_data_size(tx_list.clone(), 100, &mut dropped_txs).unwrap();
+        assert_eq!(filtered.tx_list.len(), 1);
+        assert_eq!(
+            filtered.tx_list[0].inner.tx_hash(),
+            small_tx.inner.tx_hash()
+        );
+        assert_eq!(dropped_txs.len(), 1);
+        assert_eq!(dropped_txs[0].hash, large_tx_hash);
+        assert_eq!(dropped_txs[0].reason, REASON_OVER_MAX_TX_DATA_SIZE);
+
+        let mut dropped_txs = Vec::new();
+        let filtered = drop_txs_over_max_data_size(tx_list, 101, &mut dropped_txs).unwrap();
+        assert_eq!(filtered.tx_list.len(), 2);
+        assert!(dropped_txs.is_empty());
+    }
+
+    #[test]
+    fn test_tx_pool_content_params() {
+        let params = tx_pool_content_params(Address::repeat_byte(1), 10, 240_000_000, 131_072);
+        assert_eq!(params.len(), 7);
+        assert_eq!(params[0], Value::String(format!("0x{}", "01".repeat(20))));
+        assert_eq!(params[3], Value::from(131_072));
+    }
+
     #[test]
     fn test_calculate_max_bytes_per_tx_list() {
         let max_bytes = 1000; // 128KB
@@ -5,6 +5,7 @@ pub enum OperationType {
     Preconfirm,
     Reanchor,
     Status,
+    RemovePreconfBlocks,
 }
 
 impl fmt::Display for OperationType {
@@ -13,6 +14,7 @@ impl fmt::Display for OperationType {
             OperationType::Preconfirm => "Preconfirm",
             OperationType::Reanchor => "Reanchor",
             OperationType::Status => "Status",
+            OperationType::RemovePreconfBlocks => "RemovePreconfBlocks",
         };
         write!(f, "{s}")
     }
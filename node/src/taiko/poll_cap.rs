@@ -0,0 +1,221 @@
+use super::config::EqualTipOrder;
+use alloy::{
+    consensus::Transaction as _,
+    primitives::{Address, B256},
+    rpc::types::Transaction,
+};
+use std::{
+    cmp::Reverse,
+    collections::{BinaryHeap, HashMap, VecDeque},
+};
+
+/// Marks the txs which do not fit into `max_txs` txs per poll, they stay in the pool for
+/// the next polls. The txs with the highest tip are kept, but a tx is kept only together
+/// with the preceding txs of its sender so the nonces stay continuous.
+/// Zero `max_txs` keeps all the txs.
+pub fn txs_over_poll_cap(
+    txs: &[Transaction],
+    max_txs: u64,
+    base_fee: u64,
+    equal_tip_order: EqualTipOrder,
+) -> Vec<bool> {
+    let max_txs = usize::try_from(max_txs).unwrap_or(usize::MAX);
+    if max_txs == 0 || txs.len() <= max_txs {
+        return vec![false; txs.len()];
+    }
+
+    // txs of a sender come from the pool in nonce order
+    let mut sender_txs: HashMap<Address, VecDeque<usize>> = HashMap::new();
+    for (index, tx) in txs.iter().enumerate() {
+        sender_txs
+            .entry(tx.inner.signer())
+            .or_default()
+            .push_back(index);
+    }
+
+    // equal tips are ordered by the tx hash if configured, then by the position in the list
+    let priority = |index: usize| {
+        let tx_hash = match equal_tip_order {
+            EqualTipOrder::List => B256::ZERO,
+            EqualTipOrder::TxHash => *txs[index].inner.tx_hash(),
+        };
+        (
+            txs[index].effective_tip_per_gas(base_fee).unwrap_or(0),
+            Reverse((tx_hash, index)),
+        )
+    };
+    // next tx of every sender
+    let mut candidates = BinaryHeap::new();
+    for queue in sender_txs.values_mut() {
+        if let Some(index) = queue.pop_front() {
+            candidates.push(priority(index));
+        }
+    }
+
+    let mut over_cap = vec![true; txs.len()];
+    for _ in 0..max_txs {
+        let Some((_, Reverse(index))) = candidates.pop() else {
+            break;
+        };
+        over_cap[index] = false;
+        if let Some(next) = sender_txs
+            .get_mut(&txs[index].inner.signer())
+            .and_then(|queue| queue.pop_front())
+        {
+            candidates.push(priority(next));
+        }
+    }
+    over_cap
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{metrics::Metrics, taiko::tx_buffer_controller::TxBufferController};
+    use alloy::{
+        consensus::{SignableTransaction, TxEnvelope, TxLegacy, transaction::Recovered},
+        primitives::{Bytes, Signature, TxKind, U256},
+    };
+    use std::sync::Arc;
+
+    const BASE_FEE: u64 = 10;
+
+    fn build_tx(sender: Address, nonce: u64, tip: u128) -> Transaction {
+        let tx = TxLegacy {
+            chain_id: Some(167000),
+            nonce,
+            gas_price: u128::from(BASE_FEE) + tip,
+            gas_limit: 21_000,
+            to: TxKind::Call(Address::ZERO),
+            value: U256::ZERO,
+            input: Bytes::new(),
+        };
+        Transaction {
+            inner: Recovered::new_unchecked(
+                TxEnvelope::Legacy(tx.into_signed(Signature::test_signature())),
+                sender,
+            ),
+            block_hash: None,
+            block_number: None,
+            transaction_index: None,
+            effective_gas_price: None,
+        }
+    }
+
+    fn sender(index: u64) -> Address {
+        let mut address = [0u8; 20];
+        address[12..].copy_from_slice(&index.to_be_bytes());
+        Address::from(address)
+    }
+
+    #[test]
+    fn test_only_top_txs_by_tip_are_fetched() {
+        const POOL_SIZE: u64 = 50_000;
+        const MAX_TXS: u64 = 100;
+        // 7919 is coprime with the pool size, so every tip is unique
+        let txs = (0..POOL_SIZE)
+            .map(|i| build_tx(sender(i), 0, u128::from(i * 7919 % POOL_SIZE)))
+            .collect::<Vec<_>>();
+
+        let over_cap = txs_over_poll_cap(&txs, MAX_TXS, BASE_FEE, EqualTipOrder::List);
+        let kept = txs
+            .iter()
+            .zip(&over_cap)
+            .filter(|(_, over_cap)| !**over_cap)
+            .map(|(tx, _)| tx.effective_tip_per_gas(BASE_FEE).unwrap())
+            .collect::<Vec<_>>();
+        assert_eq!(kept.len(), 100);
+        assert!(
+            kept.iter()
+                .all(|tip| *tip >= u128::from(POOL_SIZE - MAX_TXS))
+        );
+    }
+
+    #[test]
+    fn test_sender_nonce_order_is_kept() {
+        let txs = vec![
+            build_tx(sender(1), 0, 1),
+            build_tx(sender(1), 1, 100),
+            build_tx(sender(2), 0, 50),
+            build_tx(sender(3), 0, 10),
+        ];
+
+        // the high tip tx of sender 1 needs its low tip predecessor
+        assert_eq!(
+            txs_over_poll_cap(&txs, 2, BASE_FEE, EqualTipOrder::List),
+            vec![true, true, false, false]
+        );
+        assert_eq!(
+            txs_over_poll_cap(&txs, 3, BASE_FEE, EqualTipOrder::List),
+            vec![false, true, false, false]
+        );
+    }
+
+    #[test]
+    fn test_equal_tip_order() {
+        let txs = (1..=4)
+            .map(|i| build_tx(sender(i), i, 10))
+            .collect::<Vec<_>>();
+        let kept = |txs: &[Transaction], equal_tip_order| {
+            let mut kept = txs
+                .iter()
+                .zip(txs_over_poll_cap(txs, 2, BASE_FEE, equal_tip_order))
+                .filter(|(_, over_cap)| !*over_cap)
+                .map(|(tx, _)| *tx.inner.tx_hash())
+                .collect::<Vec<_>>();
+            kept.sort();
+            kept
+        };
+        let mut reversed = txs.clone();
+        reversed.reverse();
+
+        // the first txs of the list are kept
+        assert_eq!(
+            txs_over_poll_cap(&txs, 2, BASE_FEE, EqualTipOrder::List),
+            vec![false, false, true, true]
+        );
+        assert_ne!(
+            kept(&txs, EqualTipOrder::List),
+            kept(&reversed, EqualTipOrder::List)
+        );
+
+        // the txs with the lowest hashes are kept whatever the order of the list
+        let mut lowest_hashes = txs.iter().map(|tx| *tx.inner.tx_hash()).collect::<Vec<_>>();
+        lowest_hashes.sort();
+        lowest_hashes.truncate(2);
+        assert_eq!(kept(&txs, EqualTipOrder::TxHash), lowest_hashes);
+        assert_eq!(kept(&reversed, EqualTipOrder::TxHash), lowest_hashes);
+    }
+
+    #[test]
+    fn test_adjusted_buffer_size_caps_the_poll() {
+        let controller = TxBufferController::new(100, 500, Arc::new(Metrics::new()));
+        let txs = (0..1000)
+            .map(|i| build_tx(sender(i), 0, u128::from(i)))
+            .collect::<Vec<_>>();
+        let taken_txs = |max_txs| {
+            txs_over_poll_cap(&txs, max_txs, BASE_FEE, EqualTipOrder::List)
+                .iter()
+                .filter(|over_cap| !**over_cap)
+                .count() as u64
+        };
+
+        assert_eq!(taken_txs(controller.size()), 500);
+        // only a few of the taken txs are used, the next poll takes less
+        controller.observe(10, taken_txs(controller.size()));
+        assert_eq!(taken_txs(controller.size()), 400);
+    }
+
+    #[test]
+    fn test_small_pool_is_not_capped() {
+        let txs = vec![build_tx(sender(1), 0, 1), build_tx(sender(2), 0, 2)];
+        assert_eq!(
+            txs_over_poll_cap(&txs, 2, BASE_FEE, EqualTipOrder::List),
+            vec![false, false]
+        );
+        assert_eq!(
+            txs_over_poll_cap(&txs, 0, BASE_FEE, EqualTipOrder::List),
+            vec![false, false]
+        );
+    }
+}
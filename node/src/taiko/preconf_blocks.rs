@@ -2,7 +2,7 @@ use alloy::primitives::B256;
 use hex::FromHex;
 use serde::{Deserialize, Deserializer, Serialize};
 
-#[derive(Debug, Serialize, Deserialize)]
+#[derive(Clone, Debug, Serialize, Deserialize)]
 #[serde(rename_all = "camelCase")]
 pub struct BuildPreconfBlockRequestBody {
     pub executable_data: ExecutableData,
@@ -15,6 +15,8 @@ pub struct BuildPreconfBlockResponse {
     pub number: u64,
     pub hash: B256,
     pub parent_hash: B256,
+    // txs of the block without the anchor tx, not in the driver response
+    pub tx_count: usize,
 }
 
 impl BuildPreconfBlockResponse {
@@ -29,6 +31,7 @@ impl BuildPreconfBlockResponse {
             .ok()?,
             hash: Self::to_b256(header.get("hash")?.as_str()?)?,
             parent_hash: Self::to_b256(header.get("parentHash")?.as_str()?)?,
+            tx_count: 0,
         })
     }
 
@@ -38,7 +41,7 @@ impl BuildPreconfBlockResponse {
     }
 }
 
-#[derive(Debug, Serialize, Deserialize)]
+#[derive(Clone, Debug, Serialize, Deserialize)]
 #[serde(rename_all = "camelCase")]
 pub struct ExecutableData {
     pub base_fee_per_gas: u64,
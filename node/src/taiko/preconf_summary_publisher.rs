@@ -0,0 +1,150 @@
+use alloy::primitives::B256;
+use anyhow::Error;
+use serde::Serialize;
+use std::time::Duration;
+use tracing::{debug, warn};
+
+const RETRY_DELAY: Duration = Duration::from_millis(200);
+
+#[derive(Serialize, Debug, Clone, PartialEq)]
+pub struct PreconfSummary {
+    pub block_number: u64,
+    pub block_hash: B256,
+    pub parent_hash: B256,
+    pub timestamp: u64,
+    pub is_forced_inclusion: bool,
+    pub end_of_sequencing: bool,
+    pub tx_hashes: Vec<B256>,
+}
+
+/// Pushes a summary of every preconfirmed L2 block to an external HTTP endpoint.
+/// The push runs in the background, so a slow or failing endpoint never delays block building.
+pub struct PreconfSummaryPublisher {
+    client: reqwest::Client,
+    url: reqwest::Url,
+    max_attempts: u64,
+}
+
+impl PreconfSummaryPublisher {
+    pub fn new(url: &str, timeout: Duration, max_attempts: u64) -> Result<Self, Error> {
+        let client = reqwest::Client::builder().timeout(timeout).build()?;
+        Ok(Self {
+            client,
+            url: reqwest::Url::parse(url)?,
+            max_attempts: max_attempts.max(1),
+        })
+    }
+
+    pub fn publish(&self, summary: PreconfSummary) {
+        let client = self.client.clone();
+        let url = self.url.clone();
+        let max_attempts = self.max_attempts;
+        tokio::spawn(async move {
+            if let Err(err) = push_summary(&client, &url, &summary, max_attempts).await {
+                warn!(
+                    "Failed to push preconf summary of block {}: {}",
+                    summary.block_number, err
+                );
+            }
+        });
+    }
+}
+
+async fn push_summary(
+    client: &reqwest::Client,
+    url: &reqwest::Url,
+    summary: &PreconfSummary,
+    max_attempts: u64,
+) -> Result<(), Error> {
+    let body = serde_json::to_vec(summary)?;
+    let mut last_error = anyhow::anyhow!("no attempts made");
+    for attempt in 1..=max_attempts {
+        let result = client
+            .post(url.clone())
+            .header("content-type", "application/json")
+            .body(body.clone())
+            .send()
+            .await;
+        match result {
+            Ok(response) if response.status().is_success() => {
+                debug!(
+                    "Preconf summary of block {} pushed, attempt {}",
+                    summary.block_number, attempt
+                );
+                return Ok(());
+            }
+            Ok(response) => {
+                last_error =
+                    anyhow::anyhow!("endpoint responded with status {}", response.status());
+            }
+            Err(err) => {
+                last_error = anyhow::anyhow!("request failed: {}", err);
+            }
+        }
+        if attempt < max_attempts {
+            tokio::time::sleep(RETRY_DELAY).await;
+        }
+    }
+    Err(last_error)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn test_summary() -> PreconfSummary {
+        PreconfSummary {
+            block_number: 12,
+            block_hash: B256::repeat_byte(2),
+            parent_hash: B256::repeat_byte(1),
+            timestamp: 1000,
+            is_forced_inclusion: false,
+            end_of_sequencing: true,
+            tx_hashes: vec![B256::repeat_byte(3)],
+        }
+    }
+
+    #[tokio::test]
+    async fn test_summary_is_pushed() {
+        let mut server = mockito::Server::new_async().await;
+        let mock = server
+            .mock("POST", "/")
+            .match_header("content-type", "application/json")
+            .match_body(mockito::Matcher::Json(serde_json::json!({
+                "block_number": 12,
+                "block_hash": B256::repeat_byte(2).to_string(),
+                "parent_hash": B256::repeat_byte(1).to_string(),
+                "timestamp": 1000,
+                "is_forced_inclusion": false,
+                "end_of_sequencing": true,
+                "tx_hashes": [B256::repeat_byte(3).to_string()],
+            })))
+            .with_status(200)
+            .create_async()
+            .await;
+
+        let url = reqwest::Url::parse(&server.url()).unwrap();
+        push_summary(&reqwest::Client::new(), &url, &test_summary(), 3)
+            .await
+            .unwrap();
+        mock.assert_async().await;
+    }
+
+    #[tokio::test]
+    async fn test_push_is_retried() {
+        let mut server = mockito::Server::new_async().await;
+        let mock = server
+            .mock("POST", "/")
+            .with_status(503)
+            .expect(3)
+            .create_async()
+            .await;
+
+        let url = reqwest::Url::parse(&server.url()).unwrap();
+        let err = push_summary(&reqwest::Client::new(), &url, &test_summary(), 3)
+            .await
+            .unwrap_err();
+        assert!(err.to_string().contains("503"));
+        mock.assert_async().await;
+    }
+}
@@ -0,0 +1,239 @@
+use super::{
+    PreconfBlocksDriver, operation_type::OperationType,
+    preconf_blocks::BuildPreconfBlockRequestBody,
+};
+use alloy::primitives::B256;
+use anyhow::Error;
+use std::{collections::VecDeque, str::FromStr, sync::Mutex};
+use tracing::{info, warn};
+
+/// Keeps the last blocks submitted to the driver, so they can be submitted again when
+/// the driver head falls behind them, e.g. after a driver restart losing unsafe blocks.
+pub struct SubmittedBlocks {
+    // zero disables the recording
+    capacity: usize,
+    blocks: Mutex<VecDeque<BuildPreconfBlockRequestBody>>,
+}
+
+impl SubmittedBlocks {
+    pub fn new(capacity: u64) -> Self {
+        Self {
+            capacity: usize::try_from(capacity).unwrap_or(usize::MAX),
+            blocks: Mutex::new(VecDeque::new()),
+        }
+    }
+
+    pub fn is_enabled(&self) -> bool {
+        self.capacity != 0
+    }
+
+    fn lock(
+        &self,
+    ) -> Result<std::sync::MutexGuard<'_, VecDeque<BuildPreconfBlockRequestBody>>, Error> {
+        self.blocks
+            .lock()
+            .map_err(|e| anyhow::anyhow!("SubmittedBlocks: failed to lock blocks: {}", e))
+    }
+
+    pub fn record(&self, request_body: &BuildPreconfBlockRequestBody) -> Result<(), Error> {
+        if !self.is_enabled() {
+            return Ok(());
+        }
+        let mut blocks = self.lock()?;
+        // a block replacing recorded blocks starts a new chain
+        let block_number = request_body.executable_data.block_number;
+        blocks.retain(|block| block.executable_data.block_number < block_number);
+        blocks.push_back(request_body.clone());
+        while blocks.len() > self.capacity {
+            blocks.pop_front();
+        }
+        Ok(())
+    }
+
+    /// Forgets the blocks removed from the driver by a reorg.
+    pub fn remove_above(&self, new_last_block_id: u64) -> Result<(), Error> {
+        self.lock()?
+            .retain(|block| block.executable_data.block_number <= new_last_block_id);
+        Ok(())
+    }
+
+    /// Returns the recorded blocks on top of the given head. The head must be the parent
+    /// of the first of them, otherwise the chain has diverged and nothing is returned.
+    fn missing_blocks(
+        &self,
+        head_number: u64,
+        head_hash: &B256,
+    ) -> Result<Vec<BuildPreconfBlockRequestBody>, Error> {
+        let blocks = self.lock()?;
+        let missing = blocks
+            .iter()
+            .filter(|block| block.executable_data.block_number > head_number)
+            .cloned()
+            .collect::<Vec<_>>();
+        let Some(first) = missing.first() else {
+            return Ok(vec![]);
+        };
+        if first.executable_data.block_number != head_number + 1
+            || B256::from_str(&first.executable_data.parent_hash)? != *head_hash
+        {
+            return Err(anyhow::anyhow!(
+                "Recorded block {} does not extend the driver head {} {}",
+                first.executable_data.block_number,
+                head_number,
+                head_hash
+            ));
+        }
+        Ok(missing)
+    }
+
+    /// Submits again the blocks missing on top of the driver head, returns their number.
+    pub async fn reconcile(
+        &self,
+        driver: &impl PreconfBlocksDriver,
+        head_number: u64,
+        head_hash: &B256,
+    ) -> Result<u64, Error> {
+        let missing = self.missing_blocks(head_number, head_hash)?;
+        if missing.is_empty() {
+            return Ok(0);
+        }
+        warn!(
+            "Driver head {} is behind the submitted blocks, submitting {} missing blocks again",
+            head_number,
+            missing.len()
+        );
+        for request_body in &missing {
+            driver
+                .submit_preconf_block(request_body, OperationType::Preconfirm)
+                .await
+                .map_err(|e| {
+                    anyhow::anyhow!(
+                        "Failed to submit again block {}: {}",
+                        request_body.executable_data.block_number,
+                        e
+                    )
+                })?;
+        }
+        info!(
+            "✅ Driver head reconciled up to block {}",
+            head_number + missing.len() as u64
+        );
+        Ok(missing.len() as u64)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::taiko::{
+        mock_driver::{MockBlock, MockDriver},
+        preconf_blocks::ExecutableData,
+    };
+
+    fn request(parent: &MockBlock, timestamp: u64) -> BuildPreconfBlockRequestBody {
+        BuildPreconfBlockRequestBody {
+            executable_data: ExecutableData {
+                base_fee_per_gas: 0,
+                block_number: parent.number + 1,
+                extra_data: String::new(),
+                fee_recipient: String::new(),
+                gas_limit: 0,
+                parent_hash: format!("0x{}", hex::encode(parent.hash)),
+                timestamp,
+                transactions: format!("0x{timestamp:x}"),
+            },
+            end_of_sequencing: false,
+            is_forced_inclusion: false,
+        }
+    }
+
+    async fn submit_and_record(
+        driver: &MockDriver,
+        submitted_blocks: &SubmittedBlocks,
+        timestamp: u64,
+    ) -> MockBlock {
+        let request_body = request(&driver.head().unwrap(), timestamp);
+        driver
+            .submit_preconf_block(&request_body, OperationType::Preconfirm)
+            .await
+            .unwrap();
+        submitted_blocks.record(&request_body).unwrap();
+        driver.head().unwrap()
+    }
+
+    #[tokio::test]
+    async fn test_short_driver_head_is_reconciled() {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+        let submitted_blocks = SubmittedBlocks::new(8);
+        let first = submit_and_record(&driver, &submitted_blocks, 1000).await;
+        submit_and_record(&driver, &submitted_blocks, 1002).await;
+        let last = submit_and_record(&driver, &submitted_blocks, 1004).await;
+
+        // the driver lost the last two blocks
+        driver.remove_preconf_blocks(first.number).await.unwrap();
+        assert_eq!(
+            submitted_blocks
+                .reconcile(&driver, first.number, &first.hash)
+                .await
+                .unwrap(),
+            2
+        );
+        assert_eq!(driver.head().unwrap(), last);
+
+        // nothing is missing anymore
+        assert_eq!(
+            submitted_blocks
+                .reconcile(&driver, last.number, &last.hash)
+                .await
+                .unwrap(),
+            0
+        );
+        assert_eq!(driver.head().unwrap(), last);
+    }
+
+    #[tokio::test]
+    async fn test_diverged_driver_head_is_not_reconciled() {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+        let submitted_blocks = SubmittedBlocks::new(8);
+        let first = submit_and_record(&driver, &submitted_blocks, 1000).await;
+        submit_and_record(&driver, &submitted_blocks, 1002).await;
+
+        driver.remove_preconf_blocks(first.number).await.unwrap();
+        assert!(
+            submitted_blocks
+                .reconcile(&driver, first.number, &B256::repeat_byte(2))
+                .await
+                .is_err()
+        );
+        assert_eq!(driver.head().unwrap(), first);
+
+        // blocks removed by a reorg are not submitted again
+        submitted_blocks.remove_above(first.number).unwrap();
+        assert_eq!(
+            submitted_blocks
+                .reconcile(&driver, first.number, &first.hash)
+                .await
+                .unwrap(),
+            0
+        );
+    }
+
+    #[tokio::test]
+    async fn test_recorded_blocks_are_bounded() {
+        let driver = MockDriver::new(10, B256::repeat_byte(1));
+        let submitted_blocks = SubmittedBlocks::new(2);
+        let safe = driver.head().unwrap();
+        for timestamp in [1000, 1002, 1004] {
+            submit_and_record(&driver, &submitted_blocks, timestamp).await;
+        }
+
+        driver.remove_preconf_blocks(safe.number).await.unwrap();
+        // the oldest block is not recorded anymore
+        assert!(
+            submitted_blocks
+                .reconcile(&driver, safe.number, &safe.hash)
+                .await
+                .is_err()
+        );
+    }
+}
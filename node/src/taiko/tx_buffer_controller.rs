@@ -0,0 +1,142 @@
+use crate::metrics::Metrics;
+use std::sync::{
+    Arc,
+    atomic::{AtomicU64, Ordering},
+};
+use tracing::info;
+
+// number of adjustments needed to go from the lower to the upper bound
+const ADJUSTMENT_STEPS: u64 = 4;
+
+/// Adjusts the max number of txs taken from a tx pool poll to the observed hit rate, the
+/// share of the taken txs used in the block. Polls filling the buffer with txs that are
+/// used grow it to take more txs per block, polls using only a small part of the fetched
+/// txs shrink it to keep less txs in memory.
+pub struct TxBufferController {
+    min_txs: u64,
+    max_txs: u64,
+    current_txs: AtomicU64,
+    metrics: Arc<Metrics>,
+}
+
+impl TxBufferController {
+    /// `min_txs` equal to 0 disables the adjustment.
+    pub fn new(min_txs: u64, max_txs: u64, metrics: Arc<Metrics>) -> Self {
+        metrics.set_tx_buffer_size(max_txs);
+        Self {
+            min_txs: min_txs.min(max_txs),
+            max_txs,
+            current_txs: AtomicU64::new(max_txs),
+            metrics,
+        }
+    }
+
+    pub fn is_enabled(&self) -> bool {
+        self.min_txs != 0 && self.min_txs < self.max_txs
+    }
+
+    pub fn size(&self) -> u64 {
+        self.current_txs.load(Ordering::Relaxed)
+    }
+
+    /// Called with the number of txs of a poll used in the block and the number of txs
+    /// taken from it, returns the new buffer size.
+    pub fn observe(&self, used_txs: u64, buffered_txs: u64) -> u64 {
+        let size = self.size();
+        let step = ((self.max_txs - self.min_txs) / ADJUSTMENT_STEPS).max(1);
+
+        // hit rate of at least 90% of a full buffer grows it, up to 25% shrinks it
+        let new_size = if buffered_txs == 0 {
+            size
+        } else if buffered_txs >= size && used_txs.saturating_mul(10) >= buffered_txs * 9 {
+            size.saturating_add(step).min(self.max_txs)
+        } else if used_txs.saturating_mul(4) <= buffered_txs {
+            size.saturating_sub(step).max(self.min_txs)
+        } else {
+            size
+        };
+
+        if new_size != size {
+            info!(
+                "Adjusting tx buffer size from {} to {}, used txs: {}, buffered txs: {}",
+                size, new_size, used_txs, buffered_txs
+            );
+            self.current_txs.store(new_size, Ordering::Relaxed);
+            self.metrics.set_tx_buffer_size(new_size);
+        }
+
+        new_size
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn controller(min_txs: u64, max_txs: u64) -> TxBufferController {
+        TxBufferController::new(min_txs, max_txs, Arc::new(Metrics::new()))
+    }
+
+    #[test]
+    fn test_buffer_shrinks_on_low_throughput() {
+        let controller = controller(100, 500);
+        assert!(controller.is_enabled());
+        assert_eq!(controller.size(), 500);
+
+        // only a few of the fetched txs are used
+        assert_eq!(controller.observe(10, 500), 400);
+        assert_eq!(controller.observe(10, 400), 300);
+        for _ in 0..10 {
+            let size = controller.size();
+            controller.observe(10, size);
+        }
+        assert_eq!(controller.size(), 100);
+        assert!(controller.metrics.gather().contains("tx_buffer_size 100"));
+    }
+
+    #[test]
+    fn test_buffer_grows_on_high_throughput() {
+        let controller = controller(100, 500);
+        for _ in 0..10 {
+            let size = controller.size();
+            controller.observe(0, size);
+        }
+        assert_eq!(controller.size(), 100);
+
+        // every poll fills the buffer with txs used in the block
+        assert_eq!(controller.observe(100, 100), 200);
+        assert_eq!(controller.observe(190, 200), 300);
+        for _ in 0..10 {
+            let size = controller.size();
+            controller.observe(size, size);
+        }
+        assert_eq!(controller.size(), 500);
+        assert!(controller.metrics.gather().contains("tx_buffer_size 500"));
+    }
+
+    #[test]
+    fn test_buffer_is_kept_on_medium_throughput() {
+        let controller = controller(100, 500);
+        assert_eq!(controller.observe(250, 500), 500);
+        assert_eq!(controller.observe(449, 500), 500);
+    }
+
+    #[test]
+    fn test_buffer_is_kept_when_not_filled() {
+        let controller = controller(100, 500);
+        // empty pool
+        assert_eq!(controller.observe(0, 0), 500);
+        assert_eq!(controller.observe(10, 10), 500);
+
+        assert_eq!(controller.observe(0, 500), 400);
+        // all the fetched txs are used but the pool had less txs than the buffer
+        assert_eq!(controller.observe(150, 150), 400);
+    }
+
+    #[test]
+    fn test_buffer_adjustment_disabled() {
+        assert!(!controller(0, 500).is_enabled());
+        assert!(!controller(500, 500).is_enabled());
+        assert!(!controller(100, 0).is_enabled());
+    }
+}
@@ -0,0 +1,162 @@
+use super::config::EqualTipOrder;
+use alloy::{
+    consensus::Transaction as _,
+    primitives::{Address, B256},
+    rpc::types::Transaction,
+};
+use std::{
+    cmp::Reverse,
+    collections::{BinaryHeap, HashMap, VecDeque},
+};
+
+/// Orders the txs by the effective tip, highest first, keeping the txs of a sender in nonce
+/// order. Equal tips are ordered as configured, then by the position in the list.
+pub fn order_by_tip(
+    txs: Vec<Transaction>,
+    base_fee: u64,
+    equal_tip_order: EqualTipOrder,
+) -> Vec<Transaction> {
+    // txs of a sender come from the pool in nonce order
+    let mut sender_txs: HashMap<Address, VecDeque<usize>> = HashMap::new();
+    for (index, tx) in txs.iter().enumerate() {
+        sender_txs
+            .entry(tx.inner.signer())
+            .or_default()
+            .push_back(index);
+    }
+
+    let priority = |index: usize| {
+        let tx_hash = match equal_tip_order {
+            EqualTipOrder::List => B256::ZERO,
+            EqualTipOrder::TxHash => *txs[index].inner.tx_hash(),
+        };
+        (
+            txs[index].effective_tip_per_gas(base_fee).unwrap_or(0),
+            Reverse((tx_hash, index)),
+        )
+    };
+    // next tx of every sender
+    let mut candidates = BinaryHeap::new();
+    for queue in sender_txs.values_mut() {
+        if let Some(index) = queue.pop_front() {
+            candidates.push(priority(index));
+        }
+    }
+
+    let mut order = Vec::with_capacity(txs.len());
+    while let Some((_, Reverse((_, index)))) = candidates.pop() {
+        order.push(index);
+        if let Some(next) = sender_txs
+            .get_mut(&txs[index].inner.signer())
+            .and_then(|queue| queue.pop_front())
+        {
+            candidates.push(priority(next));
+        }
+    }
+
+    let mut txs = txs.into_iter().map(Some).collect::<Vec<_>>();
+    order
+        .into_iter()
+        .filter_map(|index| txs[index].take())
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use alloy::{
+        consensus::{SignableTransaction, TxEnvelope, TxLegacy, transaction::Recovered},
+        primitives::{Bytes, Signature, TxKind, U256},
+    };
+
+    const BASE_FEE: u64 = 10;
+
+    fn build_tx(sender: Address, nonce: u64, tip: u128) -> Transaction {
+        let tx = TxLegacy {
+            chain_id: Some(167000),
+            nonce,
+            gas_price: u128::from(BASE_FEE) + tip,
+            gas_limit: 21_000,
+            to: TxKind::Call(Address::ZERO),
+            value: U256::ZERO,
+            input: Bytes::new(),
+        };
+        Transaction {
+            inner: Recovered::new_unchecked(
+                TxEnvelope::Legacy(tx.into_signed(Signature::test_signature())),
+                sender,
+            ),
+            block_hash: None,
+            block_number: None,
+            transaction_index: None,
+            effective_gas_price: None,
+        }
+    }
+
+    fn sender(index: u64) -> Address {
+        let mut address = [0u8; 20];
+        address[12..].copy_from_slice(&index.to_be_bytes());
+        Address::from(address)
+    }
+
+    fn hashes(txs: &[Transaction]) -> Vec<B256> {
+        txs.iter().map(|tx| *tx.inner.tx_hash()).collect()
+    }
+
+    #[test]
+    fn test_sender_nonce_order_is_kept() {
+        let txs = vec![
+            build_tx(sender(1), 0, 1),
+            build_tx(sender(1), 1, 100),
+            build_tx(sender(2), 0, 50),
+            build_tx(sender(3), 0, 10),
+        ];
+
+        // the high tip tx of sender 1 follows its low tip predecessor
+        let ordered = order_by_tip(txs.clone(), BASE_FEE, EqualTipOrder::TxHash);
+        assert_eq!(
+            hashes(&ordered),
+            hashes(&[
+                txs[2].clone(),
+                txs[3].clone(),
+                txs[0].clone(),
+                txs[1].clone()
+            ])
+        );
+    }
+
+    #[test]
+    fn test_equal_tip_order() {
+        let txs = (1..=4)
+            .map(|i| build_tx(sender(i), i, 10))
+            .collect::<Vec<_>>();
+        let mut reversed = txs.clone();
+        reversed.reverse();
+
+        // the order of the list is kept
+        assert_eq!(
+            hashes(&order_by_tip(txs.clone(), BASE_FEE, EqualTipOrder::List)),
+            hashes(&txs)
+        );
+        assert_eq!(
+            hashes(&order_by_tip(
+                reversed.clone(),
+                BASE_FEE,
+                EqualTipOrder::List
+            )),
+            hashes(&reversed)
+        );
+
+        // ascending hashes whatever the order of the list
+        let mut sorted_hashes = hashes(&txs);
+        sorted_hashes.sort();
+        assert_eq!(
+            hashes(&order_by_tip(txs, BASE_FEE, EqualTipOrder::TxHash)),
+            sorted_hashes
+        );
+        assert_eq!(
+            hashes(&order_by_tip(reversed, BASE_FEE, EqualTipOrder::TxHash)),
+            sorted_hashes
+        );
+    }
+}
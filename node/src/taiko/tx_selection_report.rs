@@ -0,0 +1,238 @@
+use alloy::{consensus::Transaction as _, rpc::types::Transaction};
+use anyhow::Error;
+use serde::Serialize;
+use std::{
+    path::{Path, PathBuf},
+    sync::Mutex,
+};
+
+pub const REASON_SELECTED_BY_TAIKO_GETH: &str = "selected by taiko geth";
+pub const REASON_FORCED_INCLUSION: &str = "forced inclusion";
+pub const REASON_REVERTS_IN_PRE_SIMULATION: &str = "reverts in pre-simulation";
+pub const REASON_BELOW_INTRINSIC_GAS: &str = "gas limit below intrinsic gas";
+pub const REASON_OVER_MAX_TX_DATA_SIZE: &str = "calldata over max tx data size";
+pub const REASON_BLOB_TX: &str = "blob tx";
+
+#[derive(Serialize, Debug, Clone, PartialEq)]
+#[serde(rename_all = "snake_case")]
+pub enum TxSelectionDecision {
+    Included,
+    Dropped,
+}
+
+#[derive(Serialize, Debug, Clone)]
+pub struct TxSelection {
+    pub hash: String,
+    pub from: String,
+    pub nonce: u64,
+    pub decision: TxSelectionDecision,
+    pub reason: &'static str,
+}
+
+impl TxSelection {
+    pub fn new(tx: &Transaction, decision: TxSelectionDecision, reason: &'static str) -> Self {
+        Self {
+            hash: tx.inner.tx_hash().to_string(),
+            from: tx.inner.signer().to_string(),
+            nonce: tx.nonce(),
+            decision,
+            reason,
+        }
+    }
+}
+
+pub fn dropped_txs(
+    txs: &[Transaction],
+    dropped: &[bool],
+    reason: &'static str,
+) -> Vec<TxSelection> {
+    txs.iter()
+        .zip(dropped)
+        .filter(|(_, dropped)| **dropped)
+        .map(|(tx, _)| TxSelection::new(tx, TxSelectionDecision::Dropped, reason))
+        .collect()
+}
+
+#[derive(Serialize, Debug)]
+pub struct TxSelectionReport {
+    pub block_number: u64,
+    pub timestamp: u64,
+    pub is_forced_inclusion: bool,
+    pub txs: Vec<TxSelection>,
+}
+
+/// Writes a JSON artifact per preconfirmed L2 block with the selection decision
+/// for every candidate tx, for offline block building audits.
+pub struct TxSelectionReporter {
+    dir: PathBuf,
+    // txs dropped while building the last pending tx list
+    dropped_txs: Mutex<Vec<TxSelection>>,
+}
+
+impl TxSelectionReporter {
+    pub fn new(dir: &str) -> Result<Self, Error> {
+        std::fs::create_dir_all(dir).map_err(|e| {
+            anyhow::anyhow!("Failed to create tx selection report dir {}: {}", dir, e)
+        })?;
+        Ok(Self {
+            dir: PathBuf::from(dir),
+            dropped_txs: Mutex::new(Vec::new()),
+        })
+    }
+
+    /// Replaces the dropped txs recorded for the previously fetched pending tx list.
+    pub fn set_dropped_txs(&self, dropped_txs: Vec<TxSelection>) -> Result<(), Error> {
+        *self
+            .dropped_txs
+            .lock()
+            .map_err(|e| anyhow::anyhow!("Failed to lock dropped txs: {}", e))? = dropped_txs;
+        Ok(())
+    }
+
+    pub async fn write_report(
+        &self,
+        block_number: u64,
+        timestamp: u64,
+        is_forced_inclusion: bool,
+        included_txs: &[Transaction],
+    ) -> Result<PathBuf, Error> {
+        let reason = if is_forced_inclusion {
+            REASON_FORCED_INCLUSION
+        } else {
+            REASON_SELECTED_BY_TAIKO_GETH
+        };
+        let mut txs = included_txs
+            .iter()
+            .map(|tx| TxSelection::new(tx, TxSelectionDecision::Included, reason))
+            .collect::<Vec<_>>();
+        // dropped txs belong to the regular pending tx list, not to the forced inclusion
+        if !is_forced_inclusion {
+            txs.append(
+                &mut self
+                    .dropped_txs
+                    .lock()
+                    .map_err(|e| anyhow::anyhow!("Failed to lock dropped txs: {}", e))?,
+            );
+        }
+
+        let report = TxSelectionReport {
+            block_number,
+            timestamp,
+            is_forced_inclusion,
+            txs,
+        };
+        let path = report_path(&self.dir, block_number);
+        tokio::fs::write(&path, serde_json::to_vec_pretty(&report)?)
+            .await
+            .map_err(|e| {
+                anyhow::anyhow!(
+                    "Failed to write tx selection report {}: {}",
+                    path.display(),
+                    e
+                )
+            })?;
+        Ok(path)
+    }
+}
+
+fn report_path(dir: &Path, block_number: u64) -> PathBuf {
+    dir.join(format!("block_{block_number}.json"))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::shared::l2_tx_lists::PreBuiltTxList;
+    use serde_json::Value;
+    use tempfile::TempDir;
+
+    fn get_test_txs() -> Vec<Transaction> {
+        serde_json::from_str::<Vec<PreBuiltTxList>>(include_str!(
+            "../utils/tx_lists_test_response_from_geth.json"
+        ))
+        .unwrap()
+        .remove(0)
+        .tx_list
+    }
+
+    fn read_report(path: &Path) -> Value {
+        serde_json::from_slice(&std::fs::read(path).unwrap()).unwrap()
+    }
+
+    #[tokio::test]
+    async fn test_write_report_with_dropped_txs() {
+        let temp_dir = TempDir::new().unwrap();
+        let dir = temp_dir
+            .path()
+            .join("reports")
+            .to_string_lossy()
+            .to_string();
+        let reporter = TxSelectionReporter::new(&dir).unwrap();
+        let txs = get_test_txs();
+        reporter
+            .set_dropped_txs(vec![TxSelection::new(
+                &txs[1],
+                TxSelectionDecision::Dropped,
+                REASON_REVERTS_IN_PRE_SIMULATION,
+            )])
+            .unwrap();
+
+        let path = reporter
+            .write_report(10, 1000, false, &txs[..1])
+            .await
+            .unwrap();
+        assert_eq!(path, report_path(Path::new(&dir), 10));
+
+        let report = read_report(&path);
+        assert_eq!(report["block_number"], 10);
+        assert_eq!(report["timestamp"], 1000);
+        assert_eq!(report["is_forced_inclusion"], false);
+        let report_txs = report["txs"].as_array().unwrap();
+        assert_eq!(report_txs.len(), 2);
+        assert_eq!(report_txs[0]["hash"], txs[0].inner.tx_hash().to_string());
+        assert_eq!(report_txs[0]["from"], txs[0].inner.signer().to_string());
+        assert_eq!(report_txs[0]["nonce"], txs[0].nonce());
+        assert_eq!(report_txs[0]["decision"], "included");
+        assert_eq!(report_txs[0]["reason"], REASON_SELECTED_BY_TAIKO_GETH);
+        assert_eq!(report_txs[1]["hash"], txs[1].inner.tx_hash().to_string());
+        assert_eq!(report_txs[1]["decision"], "dropped");
+        assert_eq!(report_txs[1]["reason"], REASON_REVERTS_IN_PRE_SIMULATION);
+
+        // dropped txs are reported only once
+        let report = read_report(&reporter.write_report(11, 1002, false, &txs).await.unwrap());
+        let report_txs = report["txs"].as_array().unwrap();
+        assert_eq!(report_txs.len(), 2);
+        assert!(report_txs.iter().all(|tx| tx["decision"] == "included"));
+    }
+
+    #[tokio::test]
+    async fn test_write_report_for_forced_inclusion() {
+        let temp_dir = TempDir::new().unwrap();
+        let dir = temp_dir
+            .path()
+            .join("reports")
+            .to_string_lossy()
+            .to_string();
+        let reporter = TxSelectionReporter::new(&dir).unwrap();
+        let txs = get_test_txs();
+        reporter
+            .set_dropped_txs(vec![TxSelection::new(
+                &txs[1],
+                TxSelectionDecision::Dropped,
+                REASON_REVERTS_IN_PRE_SIMULATION,
+            )])
+            .unwrap();
+
+        let report = read_report(
+            &reporter
+                .write_report(12, 1004, true, &txs[..1])
+                .await
+                .unwrap(),
+        );
+        assert_eq!(report["is_forced_inclusion"], true);
+        let report_txs = report["txs"].as_array().unwrap();
+        assert_eq!(report_txs.len(), 1);
+        assert_eq!(report_txs[0]["decision"], "included");
+        assert_eq!(report_txs[0]["reason"], REASON_FORCED_INCLUSION);
+    }
+}
@@ -1,7 +1,16 @@
+use alloy::primitives::Address;
 use std::time::Duration;
 use tracing::{info, warn};
 
-use crate::utils::blob::constants::MAX_BLOB_DATA_SIZE;
+use crate::{
+    ethereum_l1::config::{BlobFeeFallback, L1BlockTag, NonceSource},
+    node::batch_manager::batch_metadata::BatchMetadata,
+    taiko::config::{
+        BlockValidationConfig, EqualTipOrder, FeeRecipientConfig, PreconfSummaryPushConfig,
+        TxSelectionConfig,
+    },
+    utils::blob::constants::MAX_BLOB_DATA_SIZE,
+};
 
 pub struct Config {
     pub preconfer_address: Option<String>,
@@ -14,9 +23,14 @@ pub struct Config {
     pub l1_beacon_url: String,
     pub web3signer_l1_url: Option<String>,
     pub web3signer_l2_url: Option<String>,
+    pub signer_fallback_private_key: Option<String>,
+    pub signer_fallback_retry_remote_sec: u64,
     pub l1_slot_duration_sec: u64,
     pub l1_slots_per_epoch: u64,
     pub preconf_heartbeat_ms: u64,
+    pub preconf_cycle_deadline_ms: u64,
+    pub l1_halt_timeout_sec: u64,
+    pub l2_halt_timeout_sec: u64,
     pub msg_expiry_sec: u64,
     pub contract_addresses: L1ContractAddresses,
     pub jwt_secret_file_path: String,
@@ -28,8 +42,19 @@ pub struct Config {
     pub handover_window_slots: u64,
     pub handover_start_buffer_ms: u64,
     pub l1_height_lag: u64,
+    pub l1_anchor_block_tag: L1BlockTag,
     pub max_bytes_size_of_batch: u64,
     pub max_blocks_per_batch: u16,
+    pub adaptive_min_blocks_per_batch: u16,
+    pub congested_l1_base_fee_wei: u128,
+    pub congested_l1_inclusion_delay_sec: u64,
+    pub max_sealed_batches: u64,
+    pub split_batches_exceeding_l1_gas_limit: bool,
+    pub shrink_batches_on_size_revert: bool,
+    pub preconf_to_anchor_latency_alert_sec: u64,
+    pub batch_metadata: BatchMetadata,
+    pub pre_seal_hook_url: Option<String>,
+    pub pre_seal_hook_timeout: Duration,
     pub max_time_shift_between_blocks_sec: u64,
     pub max_anchor_height_offset_reduction: u64,
     pub min_priority_fee_per_gas_wei: u64,
@@ -37,16 +62,47 @@ pub struct Config {
     pub max_attempts_to_send_tx: u64,
     pub max_attempts_to_wait_tx: u64,
     pub delay_between_tx_attempts_sec: u64,
+    pub max_attempts_to_resubmit_evicted_tx: u64,
+    pub evicted_tx_timeout_sec: u64,
+    pub tip_escalation_percentage_per_sec: u64,
+    pub tip_escalation_cap_percentage: u64,
+    pub receipt_batch_polling_interval_ms: u64,
+    pub signer_failure_threshold: u64,
+    pub signer_failure_backoff_sec: u64,
+    pub signer_failure_max_backoff_sec: u64,
+    pub follow_on_duty_loss: bool,
+    pub preconf_loop_stall_timeout_sec: u64,
+    pub max_reorgs_per_window: u64,
+    pub reorg_rate_window_sec: u64,
+    pub slot_report_file: Option<String>,
+    pub metrics_snapshot_file: Option<String>,
+    pub metrics_snapshot_timeout: Duration,
+    pub metrics_operator_label: Option<String>,
     pub threshold_eth: u128,
     pub threshold_taiko: u128,
+    pub min_l1_balance_for_proposing: u128,
     pub amount_to_bridge_from_l2_to_l1: u128,
     pub disable_bridging: bool,
     pub simulate_not_submitting_at_the_end_of_epoch: bool,
+    pub discard_unsafe_blocks_on_startup: bool,
     pub max_bytes_per_tx_list: u64,
     pub throttling_factor: u64,
     pub min_bytes_per_tx_list: u64,
+    pub tx_selection: TxSelectionConfig,
+    pub block_validation: BlockValidationConfig,
+    pub fee_recipients: FeeRecipientConfig,
+    pub preconf_summary_push: PreconfSummaryPushConfig,
+    pub driver_head_reconcile_blocks: u64,
+    pub engine_api_export_url: Option<String>,
+    pub engine_api_export_jwt_secret_file_path: String,
+    pub engine_api_export_timeout: Duration,
+    pub equivocation_guard_file: Option<String>,
+    pub propagate_trace_context: bool,
     pub propose_forced_inclusion: bool,
     pub extra_gas_percentage: u64,
+    pub validate_sender_authorization: bool,
+    pub blob_fee_fallback: BlobFeeFallback,
+    pub startup_nonce_source: NonceSource,
     pub preconf_min_txs: u64,
     pub preconf_max_skipped_l2_slots: u64,
     pub bridge_relayer_fee: u64,
@@ -96,6 +152,35 @@ impl Config {
             );
         }
 
+        // Signing with a local key when the web3signer is unavailable keeps the node live,
+        // but puts the key on the node host, so it has to be enabled explicitly
+        const SIGNER_FALLBACK_ENABLED: &str = "SIGNER_FALLBACK_ENABLED";
+        let signer_fallback_enabled = std::env::var(SIGNER_FALLBACK_ENABLED)
+            .unwrap_or("false".to_string())
+            .parse::<bool>()
+            .expect("SIGNER_FALLBACK_ENABLED must be a boolean");
+        const SIGNER_FALLBACK_ECDSA_PRIVATE_KEY: &str = "SIGNER_FALLBACK_ECDSA_PRIVATE_KEY";
+        let signer_fallback_private_key = if signer_fallback_enabled {
+            if web3signer_l1_url.is_none() {
+                panic!("{SIGNER_FALLBACK_ENABLED} requires the web3signer to be used");
+            }
+            Some(
+                std::env::var(SIGNER_FALLBACK_ECDSA_PRIVATE_KEY).unwrap_or_else(|_| {
+                    panic!(
+                        "When {SIGNER_FALLBACK_ENABLED} is set, {SIGNER_FALLBACK_ECDSA_PRIVATE_KEY} must be set"
+                    )
+                }),
+            )
+        } else {
+            None
+        };
+
+        // How often the web3signer is tried again while the fallback signer is in use
+        let signer_fallback_retry_remote_sec = std::env::var("SIGNER_FALLBACK_RETRY_REMOTE_SEC")
+            .unwrap_or("30".to_string())
+            .parse::<u64>()
+            .expect("SIGNER_FALLBACK_RETRY_REMOTE_SEC must be a number");
+
         const TAIKO_INBOX_ADDRESS: &str = "TAIKO_INBOX_ADDRESS";
         let taiko_inbox = std::env::var(TAIKO_INBOX_ADDRESS).unwrap_or_else(|_| {
             warn!(
@@ -185,6 +270,25 @@ impl Config {
             })
             .expect("PRECONF_HEARTBEAT_MS must be a number");
 
+        // Phases of a preconfirmation cycle exceeding it are skipped, 0 disables the deadline
+        let preconf_cycle_deadline_ms = std::env::var("PRECONF_CYCLE_DEADLINE_MS")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("PRECONF_CYCLE_DEADLINE_MS must be a number");
+
+        // The L1 halt pauses batch proposing, 0 disables the detection
+        let l1_halt_timeout_sec = std::env::var("L1_HALT_TIMEOUT_SEC")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("L1_HALT_TIMEOUT_SEC must be a number");
+
+        // Should be greater than MAX_TIME_SHIFT_BETWEEN_BLOCKS_SEC, L2 blocks can be skipped up to that time.
+        // The L2 halt pauses batch proposing, 0 disables the detection
+        let l2_halt_timeout_sec = std::env::var("L2_HALT_TIMEOUT_SEC")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("L2_HALT_TIMEOUT_SEC must be a number");
+
         let msg_expiry_sec = std::env::var("MSG_EXPIRY_SEC")
             .unwrap_or("3600".to_string())
             .parse::<u64>()
@@ -243,6 +347,16 @@ impl Config {
             .parse::<u64>()
             .expect("L1_HEIGHT_LAG must be a number");
 
+        let l1_anchor_block_tag = std::env::var("L1_ANCHOR_BLOCK_TAG")
+            .unwrap_or("latest".to_string())
+            .parse::<L1BlockTag>()
+            .expect("L1_ANCHOR_BLOCK_TAG must be one of latest, safe, finalized");
+        if l1_anchor_block_tag == L1BlockTag::Finalized {
+            warn!(
+                "L1_ANCHOR_BLOCK_TAG is finalized, the anchor block can lag several epochs behind the L1 head and exceed the max anchor height offset"
+            );
+        }
+
         let blobs_per_batch = std::env::var("BLOBS_PER_BATCH")
             .unwrap_or("3".to_string())
             .parse::<u64>()
@@ -258,6 +372,61 @@ impl Config {
             .parse::<u16>()
             .expect("MAX_BLOCKS_PER_BATCH must be a number");
 
+        // 0 disables the adjustment of max blocks per batch to the L1 congestion
+        let adaptive_min_blocks_per_batch = std::env::var("ADAPTIVE_MIN_BLOCKS_PER_BATCH")
+            .unwrap_or("0".to_string())
+            .parse::<u16>()
+            .expect("ADAPTIVE_MIN_BLOCKS_PER_BATCH must be a number");
+
+        // 20 Gwei
+        let congested_l1_base_fee_wei = std::env::var("CONGESTED_L1_BASE_FEE_WEI")
+            .unwrap_or("20000000000".to_string())
+            .parse::<u128>()
+            .expect("CONGESTED_L1_BASE_FEE_WEI must be a number");
+
+        let congested_l1_inclusion_delay_sec = std::env::var("CONGESTED_L1_INCLUSION_DELAY_SEC")
+            .unwrap_or("36".to_string())
+            .parse::<u64>()
+            .expect("CONGESTED_L1_INCLUSION_DELAY_SEC must be a number");
+
+        let pre_seal_hook_url = std::env::var("PRE_SEAL_HOOK_URL").ok();
+
+        let pre_seal_hook_timeout = std::env::var("PRE_SEAL_HOOK_TIMEOUT_MS")
+            .unwrap_or("1000".to_string())
+            .parse::<u64>()
+            .expect("PRE_SEAL_HOOK_TIMEOUT_MS must be a number");
+        let pre_seal_hook_timeout = Duration::from_millis(pre_seal_hook_timeout);
+
+        let max_sealed_batches = std::env::var("MAX_SEALED_BATCHES")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("MAX_SEALED_BATCHES must be a number");
+
+        let split_batches_exceeding_l1_gas_limit =
+            std::env::var("SPLIT_BATCHES_EXCEEDING_L1_GAS_LIMIT")
+                .unwrap_or("true".to_string())
+                .parse::<bool>()
+                .expect("SPLIT_BATCHES_EXCEEDING_L1_GAS_LIMIT must be a boolean");
+
+        // The limit of blocks per batch is lowered when a proposal reverts with TooManyBlocks
+        let shrink_batches_on_size_revert = std::env::var("SHRINK_BATCHES_ON_SIZE_REVERT")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("SHRINK_BATCHES_ON_SIZE_REVERT must be a boolean");
+
+        // Alert when preconfirmed L2 blocks anchor L1 blocks older than that, 0 disables the alert
+        let preconf_to_anchor_latency_alert_sec =
+            std::env::var("PRECONF_TO_ANCHOR_LATENCY_ALERT_SEC")
+                .unwrap_or("0".to_string())
+                .parse::<u64>()
+                .expect("PRECONF_TO_ANCHOR_LATENCY_ALERT_SEC must be a number");
+
+        // Comma separated key=value pairs logged with every batch submission, not posted on L1
+        let batch_metadata = std::env::var("BATCH_METADATA")
+            .unwrap_or_default()
+            .parse::<BatchMetadata>()
+            .expect("BATCH_METADATA must be comma separated key=value pairs");
+
         let max_time_shift_between_blocks_sec = std::env::var("MAX_TIME_SHIFT_BETWEEN_BLOCKS_SEC")
             .unwrap_or("255".to_string())
             .parse::<u64>()
@@ -307,6 +476,91 @@ impl Config {
             .parse::<u64>()
             .expect("DELAY_BETWEEN_TX_ATTEMPTS_SEC must be a number");
 
+        // How many times a tx dropped from the L1 mempool without being mined is resubmitted
+        let max_attempts_to_resubmit_evicted_tx =
+            std::env::var("MAX_ATTEMPTS_TO_RESUBMIT_EVICTED_TX")
+                .unwrap_or("2".to_string())
+                .parse::<u64>()
+                .expect("MAX_ATTEMPTS_TO_RESUBMIT_EVICTED_TX must be a number");
+
+        let evicted_tx_timeout_sec = std::env::var("EVICTED_TX_TIMEOUT_SEC")
+            .unwrap_or("36".to_string())
+            .parse::<u64>()
+            .expect("EVICTED_TX_TIMEOUT_SEC must be a number");
+
+        // Priority fee increase per second of pending time, 0 keeps the fixed bumps per attempt
+        let tip_escalation_percentage_per_sec = std::env::var("TIP_ESCALATION_PERCENTAGE_PER_SEC")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("TIP_ESCALATION_PERCENTAGE_PER_SEC must be a number");
+
+        // Upper bound of the escalated priority fee, as a percentage of the initial one
+        let tip_escalation_cap_percentage = std::env::var("TIP_ESCALATION_CAP_PERCENTAGE")
+            .unwrap_or("1000".to_string())
+            .parse::<u64>()
+            .expect("TIP_ESCALATION_CAP_PERCENTAGE must be a number");
+
+        // Interval of the batched receipt polling of the original tx and all its replacements,
+        // any of them can get mined. 0 waits for the receipt of the last sent tx only
+        let receipt_batch_polling_interval_ms = std::env::var("RECEIPT_BATCH_POLLING_INTERVAL_MS")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("RECEIPT_BATCH_POLLING_INTERVAL_MS must be a number");
+
+        // Consecutive signer failures before batch proposing is backed off, 0 disables the check
+        let signer_failure_threshold = std::env::var("SIGNER_FAILURE_THRESHOLD")
+            .unwrap_or("3".to_string())
+            .parse::<u64>()
+            .expect("SIGNER_FAILURE_THRESHOLD must be a number");
+
+        let signer_failure_backoff_sec = std::env::var("SIGNER_FAILURE_BACKOFF_SEC")
+            .unwrap_or("12".to_string())
+            .parse::<u64>()
+            .expect("SIGNER_FAILURE_BACKOFF_SEC must be a number");
+
+        let signer_failure_max_backoff_sec = std::env::var("SIGNER_FAILURE_MAX_BACKOFF_SEC")
+            .unwrap_or("384".to_string())
+            .parse::<u64>()
+            .expect("SIGNER_FAILURE_MAX_BACKOFF_SEC must be a number");
+
+        // Stop building and follow the chain when the preconfer duty is lost mid-sequencing
+        let follow_on_duty_loss = std::env::var("FOLLOW_ON_DUTY_LOSS")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("FOLLOW_ON_DUTY_LOSS must be a boolean");
+
+        // The node exits when the preconfirmation loop does not tick for so long, so the supervisor
+        // can restart it. Disabled by default (0), should be well above the heartbeat when set
+        let preconf_loop_stall_timeout_sec = std::env::var("PRECONF_LOOP_STALL_TIMEOUT_SEC")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("PRECONF_LOOP_STALL_TIMEOUT_SEC must be a number");
+
+        // Batch proposing is paused while there are more reorgs within the window, 0 disables the limit
+        let max_reorgs_per_window = std::env::var("MAX_REORGS_PER_WINDOW")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("MAX_REORGS_PER_WINDOW must be a number");
+
+        let reorg_rate_window_sec = std::env::var("REORG_RATE_WINDOW_SEC")
+            .unwrap_or("600".to_string())
+            .parse::<u64>()
+            .expect("REORG_RATE_WINDOW_SEC must be a number");
+
+        // A JSON line with the outcome of every preconfirmation loop step, disabled when not set
+        let slot_report_file = std::env::var("SLOT_REPORT_FILE").ok();
+
+        // Metrics are written to the file on graceful shutdown, disabled when not set
+        let metrics_snapshot_file = std::env::var("METRICS_SNAPSHOT_FILE").ok();
+        let metrics_snapshot_timeout = std::env::var("METRICS_SNAPSHOT_TIMEOUT_MS")
+            .unwrap_or("1000".to_string())
+            .parse::<u64>()
+            .expect("METRICS_SNAPSHOT_TIMEOUT_MS must be a number");
+        let metrics_snapshot_timeout = Duration::from_millis(metrics_snapshot_timeout);
+
+        // Added as the operator label to all metrics to tell apart the nodes in one Prometheus
+        let metrics_operator_label = std::env::var("METRICS_OPERATOR_LABEL").ok();
+
         // 0.5 ETH
         let threshold_eth =
             std::env::var("THRESHOLD_ETH").unwrap_or("500000000000000000".to_string());
@@ -321,6 +575,12 @@ impl Config {
             .parse::<u128>()
             .expect("THRESHOLD_TAIKO must be a number");
 
+        // Proposing is paused below this balance, 0 disables the check
+        let min_l1_balance_for_proposing = std::env::var("MIN_L1_BALANCE_FOR_PROPOSING")
+            .unwrap_or("0".to_string())
+            .parse::<u128>()
+            .expect("MIN_L1_BALANCE_FOR_PROPOSING must be a number");
+
         // 1 ETH
         let amount_to_bridge_from_l2_to_l1 = std::env::var("AMOUNT_TO_BRIDGE_FROM_L2_TO_L1")
             .unwrap_or("1000000000000000000".to_string())
@@ -338,11 +598,35 @@ impl Config {
                 .parse::<bool>()
                 .expect("SIMULATE_NOT_SUBMITTING_AT_THE_END_OF_EPOCH must be a boolean");
 
+        let discard_unsafe_blocks_on_startup = std::env::var("DISCARD_UNSAFE_BLOCKS_ON_STARTUP")
+            .unwrap_or("false".to_string())
+            .parse::<bool>()
+            .expect("DISCARD_UNSAFE_BLOCKS_ON_STARTUP must be a boolean");
+
         let propose_forced_inclusion = std::env::var("PROPOSE_FORCED_INCLUSION")
             .unwrap_or("true".to_string())
             .parse::<bool>()
             .expect("PROPOSE_FORCED_INCLUSION must be a boolean");
 
+        // Check once per epoch that the preconfer is allowed by the PreconfRouter to propose
+        let validate_sender_authorization = std::env::var("VALIDATE_SENDER_AUTHORIZATION")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("VALIDATE_SENDER_AUTHORIZATION must be a boolean");
+
+        // Used when the blob base fee can't be fetched: last_known proposes blobs with the doubled
+        // last known blob base fee, calldata proposes with calldata
+        let blob_fee_fallback = std::env::var("BLOB_FEE_FALLBACK")
+            .unwrap_or("last_known".to_string())
+            .parse::<BlobFeeFallback>()
+            .expect("BLOB_FEE_FALLBACK must be one of last_known, calldata");
+
+        // Nonce of the first submission after the startup, latest replaces the txs sent before the restart
+        let startup_nonce_source = std::env::var("STARTUP_NONCE_SOURCE")
+            .unwrap_or("pending".to_string())
+            .parse::<NonceSource>()
+            .expect("STARTUP_NONCE_SOURCE must be one of pending, latest");
+
         let max_bytes_per_tx_list = std::env::var("MAX_BYTES_PER_TX_LIST")
             .unwrap_or(MAX_BLOB_DATA_SIZE.to_string())
             .parse::<u64>()
@@ -359,6 +643,129 @@ impl Config {
             .parse::<u64>()
             .expect("MIN_BYTES_PER_TX_LIST must be a number");
 
+        let pre_simulate_txs = std::env::var("PRE_SIMULATE_TXS")
+            .unwrap_or("false".to_string())
+            .parse::<bool>()
+            .expect("PRE_SIMULATE_TXS must be a boolean");
+        if pre_simulate_txs {
+            warn!(
+                "PRE_SIMULATE_TXS is enabled, every pending L2 tx is simulated before preconfirmation. This increases the L2 block building time."
+            );
+        }
+
+        let drop_txs_below_intrinsic_gas = std::env::var("DROP_TXS_BELOW_INTRINSIC_GAS")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("DROP_TXS_BELOW_INTRINSIC_GAS must be a boolean");
+
+        // Blob txs can't be executed on L2, their blobs are not proposed with the batch
+        let drop_blob_txs = std::env::var("DROP_BLOB_TXS")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("DROP_BLOB_TXS must be a boolean");
+
+        let validate_gas_used = std::env::var("VALIDATE_GAS_USED")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("VALIDATE_GAS_USED must be a boolean");
+
+        // Check that a built block extends the current L2 head, costs one more geth call per block
+        let validate_block_number = std::env::var("VALIDATE_BLOCK_NUMBER")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("VALIDATE_BLOCK_NUMBER must be a boolean");
+
+        let validate_anchor_tx = std::env::var("VALIDATE_ANCHOR_TX")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("VALIDATE_ANCHOR_TX must be a boolean");
+
+        // Discard a preconfirmed block when the slot clock has already left its L2 slot
+        let validate_block_slot = std::env::var("VALIDATE_BLOCK_SLOT")
+            .unwrap_or("true".to_string())
+            .parse::<bool>()
+            .expect("VALIDATE_BLOCK_SLOT must be a boolean");
+
+        // Number of the last submitted blocks kept to submit them again when the driver
+        // head falls behind them, 0 disables the reconciliation
+        let driver_head_reconcile_blocks = std::env::var("DRIVER_HEAD_RECONCILE_BLOCKS")
+            .unwrap_or("32".to_string())
+            .parse::<u64>()
+            .expect("DRIVER_HEAD_RECONCILE_BLOCKS must be a number");
+
+        // Max number of txs taken from a single tx pool poll, the txs with the highest tips
+        // are taken and the rest stays in the pool for the next polls. 0 means no limit
+        let max_txs_per_poll = std::env::var("MAX_TXS_PER_POLL")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("MAX_TXS_PER_POLL must be a number");
+
+        // Lower bound of the txs taken from a poll when it is adjusted to the share of the
+        // taken txs used in the block, up to MAX_TXS_PER_POLL, 0 disables the adjustment
+        let adaptive_min_txs_per_poll = std::env::var("ADAPTIVE_MIN_TXS_PER_POLL")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("ADAPTIVE_MIN_TXS_PER_POLL must be a number");
+
+        // Order of the txs with the same tip in a block
+        let equal_tip_order = std::env::var("EQUAL_TIP_ORDER")
+            .unwrap_or("list".to_string())
+            .parse::<EqualTipOrder>()
+            .expect("EQUAL_TIP_ORDER must be one of list, tx_hash");
+
+        // Max calldata size of a single tx in bytes, larger txs stay in the pool. 0 means no limit
+        let max_tx_data_size = std::env::var("MAX_TX_DATA_SIZE")
+            .unwrap_or("0".to_string())
+            .parse::<u64>()
+            .expect("MAX_TX_DATA_SIZE must be a number");
+
+        let tx_selection_report_dir = std::env::var("TX_SELECTION_REPORT_DIR").ok();
+
+        // Defaults to the preconfer address
+        let fee_recipient = std::env::var("FEE_RECIPIENT").ok();
+        let fallback_fee_recipient = std::env::var("FALLBACK_FEE_RECIPIENT").ok().map(|address| {
+            address
+                .parse::<Address>()
+                .expect("FALLBACK_FEE_RECIPIENT must be a valid address")
+        });
+        let enforce_registered_fee_recipient = std::env::var("ENFORCE_REGISTERED_FEE_RECIPIENT")
+            .unwrap_or("false".to_string())
+            .parse::<bool>()
+            .expect("ENFORCE_REGISTERED_FEE_RECIPIENT must be a boolean");
+
+        let preconf_summary_push_url = std::env::var("PRECONF_SUMMARY_PUSH_URL").ok();
+        let preconf_summary_push_timeout = std::env::var("PRECONF_SUMMARY_PUSH_TIMEOUT_MS")
+            .unwrap_or("1000".to_string())
+            .parse::<u64>()
+            .expect("PRECONF_SUMMARY_PUSH_TIMEOUT_MS must be a number");
+        let preconf_summary_push_timeout = Duration::from_millis(preconf_summary_push_timeout);
+        let preconf_summary_push_max_attempts = std::env::var("PRECONF_SUMMARY_PUSH_MAX_ATTEMPTS")
+            .unwrap_or("3".to_string())
+            .parse::<u64>()
+            .expect("PRECONF_SUMMARY_PUSH_MAX_ATTEMPTS must be a number");
+
+        // Engine API endpoint of an execution client following the preconfirmed blocks
+        let engine_api_export_url = std::env::var("ENGINE_API_EXPORT_URL").ok();
+        let engine_api_export_jwt_secret_file_path =
+            std::env::var("ENGINE_API_EXPORT_JWT_SECRET_FILE_PATH")
+                .unwrap_or(jwt_secret_file_path.clone());
+        let engine_api_export_timeout = std::env::var("ENGINE_API_EXPORT_TIMEOUT_MS")
+            .unwrap_or("1000".to_string())
+            .parse::<u64>()
+            .expect("ENGINE_API_EXPORT_TIMEOUT_MS must be a number");
+        let engine_api_export_timeout = Duration::from_millis(engine_api_export_timeout);
+
+        // File keeping the last preconfirmed blocks, so a restarted node does not
+        // preconfirm a conflicting block for the same slot
+        let equivocation_guard_file = std::env::var("EQUIVOCATION_GUARD_FILE").ok();
+
+        // Sends the trace context of the preconfirmed block in the driver requests
+        // and adds its trace id to the logs of the block
+        let propagate_trace_context = std::env::var("PROPAGATE_TRACE_CONTEXT")
+            .unwrap_or("false".to_string())
+            .parse::<bool>()
+            .expect("PROPAGATE_TRACE_CONTEXT must be a boolean");
+
         let preconf_min_txs = std::env::var("PRECONF_MIN_TXS")
             .unwrap_or("3".to_string())
             .parse::<u64>()
@@ -401,9 +808,14 @@ impl Config {
                 .unwrap_or("http://127.0.0.1:4000".to_string()),
             web3signer_l1_url,
             web3signer_l2_url,
+            signer_fallback_private_key,
+            signer_fallback_retry_remote_sec,
             l1_slot_duration_sec,
             l1_slots_per_epoch,
             preconf_heartbeat_ms,
+            preconf_cycle_deadline_ms,
+            l1_halt_timeout_sec,
+            l2_halt_timeout_sec,
             msg_expiry_sec,
             contract_addresses,
             jwt_secret_file_path,
@@ -415,8 +827,19 @@ impl Config {
             handover_window_slots,
             handover_start_buffer_ms,
             l1_height_lag,
+            l1_anchor_block_tag,
             max_bytes_size_of_batch,
             max_blocks_per_batch,
+            adaptive_min_blocks_per_batch,
+            congested_l1_base_fee_wei,
+            congested_l1_inclusion_delay_sec,
+            max_sealed_batches,
+            split_batches_exceeding_l1_gas_limit,
+            shrink_batches_on_size_revert,
+            preconf_to_anchor_latency_alert_sec,
+            batch_metadata,
+            pre_seal_hook_url,
+            pre_seal_hook_timeout,
             max_time_shift_between_blocks_sec,
             max_anchor_height_offset_reduction,
             min_priority_fee_per_gas_wei,
@@ -424,16 +847,69 @@ impl Config {
             max_attempts_to_send_tx,
             max_attempts_to_wait_tx,
             delay_between_tx_attempts_sec,
+            max_attempts_to_resubmit_evicted_tx,
+            evicted_tx_timeout_sec,
+            tip_escalation_percentage_per_sec,
+            tip_escalation_cap_percentage,
+            receipt_batch_polling_interval_ms,
+            signer_failure_threshold,
+            signer_failure_backoff_sec,
+            signer_failure_max_backoff_sec,
+            follow_on_duty_loss,
+            preconf_loop_stall_timeout_sec,
+            max_reorgs_per_window,
+            reorg_rate_window_sec,
+            slot_report_file,
+            metrics_snapshot_file,
+            metrics_snapshot_timeout,
+            metrics_operator_label,
             threshold_eth,
             threshold_taiko,
+            min_l1_balance_for_proposing,
             amount_to_bridge_from_l2_to_l1,
             disable_bridging,
             simulate_not_submitting_at_the_end_of_epoch,
+            discard_unsafe_blocks_on_startup,
             max_bytes_per_tx_list,
             throttling_factor,
             min_bytes_per_tx_list,
+            tx_selection: TxSelectionConfig {
+                pre_simulate_txs,
+                drop_txs_below_intrinsic_gas,
+                drop_blob_txs,
+                max_tx_data_size,
+                max_txs_per_poll,
+                adaptive_min_txs_per_poll,
+                equal_tip_order,
+                report_dir: tx_selection_report_dir,
+            },
+            block_validation: BlockValidationConfig {
+                validate_gas_used,
+                validate_block_number,
+                validate_anchor_tx,
+                validate_block_slot,
+            },
+            fee_recipients: FeeRecipientConfig {
+                fee_recipient,
+                fallback_fee_recipient,
+                enforce_registered_fee_recipient,
+            },
+            preconf_summary_push: PreconfSummaryPushConfig {
+                url: preconf_summary_push_url,
+                timeout: preconf_summary_push_timeout,
+                max_attempts: preconf_summary_push_max_attempts,
+            },
+            driver_head_reconcile_blocks,
+            engine_api_export_url,
+            engine_api_export_jwt_secret_file_path,
+            engine_api_export_timeout,
+            equivocation_guard_file,
+            propagate_trace_context,
             propose_forced_inclusion,
             extra_gas_percentage,
+            validate_sender_authorization,
+            blob_fee_fallback,
+            startup_nonce_source,
             preconf_min_txs,
             preconf_max_skipped_l2_slots,
             bridge_relayer_fee,
@@ -451,9 +927,14 @@ L1 RPC URL: {},
 Consensus layer URL: {},
 Web3signer L1 URL: {},
 Web3signer L2 URL: {},
+signer fallback: {}
+signer fallback retry remote: {}s
 L1 slot duration: {}s
 L1 slots per epoch: {}
 L2 slot duration (heart beat): {}
+preconf cycle deadline: {}ms
+L1 halt timeout: {}s
+L2 halt timeout: {}s
 Preconf registry expiry: {}s
 Contract addresses: {:#?}
 jwt secret file path: {}
@@ -465,11 +946,46 @@ taiko bridge address: {}
 handover window slots: {}
 handover start buffer: {}ms
 l1 height lag: {}
+l1 anchor block tag: {}
 max bytes per tx list from taiko driver: {}
 throttling factor: {}
 min pending tx list size: {} bytes
+pre simulate txs: {}
+drop txs below intrinsic gas: {}
+drop blob txs: {}
+validate gas used: {}
+validate block number: {}
+validate anchor tx: {}
+validate block slot: {}
+driver head reconcile blocks: {}
+max txs per poll: {}
+adaptive min txs per poll: {}
+equal tip order: {}
+max tx data size: {} bytes
+tx selection report dir: {}
+fee recipient: {}
+fallback fee recipient: {}
+enforce registered fee recipient: {}
+preconf summary push url: {}
+preconf summary push timeout: {}ms
+preconf summary push max attempts: {}
+engine API export url: {}
+engine API export jwt secret file path: {}
+engine API export timeout: {}ms
+equivocation guard file: {}
+propagate trace context: {}
 max bytes size of batch: {}
 max blocks per batch value: {}
+adaptive min blocks per batch: {}
+congested l1 base fee: {} wei
+congested l1 inclusion delay: {}s
+max sealed batches: {}
+split batches exceeding l1 gas limit: {}
+shrink batches on size revert: {}
+preconf to anchor latency alert: {}s
+batch metadata: {}
+pre-seal hook url: {}
+pre-seal hook timeout: {}ms
 max time shift between blocks: {}s
 max anchor height offset reduction value: {}
 min priority fee per gas: {}wei
@@ -477,12 +993,33 @@ tx fees increase percentage: {}
 max attempts to send tx: {}
 max attempts to wait tx: {}
 delay between tx attempts: {}s
+max attempts to resubmit evicted tx: {}
+evicted tx timeout: {}s
+tip escalation: {}% per second
+tip escalation cap: {}%
+receipt batch polling interval: {}ms
+signer failure threshold: {}
+signer failure backoff: {}s
+signer failure max backoff: {}s
+follow on duty loss: {}
+preconf loop stall timeout: {}s
+max reorgs per window: {}
+reorg rate window: {}s
+slot report file: {}
+metrics snapshot file: {}
+metrics snapshot timeout: {}ms
+metrics operator label: {}
 threshold_eth: {}
 threshold_taiko: {}
+min l1 balance for proposing: {}
 amount to bridge from l2 to l1: {}
 disable bridging: {}
 simulate not submitting at the end of epoch: {}
+discard unsafe blocks on startup: {}
 propose_forced_inclusion: {}
+validate sender authorization: {}
+blob fee fallback: {}
+startup nonce source: {}
 min number of transaction to create a L2 block: {}
 max number of skipped L2 slots while creating a L2 block: {}
 bridge relayer fee: {}wei
@@ -508,9 +1045,18 @@ bridge transaction fee: {}wei
             config.l1_beacon_url,
             config.web3signer_l1_url.as_deref().unwrap_or("not set"),
             config.web3signer_l2_url.as_deref().unwrap_or("not set"),
+            if config.signer_fallback_private_key.is_some() {
+                "enabled"
+            } else {
+                "disabled"
+            },
+            config.signer_fallback_retry_remote_sec,
             config.l1_slot_duration_sec,
             config.l1_slots_per_epoch,
             config.preconf_heartbeat_ms,
+            config.preconf_cycle_deadline_ms,
+            config.l1_halt_timeout_sec,
+            config.l2_halt_timeout_sec,
             config.msg_expiry_sec,
             config.contract_addresses,
             config.jwt_secret_file_path,
@@ -522,11 +1068,64 @@ bridge transaction fee: {}wei
             config.handover_window_slots,
             config.handover_start_buffer_ms,
             config.l1_height_lag,
+            config.l1_anchor_block_tag,
             config.max_bytes_per_tx_list,
             config.throttling_factor,
             config.min_bytes_per_tx_list,
+            config.tx_selection.pre_simulate_txs,
+            config.tx_selection.drop_txs_below_intrinsic_gas,
+            config.tx_selection.drop_blob_txs,
+            config.block_validation.validate_gas_used,
+            config.block_validation.validate_block_number,
+            config.block_validation.validate_anchor_tx,
+            config.block_validation.validate_block_slot,
+            config.driver_head_reconcile_blocks,
+            config.tx_selection.max_txs_per_poll,
+            config.tx_selection.adaptive_min_txs_per_poll,
+            config.tx_selection.equal_tip_order,
+            config.tx_selection.max_tx_data_size,
+            config
+                .tx_selection
+                .report_dir
+                .as_deref()
+                .unwrap_or("not set"),
+            config
+                .fee_recipients
+                .fee_recipient
+                .as_deref()
+                .unwrap_or("not set"),
+            config
+                .fee_recipients
+                .fallback_fee_recipient
+                .map_or("not set".to_string(), |address| address.to_string()),
+            config.fee_recipients.enforce_registered_fee_recipient,
+            config
+                .preconf_summary_push
+                .url
+                .as_deref()
+                .unwrap_or("not set"),
+            config.preconf_summary_push.timeout.as_millis(),
+            config.preconf_summary_push.max_attempts,
+            config.engine_api_export_url.as_deref().unwrap_or("not set"),
+            config.engine_api_export_jwt_secret_file_path,
+            config.engine_api_export_timeout.as_millis(),
+            config
+                .equivocation_guard_file
+                .as_deref()
+                .unwrap_or("not set"),
+            config.propagate_trace_context,
             config.max_bytes_size_of_batch,
             config.max_blocks_per_batch,
+            config.adaptive_min_blocks_per_batch,
+            config.congested_l1_base_fee_wei,
+            config.congested_l1_inclusion_delay_sec,
+            config.max_sealed_batches,
+            config.split_batches_exceeding_l1_gas_limit,
+            config.shrink_batches_on_size_revert,
+            config.preconf_to_anchor_latency_alert_sec,
+            config.batch_metadata,
+            config.pre_seal_hook_url.as_deref().unwrap_or("not set"),
+            config.pre_seal_hook_timeout.as_millis(),
             config.max_time_shift_between_blocks_sec,
             config.max_anchor_height_offset_reduction,
             config.min_priority_fee_per_gas_wei,
@@ -534,12 +1133,36 @@ bridge transaction fee: {}wei
             config.max_attempts_to_send_tx,
             config.max_attempts_to_wait_tx,
             config.delay_between_tx_attempts_sec,
+            config.max_attempts_to_resubmit_evicted_tx,
+            config.evicted_tx_timeout_sec,
+            config.tip_escalation_percentage_per_sec,
+            config.tip_escalation_cap_percentage,
+            config.receipt_batch_polling_interval_ms,
+            config.signer_failure_threshold,
+            config.signer_failure_backoff_sec,
+            config.signer_failure_max_backoff_sec,
+            config.follow_on_duty_loss,
+            config.preconf_loop_stall_timeout_sec,
+            config.max_reorgs_per_window,
+            config.reorg_rate_window_sec,
+            config.slot_report_file.as_deref().unwrap_or("not set"),
+            config.metrics_snapshot_file.as_deref().unwrap_or("not set"),
+            config.metrics_snapshot_timeout.as_millis(),
+            config
+                .metrics_operator_label
+                .as_deref()
+                .unwrap_or("not set"),
             threshold_eth,
             threshold_taiko,
+            config.min_l1_balance_for_proposing,
             config.amount_to_bridge_from_l2_to_l1,
             config.disable_bridging,
             config.simulate_not_submitting_at_the_end_of_epoch,
+            config.discard_unsafe_blocks_on_startup,
             config.propose_forced_inclusion,
+            config.validate_sender_authorization,
+            config.blob_fee_fallback,
+            config.startup_nonce_source,
             config.preconf_min_txs,
             config.preconf_max_skipped_l2_slots,
             config.bridge_relayer_fee,
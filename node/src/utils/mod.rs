@@ -5,4 +5,5 @@ pub mod file_operations;
 mod retry;
 pub mod rpc_client;
 pub mod rpc_server;
+pub mod trace_context;
 pub mod types;
@@ -20,7 +20,7 @@ pub async fn backoff_retry_with_timeout<T, E, F, Fut>(
 where
     F: Fn() -> Fut,
     Fut: std::future::Future<Output = Result<T, E>>,
-    E: std::fmt::Display + Send + Sync + 'static,
+    E: Into<anyhow::Error>,
 {
     let start_time = SystemTime::now();
     let mut current_delay = base_delay;
@@ -32,18 +32,21 @@ where
             Duration::from_secs(0)
         }) >= timeout
         {
-            let error_msg = if let Some(ref err) = last_error {
-                format!("Operation timed out after {timeout:?}, last error: {err}")
-            } else {
-                format!("Operation timed out after {timeout:?}")
-            };
-            return Err(anyhow::anyhow!(error_msg));
+            // the last error stays in the chain for the caller to inspect
+            return Err(match last_error {
+                Some(err) => {
+                    let error_msg =
+                        format!("Operation timed out after {timeout:?}, last error: {err}");
+                    err.context(error_msg)
+                }
+                None => anyhow::anyhow!("Operation timed out after {timeout:?}"),
+            });
         }
 
         match operation().await {
             Ok(value) => return Ok(value),
             Err(e) => {
-                last_error = Some(anyhow::anyhow!("{}", e));
+                last_error = Some(e.into());
                 tokio::time::sleep(current_delay).await;
 
                 // Calculate next delay with exponential backoff
@@ -81,4 +84,22 @@ mod tests {
         assert!(result.is_err());
         assert!(result.unwrap_err().to_string().contains("test error"));
     }
+
+    #[tokio::test]
+    async fn backoff_retry_with_timeout_keeps_last_error_test() {
+        let result: Result<(), anyhow::Error> = backoff_retry_with_timeout(
+            || async { Err(std::io::Error::from(std::io::ErrorKind::ConnectionRefused)) },
+            Duration::from_millis(1),
+            Duration::from_millis(10),
+            Duration::from_millis(100),
+        )
+        .await;
+        assert_eq!(
+            result
+                .unwrap_err()
+                .downcast_ref::<std::io::Error>()
+                .map(std::io::Error::kind),
+            Some(std::io::ErrorKind::ConnectionRefused)
+        );
+    }
 }
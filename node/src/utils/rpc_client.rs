@@ -128,7 +128,8 @@ impl JSONRPCClient {
                         .await
                         .map_err(Error::from);
                 }
-                Err(anyhow::anyhow!("Http transport error: {err}."))
+                let error_msg = format!("Http transport error: {err}.");
+                Err(Error::from(JsonRpcError::Transport(err)).context(error_msg))
             }
             Err(err) => Err(Error::from(err)),
         }
@@ -148,7 +149,9 @@ impl JSONRPCClient {
         .await;
 
         result.map_err(|e| {
-            anyhow::anyhow!("JSONRPCClient: Failed to call method {method} with retry: {e}")
+            let error_msg =
+                format!("JSONRPCClient: Failed to call method {method} with retry: {e}");
+            e.context(error_msg)
         })
     }
 
@@ -227,6 +230,7 @@ impl HttpRPCClient {
         method: http::Method,
         endpoint: &str,
         payload: &T,
+        headers: &HeaderMap,
         max_duration: Duration,
     ) -> Result<Value, Error>
     where
@@ -234,7 +238,9 @@ impl HttpRPCClient {
     {
         let result = backoff_retry_with_timeout(
             || async {
-                let response = self.request_json(method.clone(), endpoint, payload).await;
+                let response = self
+                    .request_json(method.clone(), endpoint, payload, headers)
+                    .await;
 
                 if let Err(ref e) = response {
                     tracing::error!(
@@ -258,12 +264,14 @@ impl HttpRPCClient {
         })
     }
 
-    /// Send a request to the specified endpoint with the given method and payload
+    /// Send a request to the specified endpoint with the given method and payload,
+    /// `headers` are added to the default ones
     pub async fn request_json<T: Serialize>(
         &self,
         method: http::Method,
         endpoint: &str,
         payload: &T,
+        headers: &HeaderMap,
     ) -> Result<Value, Error> {
         let url = format!(
             "{}/{}",
@@ -276,6 +284,7 @@ impl HttpRPCClient {
             .read()
             .await
             .request(method.clone(), &url)
+            .headers(headers.clone())
             .json(payload)
             .send()
             .await
@@ -295,6 +304,7 @@ impl HttpRPCClient {
                 .read()
                 .await
                 .request(method, &url)
+                .headers(headers.clone())
                 .json(payload)
                 .send()
                 .await
@@ -0,0 +1,122 @@
+use alloy::primitives::keccak256;
+use anyhow::Error;
+use http::{HeaderMap, HeaderValue};
+use std::fmt;
+
+/// W3C trace context header
+pub const TRACEPARENT_HEADER: &str = "traceparent";
+
+/// Trace context of a preconfirmed L2 block. The ids are derived from the block,
+/// so every call made for the block, including retries, is linked to the same trace.
+#[derive(Clone, Debug, PartialEq)]
+pub struct TraceContext {
+    trace_id: [u8; 16],
+    span_id: [u8; 8],
+}
+
+impl TraceContext {
+    pub fn for_block(block_number: u64, parent_hash: &str) -> Self {
+        let hash = keccak256([&block_number.to_be_bytes(), parent_hash.as_bytes()].concat());
+        let mut trace_id = [0u8; 16];
+        trace_id.copy_from_slice(&hash[..16]);
+        let mut span_id = [0u8; 8];
+        span_id.copy_from_slice(&hash[16..24]);
+        Self { trace_id, span_id }
+    }
+
+    pub fn trace_id(&self) -> String {
+        hex::encode(self.trace_id)
+    }
+
+    pub fn to_headers(&self) -> Result<HeaderMap, Error> {
+        let mut headers = HeaderMap::new();
+        headers.insert(
+            TRACEPARENT_HEADER,
+            HeaderValue::from_str(&self.to_string())
+                .map_err(|e| anyhow::anyhow!("Failed to create traceparent header: {e}"))?,
+        );
+        Ok(headers)
+    }
+}
+
+impl fmt::Display for TraceContext {
+    // `traceparent` header value, version 00 with the sampled flag
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "00-{}-{}-01",
+            hex::encode(self.trace_id),
+            hex::encode(self.span_id)
+        )
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::utils::rpc_client::HttpRPCClient;
+    use std::time::Duration;
+
+    const PARENT_HASH: &str = "0x0101010101010101010101010101010101010101010101010101010101010101";
+
+    #[test]
+    fn test_trace_context_of_block() {
+        let trace_context = TraceContext::for_block(12, PARENT_HASH);
+        assert_eq!(trace_context, TraceContext::for_block(12, PARENT_HASH));
+        assert_ne!(trace_context, TraceContext::for_block(13, PARENT_HASH));
+
+        let traceparent = trace_context.to_string();
+        let parts: Vec<&str> = traceparent.split('-').collect();
+        assert_eq!(parts.len(), 4);
+        assert_eq!(parts[0], "00");
+        assert_eq!(parts[1], trace_context.trace_id());
+        assert_eq!(parts[1].len(), 32);
+        assert_eq!(parts[2].len(), 16);
+        assert_eq!(parts[3], "01");
+    }
+
+    #[tokio::test]
+    async fn test_traceparent_header_is_sent() {
+        let trace_context = TraceContext::for_block(12, PARENT_HASH);
+        let mut server = mockito::Server::new_async().await;
+        let traced = server
+            .mock("POST", "/preconfBlocks")
+            .match_header(TRACEPARENT_HEADER, trace_context.to_string().as_str())
+            .match_header("authorization", mockito::Matcher::Any)
+            .with_status(200)
+            .with_body("{}")
+            .create_async()
+            .await;
+        let untraced = server
+            .mock("GET", "/status")
+            .match_header(TRACEPARENT_HEADER, mockito::Matcher::Missing)
+            .with_status(200)
+            .with_body("{}")
+            .create_async()
+            .await;
+
+        let client =
+            HttpRPCClient::new_with_jwt(&server.url(), Duration::from_secs(1), &[0u8; 32]).unwrap();
+        client
+            .request_json(
+                http::Method::POST,
+                "preconfBlocks",
+                &serde_json::json!({}),
+                &trace_context.to_headers().unwrap(),
+            )
+            .await
+            .unwrap();
+        client
+            .request_json(
+                http::Method::GET,
+                "status",
+                &serde_json::json!({}),
+                &HeaderMap::new(),
+            )
+            .await
+            .unwrap();
+
+        traced.assert_async().await;
+        untraced.assert_async().await;
+    }
+}